@@ -1,10 +1,15 @@
 package config
 
 import (
+	"encoding/base64"
+
 	"github.com/NYTimes/gizmo/config"
 	"github.com/Sirupsen/logrus"
 )
 
+// secretsKeyLen is the length of the secrets key (AES-256)
+const secretsKeyLen = 32
+
 var (
 	// ValidStorageEngines contains the selectable storage engines
 	ValidStorageEngines = []string{"dummy", "boltdb"}
@@ -13,6 +18,9 @@ var (
 	resultBufferLenDefault     = 100
 	storageEngineDefault       = "boltdb"
 	apiResourcesPerPageDefault = 20
+	jobDefaultTimeoutDefault   = 2
+	workerPoolSizeDefault      = 50
+	hostRateBurstDefault       = 1
 )
 
 // Khronos holds the configuration of the main application
@@ -31,6 +39,32 @@ type Khronos struct {
 
 	//APIDisableSecurity Disable API security
 	APIDisableSecurity bool `envconfig:"KHRONOS_API_DISABLE_SECURITY"`
+
+	//JobDefaultTimeoutSeconds integer, the timeout of the jobs that don't have a custom one
+	JobDefaultTimeoutSeconds int `envconfig:"KHRONOS_JOB_DEFAULT_TIMEOUT_SECONDS"`
+
+	//WorkerPoolSize integer, the max number of concurrent job executions
+	WorkerPoolSize int `envconfig:"KHRONOS_WORKER_POOL_SIZE"`
+
+	//WorkerPoolMaxQueueWaitSeconds integer, the max wait of a run for a free worker
+	//before being dropped, 0 means no limit
+	WorkerPoolMaxQueueWaitSeconds int `envconfig:"KHRONOS_WORKER_POOL_MAX_QUEUE_WAIT_SECONDS"`
+
+	//HostRateLimit float, the max calls per second to the same destination host
+	//of the jobs, 0 means no limit
+	HostRateLimit float64 `envconfig:"KHRONOS_HOST_RATE_LIMIT"`
+
+	//HostRateBurst integer, the calls to the same destination host that can be
+	//made at once over the rate limit (default 1)
+	HostRateBurst int `envconfig:"KHRONOS_HOST_RATE_BURST"`
+
+	//HostMaxConcurrency integer, the max concurrent calls to the same
+	//destination host of the jobs, 0 means no limit
+	HostMaxConcurrency int `envconfig:"KHRONOS_HOST_MAX_CONCURRENCY"`
+
+	//SecretsKey string, base64 encoded 32 byte key used to encrypt the secrets of
+	//the jobs, empty disables the secrets
+	SecretsKey string `envconfig:"KHRONOS_SECRETS_KEY"`
 }
 
 // LoadKhronosConfig Loads the configuration for the application
@@ -69,6 +103,25 @@ func (k *Khronos) LoadKhronosConfig(cfg *AppConfig) {
 	if k.APIDisableSecurity {
 		logrus.Warning("Security of the API is disabled!")
 	}
+
+	logrus.Infof("Set default job timeout to %ds", cfg.JobDefaultTimeoutSeconds)
+	logrus.Infof("Set worker pool size to %d", cfg.WorkerPoolSize)
+	if k.WorkerPoolMaxQueueWaitSeconds > 0 {
+		logrus.Infof("Set worker pool max queue wait to %ds", cfg.WorkerPoolMaxQueueWaitSeconds)
+	}
+	if k.HostRateLimit > 0 {
+		logrus.Infof("Set host rate limit to %v calls/s with a burst of %d", cfg.HostRateLimit, cfg.HostRateBurst)
+	}
+	if k.HostMaxConcurrency > 0 {
+		logrus.Infof("Set host max concurrency to %d", cfg.HostMaxConcurrency)
+	}
+
+	// Check secrets key
+	if k.SecretsKey == "" {
+		logrus.Warning("Secrets are disabled, no secrets key")
+	} else if key, err := base64.StdEncoding.DecodeString(k.SecretsKey); err != nil || len(key) != secretsKeyLen {
+		logrus.Fatalf("Incorrect secrets key, should be %d bytes base64 encoded", secretsKeyLen)
+	}
 }
 
 // LoadDefaults loads defaults settings
@@ -84,4 +137,16 @@ func (k *Khronos) LoadDefaults() {
 	if k.APIResourcesPerPage == 0 {
 		k.APIResourcesPerPage = apiResourcesPerPageDefault
 	}
+
+	if k.JobDefaultTimeoutSeconds == 0 {
+		k.JobDefaultTimeoutSeconds = jobDefaultTimeoutDefault
+	}
+
+	if k.WorkerPoolSize <= 0 {
+		k.WorkerPoolSize = workerPoolSizeDefault
+	}
+
+	if k.HostRateBurst <= 0 {
+		k.HostRateBurst = hostRateBurstDefault
+	}
 }
@@ -0,0 +1,168 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// AssertionRegex checks that the response body matches a regular expression
+	AssertionRegex = "regex"
+	// AssertionJSONPath checks that the value of a JSONPath on the response body is equal to a value
+	AssertionJSONPath = "jsonpath"
+	// AssertionContains checks that the response body contains a substring
+	AssertionContains = "contains"
+)
+
+// StatusRange is a range of HTTP status codes, both limits included
+type StatusRange struct {
+	From int
+	To   int
+}
+
+// Contains checks if the status code is in the range
+func (s StatusRange) Contains(code int) bool {
+	return code >= s.From && code <= s.To
+}
+
+// Assertion is a check made on the response body of a job execution
+type Assertion struct {
+	Type string
+	// Path is the JSONPath of the value to check (only jsonpath assertions)
+	Path string
+	// Value is the regex, the expected value or the substring based on the type
+	Value string
+}
+
+// String returns a readable representation of the assertion
+func (a *Assertion) String() string {
+	if a.Type == AssertionJSONPath {
+		return fmt.Sprintf("%s %s == %q", a.Type, a.Path, a.Value)
+	}
+	return fmt.Sprintf("%s %q", a.Type, a.Value)
+}
+
+// Check checks the assertion against a response body, returns an error if
+// the assertion fails
+func (a *Assertion) Check(body []byte) error {
+	switch a.Type {
+	case AssertionRegex:
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return err
+		}
+		if !re.Match(body) {
+			return errors.New("body doesn't match")
+		}
+	case AssertionContains:
+		if !strings.Contains(string(body), a.Value) {
+			return errors.New("body doesn't contain the value")
+		}
+	case AssertionJSONPath:
+		var data interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return fmt.Errorf("body is not valid json: %v", err)
+		}
+		v, err := JSONPathValue(data, a.Path)
+		if err != nil {
+			return err
+		}
+		// Strings are compared as they are, the rest as json
+		got, ok := v.(string)
+		if !ok {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+			got = string(b)
+		}
+		if got != a.Value {
+			return fmt.Errorf("got %q", got)
+		}
+	default:
+		return fmt.Errorf("unknown assertion type '%s'", a.Type)
+	}
+	return nil
+}
+
+// ParseJSONPath splits a JSONPath in its steps, only a subset of JSONPath is
+// supported: root ($), child keys (.key or ['key']) and array indexes ([0]).
+// example: $.items[0].status
+func ParseJSONPath(path string) ([]string, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, errors.New("JSONPath should start with '$'")
+	}
+
+	steps := []string{}
+	p := path[1:]
+	for len(p) > 0 {
+		switch {
+		case p[0] == '.':
+			p = p[1:]
+			i := strings.IndexAny(p, ".[")
+			if i == -1 {
+				i = len(p)
+			}
+			if i == 0 {
+				return nil, errors.New("JSONPath has an empty key")
+			}
+			steps = append(steps, p[:i])
+			p = p[i:]
+		case strings.HasPrefix(p, "['"):
+			i := strings.Index(p, "']")
+			if i == -1 {
+				return nil, errors.New("JSONPath has an unclosed key")
+			}
+			steps = append(steps, p[2:i])
+			p = p[i+2:]
+		case p[0] == '[':
+			i := strings.Index(p, "]")
+			if i == -1 {
+				return nil, errors.New("JSONPath has an unclosed index")
+			}
+			if _, err := strconv.Atoi(p[1:i]); err != nil {
+				return nil, errors.New("JSONPath has a not valid index")
+			}
+			// Indexes are kept with the brackets to know they are not keys
+			steps = append(steps, p[:i+1])
+			p = p[i+1:]
+		default:
+			return nil, fmt.Errorf("JSONPath has an unexpected character '%c'", p[0])
+		}
+	}
+	return steps, nil
+}
+
+// JSONPathValue returns the value of a JSONPath from decoded json data
+func JSONPathValue(data interface{}, path string) (interface{}, error) {
+	steps, err := ParseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := data
+	for _, s := range steps {
+		if strings.HasPrefix(s, "[") {
+			i, _ := strconv.Atoi(s[1 : len(s)-1])
+			arr, ok := v.([]interface{})
+			if !ok || i < 0 || i >= len(arr) {
+				return nil, fmt.Errorf("%s not found", path)
+			}
+			v = arr[i]
+			continue
+		}
+
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s not found", path)
+		}
+		if v, ok = obj[s]; !ok {
+			return nil, fmt.Errorf("%s not found", path)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,76 @@
+package job
+
+import "testing"
+
+func TestAssertionCheck(t *testing.T) {
+	body := []byte(`{"status": "ok", "count": 3, "done": true, "items": [{"id": "a"}, {"id": "b"}], "the key": "v"}`)
+
+	tests := []struct {
+		givenAssertion *Assertion
+		wantError      bool
+	}{
+		{givenAssertion: &Assertion{Type: AssertionContains, Value: `"status": "ok"`}, wantError: false},
+		{givenAssertion: &Assertion{Type: AssertionContains, Value: `"status": "error"`}, wantError: true},
+		{givenAssertion: &Assertion{Type: AssertionRegex, Value: `"count": \d+`}, wantError: false},
+		{givenAssertion: &Assertion{Type: AssertionRegex, Value: `^ok$`}, wantError: true},
+		{givenAssertion: &Assertion{Type: AssertionJSONPath, Path: "$.status", Value: "ok"}, wantError: false},
+		{givenAssertion: &Assertion{Type: AssertionJSONPath, Path: "$.status", Value: "error"}, wantError: true},
+		{givenAssertion: &Assertion{Type: AssertionJSONPath, Path: "$.count", Value: "3"}, wantError: false},
+		{givenAssertion: &Assertion{Type: AssertionJSONPath, Path: "$.done", Value: "true"}, wantError: false},
+		{givenAssertion: &Assertion{Type: AssertionJSONPath, Path: "$.items[1].id", Value: "b"}, wantError: false},
+		{givenAssertion: &Assertion{Type: AssertionJSONPath, Path: "$['the key']", Value: "v"}, wantError: false},
+		{givenAssertion: &Assertion{Type: AssertionJSONPath, Path: "$.items[2].id", Value: "c"}, wantError: true},
+		{givenAssertion: &Assertion{Type: AssertionJSONPath, Path: "$.missing", Value: "ok"}, wantError: true},
+		{givenAssertion: &Assertion{Type: "wrong", Value: "ok"}, wantError: true},
+	}
+
+	for _, test := range tests {
+		err := test.givenAssertion.Check(body)
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should fail", test.givenAssertion)
+		}
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not fail: %v", test.givenAssertion, err)
+		}
+	}
+}
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		givenPath string
+		wantSteps []string
+		wantError bool
+	}{
+		{givenPath: "$", wantSteps: []string{}},
+		{givenPath: "$.status", wantSteps: []string{"status"}},
+		{givenPath: "$.items[0].id", wantSteps: []string{"items", "[0]", "id"}},
+		{givenPath: "$['a b'].c", wantSteps: []string{"a b", "c"}},
+		{givenPath: "status", wantError: true},
+		{givenPath: "$..status", wantError: true},
+		{givenPath: "$.items[a]", wantError: true},
+		{givenPath: "$.items[0", wantError: true},
+	}
+
+	for _, test := range tests {
+		steps, err := ParseJSONPath(test.givenPath)
+		if test.wantError {
+			if err == nil {
+				t.Errorf("'%s' should raise error", test.givenPath)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("'%s' should not raise error: %v", test.givenPath, err)
+			continue
+		}
+		if len(steps) != len(test.wantSteps) {
+			t.Errorf("'%s' steps should be %v; got %v", test.givenPath, test.wantSteps, steps)
+			continue
+		}
+		for i := range steps {
+			if steps[i] != test.wantSteps[i] {
+				t.Errorf("'%s' steps should be %v; got %v", test.givenPath, test.wantSteps, steps)
+			}
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package job
+
+import (
+	"time"
+)
+
+const (
+	// CalendarExclude skips the activations of a job inside the calendar windows
+	CalendarExclude = "exclude"
+	// CalendarInclude skips the activations of a job outside the calendar windows
+	CalendarInclude = "include"
+)
+
+// Calendar is a set of dates and time ranges (e.g holidays, change freezes)
+// that jobs can reference to skip some of their activations
+type Calendar struct {
+	ID          int
+	Name        string
+	Description string
+	// TimeZone is where the whole day windows are evaluated
+	TimeZone string
+	Windows  []*Window
+}
+
+// Window is a time range of a calendar, the end is not included
+type Window struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Window returns the window of the calendar that contains a time, nil if none
+func (c *Calendar) Window(t time.Time) *Window {
+	for _, w := range c.Windows {
+		if !t.Before(w.Start) && t.Before(w.End) {
+			return w
+		}
+	}
+	return nil
+}
+
+// CalendarRule is a calendar referenced by a job and how its windows apply to
+// the job activations
+type CalendarRule struct {
+	CalendarID int
+	Mode       string
+}
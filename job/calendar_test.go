@@ -0,0 +1,36 @@
+package job
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarWindow(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2017, time.December, d, h, 0, 0, 0, time.UTC) }
+	cal := &Calendar{Windows: []*Window{
+		{Name: "christmas", Start: day(25, 0), End: day(26, 0)},
+		{Name: "freeze", Start: day(28, 9), End: day(28, 18)},
+	}}
+
+	tests := []struct {
+		givenTime  time.Time
+		wantWindow string
+	}{
+		{givenTime: day(24, 23), wantWindow: ""},
+		{givenTime: day(25, 0), wantWindow: "christmas"},
+		{givenTime: day(25, 23), wantWindow: "christmas"},
+		{givenTime: day(26, 0), wantWindow: ""},
+		{givenTime: day(28, 12), wantWindow: "freeze"},
+		{givenTime: day(28, 18), wantWindow: ""},
+	}
+
+	for _, test := range tests {
+		got := ""
+		if w := cal.Window(test.givenTime); w != nil {
+			got = w.Name
+		}
+		if got != test.wantWindow {
+			t.Errorf("Window of %v should be '%s'; got '%s'", test.givenTime, test.wantWindow, got)
+		}
+	}
+}
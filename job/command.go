@@ -0,0 +1,11 @@
+package job
+
+// Command is the local command that a command job runs
+type Command struct {
+	// Path is the name or the path of the executable
+	Path string
+	Args []string
+	Env  map[string]string
+	// Dir is the working directory, empty means the khronos one
+	Dir string
+}
@@ -0,0 +1,14 @@
+package job
+
+// HostLimit are the limits of the job executions that call the same
+// destination host (host of the job URL), zero values mean no limit
+type HostLimit struct {
+	Host string
+	// Rate is the number of calls per second, the calls over the rate wait for
+	// their turn (token bucket)
+	Rate float64
+	// Burst is the number of calls that can be made at once over the rate
+	Burst int
+	// MaxConcurrency is the max number of calls running at the same time
+	MaxConcurrency int
+}
@@ -3,33 +3,163 @@ package job
 import (
 	"encoding/json"
 	"net/url"
+	"time"
 )
 
-// Job is the unit of job to be executed periodically making an HTTP call
+const (
+	// TypeHTTP is the type of the jobs that make an HTTP call
+	TypeHTTP = "http"
+	// TypeCommand is the type of the jobs that run a local command
+	TypeCommand = "command"
+)
+
+const (
+	// ConcurrencyAllow allows concurrent executions of the same job
+	ConcurrencyAllow = "allow"
+	// ConcurrencyForbid skips the new execution if the previous one is still running
+	ConcurrencyForbid = "forbid"
+	// ConcurrencyReplace cancels the running execution and starts the new one
+	ConcurrencyReplace = "replace"
+)
+
+const (
+	// CatchUpSkip skips the missed runs of a job after a downtime
+	CatchUpSkip = "skip"
+	// CatchUpOnce runs the job once to catch up the missed runs after a downtime
+	CatchUpOnce = "once"
+	// CatchUpAll runs the job for every missed run after a downtime
+	CatchUpAll = "all"
+)
+
+const (
+	// JitterRandom delays each execution of a job a random time of its jitter window
+	JitterRandom = "random"
+	// JitterHash delays the executions of a job a stable time of its jitter
+	// window based on the job ID
+	JitterHash = "hash"
+)
+
+// DefaultMethod is the HTTP method used when a job doesn't set one
+const DefaultMethod = "GET"
+
+// Job is the unit of job to be executed periodically making an HTTP call or
+// running a local command based on its type
 type Job struct {
-	ID          int
+	ID int
+	// Namespace is the namespace of the job, empty means the default one
+	Namespace   string
 	Name        string
 	Description string
-	When        string
-	Active      bool
-	URL         *url.URL
+	// Labels are free-form key/value pairs to organize and select the jobs
+	Labels map[string]string
+	When   string
+	// TimeZone is the tz database name of the location where When is evaluated,
+	// empty means the server local time
+	TimeZone string
+	// At is the time of the single execution of a one-shot job, nil means a
+	// recurring job scheduled with When
+	At *time.Time `json:",omitempty"`
+	// Completed flag is up when the one-shot job has been executed
+	Completed bool
+	Active    bool
+	// Priority orders the runs waiting for a free worker of the execution pool,
+	// higher first
+	Priority int
+	// Concurrency is the policy of overlapping executions, empty means allow
+	Concurrency string
+	// CatchUp is the policy of the missed runs after a downtime, empty means skip
+	CatchUp string
+	// StartingDeadline is the max delay of a missed run to be caught up, older
+	// missed runs are abandoned, 0 means no deadline
+	StartingDeadline time.Duration
+	// LastScheduledRun is the last activation time of the job schedule, used to
+	// know the missed runs after a downtime
+	LastScheduledRun *time.Time `json:",omitempty"`
+	// DependsOn are the upstream jobs that trigger the job when they end, all of
+	// them need to end matching their condition on the same workflow run
+	DependsOn []*Dependency
+	// Jitter is the max delay of the executions after the schedule activation
+	// to spread out the jobs with the same schedule, 0 means no delay
+	Jitter time.Duration
+	// JitterMode is how the delay of the jitter window is chosen, empty means random
+	JitterMode string
+	// Calendars are the calendars that skip the activations of the job inside
+	// (exclude) or outside (include) their windows
+	Calendars []*CalendarRule
+	// Type is the type of the job, empty means HTTP
+	Type    string
+	Command *Command
+	URL     *url.URL
+	// Template flag is up when the URL, header values and body are templates
+	// rendered on each execution, otherwise they are sent as they are
+	Template bool
+	// URLTemplate is the URL of the templated job when it has template
+	// actions. URL is nil for these jobs
+	URLTemplate string `json:"-"`
+	Method      string
+	Headers     map[string]string
+	Body        string
+	// Variables are the custom values of the job available on its templates
+	Variables map[string]string
+	// Timeout is the max duration of the job execution, 0 means the default one
+	Timeout time.Duration
+	// Retry is the retry policy of the failed executions, nil means no retries
+	Retry *Retry
+	// SuccessCodes are the HTTP status codes that mean the job went ok, if
+	// empty 2xx will be ok, 5xx error and the rest unknown
+	SuccessCodes []StatusRange
+	// Assertions are the checks on the response body for the job to be ok
+	Assertions []*Assertion
+
+	// NextRun and PrevRun are the computed next and previous executions by the
+	// schedule of the registered job, nil means none. Only set on API responses
+	NextRun *time.Time `json:",omitempty"`
+	PrevRun *time.Time `json:",omitempty"`
 
 	// Don't link results on instance, isn't a requirement, get results from
 	// storage client with the job instance
 	//results []*Result
 }
 
+// NamespaceOrDefault returns the namespace of the job, the default one if not set
+func (j *Job) NamespaceOrDefault() string {
+	if j.Namespace == "" {
+		return DefaultNamespace
+	}
+	return j.Namespace
+}
+
+// OneShot returns true if the job is executed once at a specific time instead
+// of periodically
+func (j *Job) OneShot() bool {
+	return j.At != nil
+}
+
+// Triggered returns true if the job doesn't have schedule and only runs when
+// triggered by its upstream jobs
+func (j *Job) Triggered() bool {
+	return j.When == "" && !j.OneShot()
+}
+
 // MarshalJSON is a custom json marshaller for Job
 func (j *Job) MarshalJSON() ([]byte, error) {
 	// Alias is a custom type to inherint all the properties of Job but not the methods
 	type Alias Job
 
+	// Not HTTP jobs don't have URL, the templated URLs are stored as they are
+	var u string
+	if j.URLTemplate != "" {
+		u = j.URLTemplate
+	} else if j.URL != nil {
+		u = j.URL.String()
+	}
+
 	return json.Marshal(struct {
 		*Alias
 		URL string
 	}{
 		Alias: (*Alias)(j),
-		URL:   j.URL.String(),
+		URL:   u,
 	})
 }
 
@@ -49,6 +179,13 @@ func (j *Job) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// Templated URLs are only valid after rendering them
+	if j.Template && HasTemplate(aux.URL) {
+		j.URL = nil
+		j.URLTemplate = aux.URL
+		return nil
+	}
+
 	sURL, err := url.Parse(aux.URL)
 	if err != nil {
 		return err
@@ -0,0 +1,17 @@
+package job
+
+import (
+	"time"
+)
+
+// DefaultNamespace is the namespace of the jobs that don't set one, the data
+// stored before the namespaces belongs to it
+const DefaultNamespace = "default"
+
+// Namespace isolates the jobs, their results and the authentication tokens
+// of a team from the other teams
+type Namespace struct {
+	Name        string
+	Description string
+	CreatedAt   time.Time
+}
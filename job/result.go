@@ -1,6 +1,9 @@
 package job
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 const (
 	// ResultOK means that the result was ok
@@ -11,14 +14,84 @@ const (
 	ResultInternalError
 	// ResultUnknow means that the result was not clear how it ended
 	ResultUnknow
+	// ResultSkipped means that the job was not executed
+	ResultSkipped
+	// ResultCanceled means that the job execution was stopped before it ended
+	ResultCanceled
+	// ResultRunning means that the job execution has not finished yet
+	ResultRunning
+	// ResultAbandoned means that the missed run was not caught up because it
+	// was older than the starting deadline
+	ResultAbandoned
+	// ResultDropped means that the run waited too long for a free worker of
+	// the execution pool
+	ResultDropped
 )
 
 // Result has the result of a job
 type Result struct {
-	Job    *Job
-	ID     int
+	Job *Job
+	ID  int
+	// RunID is the unique identifier of the execution, set before running it
+	// while the ID is set when stored
+	RunID  string
+	Out    string
+	Status int
+	// Scheduled is the activation time of the schedule, the execution Start
+	// could be later (e.g jitter), zero means not run by the schedule
+	Scheduled time.Time
+	Start     time.Time
+	Finish    time.Time
+	// Stderr and ExitCode are only set by the command jobs, Out has the stdout
+	Stderr   string
+	ExitCode int
+	// Attempts are the executions made when the job has a retry policy
+	Attempts []*Attempt
+	// Manual flag is up when the execution was triggered on demand and not by
+	// the job schedule
+	Manual bool
+	// CatchUp flag is up when the execution is a missed run after a downtime
+	CatchUp bool
+	// WorkflowRun is the workflow run of the execution, 0 means none
+	WorkflowRun int
+	// HostWait is the time the execution waited for the limits of its
+	// destination host
+	HostWait time.Duration
+	// Request is the HTTP request made by the execution after rendering the
+	// job templates, nil for the not HTTP jobs
+	Request *Request `json:",omitempty"`
+
+	// Context is the context of the execution, when is done the execution
+	// should stop, nil means never
+	Context context.Context `json:"-"`
+}
+
+// Ctx returns the context of the execution
+func (r *Result) Ctx() context.Context {
+	if r.Context == nil {
+		return context.Background()
+	}
+	return r.Context
+}
+
+// Attempt has the result of a single execution of a job run
+type Attempt struct {
+	Number int
 	Out    string
 	Status int
 	Start  time.Time
 	Finish time.Time
+	// Stderr and ExitCode are only set by the command jobs
+	Stderr   string
+	ExitCode int
+}
+
+// Executed checks if the job was executed for the result, the skipped,
+// abandoned and dropped runs never started the job
+func (r *Result) Executed() bool {
+	switch r.Status {
+	case ResultSkipped, ResultAbandoned, ResultDropped:
+		return false
+	}
+	return true
 }
@@ -0,0 +1,44 @@
+package job
+
+import "time"
+
+// Retry is the retry policy of a job, it will retry the failed executions of
+// a job waiting between them with an exponential backoff
+type Retry struct {
+	// MaxAttempts is the max number of executions of a run (first one included)
+	MaxAttempts int
+	// Backoff is the wait before the first retry
+	Backoff time.Duration
+	// Factor multiplies the wait after each retry
+	Factor float64
+	// MaxBackoff is the max wait between retries, 0 means no limit
+	MaxBackoff time.Duration
+	// On are the result statuses that will be retried
+	On []int
+}
+
+// Retryable checks if a result status should be retried
+func (r *Retry) Retryable(status int) bool {
+	for _, s := range r.On {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait returns the wait before the retry of the attempt number n (starting on 1)
+func (r *Retry) Wait(n int) time.Duration {
+	w := float64(r.Backoff)
+	for i := 1; i < n; i++ {
+		w = w * r.Factor
+		// Don't overflow
+		if r.MaxBackoff > 0 && w > float64(r.MaxBackoff) {
+			return r.MaxBackoff
+		}
+	}
+	if r.MaxBackoff > 0 && w > float64(r.MaxBackoff) {
+		return r.MaxBackoff
+	}
+	return time.Duration(w)
+}
@@ -0,0 +1,28 @@
+package job
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryWait(t *testing.T) {
+	tests := []struct {
+		givenRetry *Retry
+		givenN     int
+		wantWait   time.Duration
+	}{
+		{givenRetry: &Retry{Backoff: time.Second, Factor: 2}, givenN: 1, wantWait: time.Second},
+		{givenRetry: &Retry{Backoff: time.Second, Factor: 2}, givenN: 2, wantWait: 2 * time.Second},
+		{givenRetry: &Retry{Backoff: time.Second, Factor: 2}, givenN: 4, wantWait: 8 * time.Second},
+		{givenRetry: &Retry{Backoff: time.Second, Factor: 1}, givenN: 4, wantWait: time.Second},
+		{givenRetry: &Retry{Backoff: time.Second, Factor: 2, MaxBackoff: 5 * time.Second}, givenN: 4, wantWait: 5 * time.Second},
+		{givenRetry: &Retry{Backoff: 10 * time.Second, Factor: 2, MaxBackoff: 5 * time.Second}, givenN: 1, wantWait: 5 * time.Second},
+	}
+
+	for _, test := range tests {
+		got := test.givenRetry.Wait(test.givenN)
+		if got != test.wantWait {
+			t.Errorf("Wait of attempt %d should be %v; got %v", test.givenN, test.wantWait, got)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package job
+
+import (
+	"time"
+)
+
+// Secret is a sensitive value (e.g tokens, passwords) that the jobs reference
+// by name on their templates, e.g {{secret "api-token"}}
+type Secret struct {
+	Name        string
+	Description string
+	// Value is the encrypted value of the secret, never returned by the API
+	Value     []byte `json:",omitempty"`
+	UpdatedAt time.Time
+}
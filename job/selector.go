@@ -0,0 +1,148 @@
+package job
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// SelectorEquals matches the labels with the key and the value
+	SelectorEquals = "="
+	// SelectorNotEquals matches the labels without the key and the value
+	SelectorNotEquals = "!="
+	// SelectorIn matches the labels with the key and one of the values
+	SelectorIn = "in"
+	// SelectorNotIn matches the labels without the key and any of the values
+	SelectorNotIn = "notin"
+	// SelectorExists matches the labels with the key
+	SelectorExists = "exists"
+	// SelectorNotExists matches the labels without the key
+	SelectorNotExists = "!"
+)
+
+var setRequirementRe = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\((.*)\)$`)
+
+// Requirement is a condition of a label selector on the labels of a job
+type Requirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// Selector selects the jobs by their labels, a job is selected when its labels
+// match all the requirements. An empty selector selects all the jobs
+type Selector []*Requirement
+
+// Matches checks if the requirement matches the labels
+func (r *Requirement) Matches(labels map[string]string) bool {
+	v, ok := labels[r.Key]
+	switch r.Operator {
+	case SelectorEquals, SelectorIn:
+		return ok && r.hasValue(v)
+	case SelectorNotEquals, SelectorNotIn:
+		return !ok || !r.hasValue(v)
+	case SelectorExists:
+		return ok
+	case SelectorNotExists:
+		return !ok
+	}
+	return false
+}
+
+// hasValue checks if a value is one of the requirement values
+func (r *Requirement) hasValue(value string) bool {
+	for _, v := range r.Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches checks if all the requirements of the selector match the labels
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSelector parses a Kubernetes style label selector, the requirements are
+// separated by commas, e.g team=payments,env!=staging,tier in (web,api),!legacy
+func ParseSelector(text string) (Selector, error) {
+	s := Selector{}
+	for _, p := range splitSelector(text) {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+
+		r, err := parseRequirement(p)
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, r)
+	}
+	return s, nil
+}
+
+// splitSelector splits a selector on its requirements, the commas of the
+// value sets are not separators
+func splitSelector(text string) []string {
+	parts := []string{}
+	depth, start := 0, 0
+	for i, c := range text {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// parseRequirement parses a single requirement of a label selector
+func parseRequirement(text string) (*Requirement, error) {
+	r := &Requirement{}
+	switch {
+	case setRequirementRe.MatchString(text):
+		m := setRequirementRe.FindStringSubmatch(text)
+		r.Key, r.Operator = m[1], m[2]
+		for _, v := range strings.Split(m[3], ",") {
+			r.Values = append(r.Values, strings.TrimSpace(v))
+		}
+	case strings.HasPrefix(text, "!"):
+		r.Key, r.Operator = strings.TrimSpace(text[1:]), SelectorNotExists
+	case strings.Contains(text, "!="):
+		kv := strings.SplitN(text, "!=", 2)
+		r.Key, r.Operator, r.Values = strings.TrimSpace(kv[0]), SelectorNotEquals, []string{strings.TrimSpace(kv[1])}
+	case strings.Contains(text, "="):
+		kv := strings.SplitN(strings.Replace(text, "==", "=", 1), "=", 2)
+		r.Key, r.Operator, r.Values = strings.TrimSpace(kv[0]), SelectorEquals, []string{strings.TrimSpace(kv[1])}
+	default:
+		r.Key, r.Operator = text, SelectorExists
+	}
+
+	if r.Key == "" || strings.ContainsAny(r.Key, " \t=!(),") {
+		return nil, fmt.Errorf("not valid label key on requirement '%s'", text)
+	}
+	for _, v := range r.Values {
+		if strings.ContainsAny(v, " \t=!(),") {
+			return nil, fmt.Errorf("not valid label value on requirement '%s'", text)
+		}
+	}
+	if (r.Operator == SelectorIn || r.Operator == SelectorNotIn) && len(r.Values) == 0 {
+		return nil, errors.New("empty label values")
+	}
+	return r, nil
+}
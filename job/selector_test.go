@@ -0,0 +1,87 @@
+package job
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		givenSelector string
+		wantSelector  Selector
+		wantError     bool
+	}{
+		{
+			givenSelector: "",
+			wantSelector:  Selector{},
+		},
+		{
+			givenSelector: "team=payments,env!=staging",
+			wantSelector: Selector{
+				{Key: "team", Operator: SelectorEquals, Values: []string{"payments"}},
+				{Key: "env", Operator: SelectorNotEquals, Values: []string{"staging"}},
+			},
+		},
+		{
+			givenSelector: " tier in (web, api) , region notin (eu),example.org/owner,!legacy,app==billing,empty=",
+			wantSelector: Selector{
+				{Key: "tier", Operator: SelectorIn, Values: []string{"web", "api"}},
+				{Key: "region", Operator: SelectorNotIn, Values: []string{"eu"}},
+				{Key: "example.org/owner", Operator: SelectorExists},
+				{Key: "legacy", Operator: SelectorNotExists},
+				{Key: "app", Operator: SelectorEquals, Values: []string{"billing"}},
+				{Key: "empty", Operator: SelectorEquals, Values: []string{""}},
+			},
+		},
+		{givenSelector: "=payments", wantError: true},
+		{givenSelector: "team=pay=ments", wantError: true},
+		{givenSelector: "tier in (web", wantError: true},
+		{givenSelector: "!", wantError: true},
+	}
+
+	for _, test := range tests {
+		got, err := ParseSelector(test.givenSelector)
+		if test.wantError {
+			if err == nil {
+				t.Errorf("'%s' should raise error", test.givenSelector)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("'%s' should not raise error: %v", test.givenSelector, err)
+		}
+		if !reflect.DeepEqual(got, test.wantSelector) {
+			t.Errorf("Selectors are not equal; expected %+v; got %+v", test.wantSelector, got)
+		}
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "env": "production", "tier": "web"}
+
+	tests := []struct {
+		givenSelector string
+		want          bool
+	}{
+		{givenSelector: "", want: true},
+		{givenSelector: "team=payments,env!=staging", want: true},
+		{givenSelector: "team=payments,env!=production", want: false},
+		{givenSelector: "tier in (web,api)", want: true},
+		{givenSelector: "tier notin (web,api)", want: false},
+		{givenSelector: "region notin (eu)", want: true},
+		{givenSelector: "region!=eu", want: true},
+		{givenSelector: "region", want: false},
+		{givenSelector: "!region,team", want: true},
+		{givenSelector: "!team", want: false},
+	}
+
+	for _, test := range tests {
+		s, err := ParseSelector(test.givenSelector)
+		if err != nil {
+			t.Fatalf("'%s' should not raise error: %v", test.givenSelector, err)
+		}
+		if got := s.Matches(labels); got != test.want {
+			t.Errorf("'%s' match should be %t; got %t", test.givenSelector, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package job
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateFuncs are the functions available on the job templates besides the
+// text/template builtins (e.g urlquery, printf)
+var templateFuncs = template.FuncMap{
+	// date formats a time with a Go layout, e.g {{.ScheduledTime | date "2006-01-02"}}
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+	// add adds a duration to a time, e.g {{.ScheduledTime | add "-24h" | date "2006-01-02"}}
+	"add": func(d string, t time.Time) (time.Time, error) {
+		dd, err := time.ParseDuration(d)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.Add(dd), nil
+	},
+	// unix returns the unix seconds of a time
+	"unix": func(t time.Time) int64 {
+		return t.Unix()
+	},
+}
+
+// TemplateData are the values of an execution available on the job templates
+type TemplateData struct {
+	JobID   int
+	JobName string
+	// RunID is the unique identifier of the execution
+	RunID string
+	// ScheduledTime is the activation time of the schedule or the execution
+	// start when not run by the schedule
+	ScheduledTime time.Time
+	// Vars are the custom variables of the job
+	Vars map[string]string
+
+	// Secret returns the value of a secret by name for the secret function of
+	// the templates, nil means that the secrets can't be used
+	Secret func(name string) (string, error)
+}
+
+// Request is the HTTP request made by an execution of a job after rendering
+// its templates
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+}
+
+// HasTemplate returns true if the text has template actions
+func HasTemplate(text string) bool {
+	return strings.Contains(text, "{{")
+}
+
+// RenderTemplate renders a job template with the values of an execution, using
+// a missing variable is an error. The secrets are referenced by name with the
+// secret function, e.g {{secret "api-token"}}
+func RenderTemplate(text string, data *TemplateData) (string, error) {
+	t, err := template.New("job").Funcs(templateFuncs).Funcs(template.FuncMap{
+		"secret": func(name string) (string, error) {
+			if data.Secret == nil {
+				return "", errors.New("secrets not available")
+			}
+			return data.Secret(name)
+		},
+	}).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// RenderRequest renders the templates of the job URL, header values and body
+// with the values of an execution and returns the HTTP request to make. The
+// request of the not templated jobs is returned as it is
+func (j *Job) RenderRequest(data *TemplateData) (*Request, error) {
+	u := j.URLTemplate
+	if u == "" && j.URL != nil {
+		u = j.URL.String()
+	}
+	m := j.Method
+	if m == "" {
+		m = DefaultMethod
+	}
+	if !j.Template {
+		return &Request{Method: m, URL: u, Headers: j.Headers, Body: j.Body}, nil
+	}
+
+	u, err := RenderTemplate(u, data)
+	if err != nil {
+		return nil, err
+	}
+	// The rendered URL should be valid
+	if _, err := url.ParseRequestURI(u); err != nil {
+		return nil, err
+	}
+
+	var hs map[string]string
+	if j.Headers != nil {
+		hs = make(map[string]string, len(j.Headers))
+		for k, v := range j.Headers {
+			if hs[k], err = RenderTemplate(v, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	b, err := RenderTemplate(j.Body, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Request{Method: m, URL: u, Headers: hs, Body: b}, nil
+}
+
+// SecretNames returns the names of the secrets referenced by the templates of
+// the job ordered by name, none if the job isn't templated
+func (j *Job) SecretNames() []string {
+	if !j.Template {
+		return []string{}
+	}
+
+	found := map[string]bool{}
+	data := &TemplateData{
+		Vars: j.Variables,
+		Secret: func(name string) (string, error) {
+			found[name] = true
+			return "", nil
+		},
+	}
+
+	// Each template on its own, the errors of one don't hide the others
+	ts := []string{j.URLTemplate, j.Body}
+	for _, v := range j.Headers {
+		ts = append(ts, v)
+	}
+	for _, t := range ts {
+		RenderTemplate(t, data)
+	}
+
+	names := []string{}
+	for n := range found {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,124 @@
+package job
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRenderRequest(t *testing.T) {
+	data := &TemplateData{
+		JobID:         7,
+		JobName:       "reports",
+		RunID:         "8a1f3c",
+		ScheduledTime: time.Date(2017, time.December, 25, 2, 0, 0, 0, time.UTC),
+		Vars:          map[string]string{"team": "billing"},
+	}
+	u, _ := url.Parse("https://svc.test.com/reports")
+
+	tests := []struct {
+		givenJob    *Job
+		wantRequest *Request
+		wantError   bool
+	}{
+		{
+			givenJob:    &Job{URL: u, Body: `{"a": {"b": 1}}`, Template: true},
+			wantRequest: &Request{Method: "GET", URL: "https://svc.test.com/reports", Body: `{"a": {"b": 1}}`},
+		},
+		{
+			// Not templated jobs are sent as they are
+			givenJob:    &Job{URL: u, Headers: map[string]string{"X-Run": "{{.RunID}}"}, Body: `{{"a": 1}}`},
+			wantRequest: &Request{Method: "GET", URL: "https://svc.test.com/reports", Headers: map[string]string{"X-Run": "{{.RunID}}"}, Body: `{{"a": 1}}`},
+		},
+		{
+			givenJob: &Job{
+				Template:    true,
+				URLTemplate: `https://svc.test.com/{{.Vars.team}}/reports?date={{.ScheduledTime | add "-24h" | date "2006-01-02"}}`,
+				Method:      "POST",
+				Headers:     map[string]string{"X-Run": "{{.RunID}}", "X-Unix": "{{.ScheduledTime | unix}}"},
+				Body:        `{"job": {{.JobID}}, "name": "{{.JobName}}"}`,
+				Variables:   map[string]string{"team": "billing"},
+			},
+			wantRequest: &Request{
+				Method:  "POST",
+				URL:     "https://svc.test.com/billing/reports?date=2017-12-24",
+				Headers: map[string]string{"X-Run": "8a1f3c", "X-Unix": "1514167200"},
+				Body:    `{"job": 7, "name": "reports"}`,
+			},
+		},
+		{
+			givenJob:  &Job{URLTemplate: "https://svc.test.com/{{.Vars.missing}}", Template: true},
+			wantError: true,
+		},
+		{
+			givenJob:  &Job{URLTemplate: "{{.JobName}}", Template: true},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := test.givenJob.RenderRequest(data)
+		if test.wantError {
+			if err == nil {
+				t.Errorf("Expected error rendering %#v", test.givenJob)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Didn't expect error: %v", err)
+		}
+		if !reflect.DeepEqual(got, test.wantRequest) {
+			t.Errorf("Request should be %#v; got %#v", test.wantRequest, got)
+		}
+	}
+}
+
+func TestJobURLTemplateJSON(t *testing.T) {
+	tmpl := "https://svc.test.com/{{.Vars.team}}/reports"
+	j := &Job{ID: 1, URLTemplate: tmpl, Template: true}
+
+	b, err := json.Marshal(j)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	got := &Job{}
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if got.URL != nil || got.URLTemplate != tmpl {
+		t.Errorf("Job should have the URL template %s; got %v, %s", tmpl, got.URL, got.URLTemplate)
+	}
+
+	// The URL of the not templated jobs is a literal one
+	j.Template = false
+	b, _ = json.Marshal(j)
+	got = &Job{}
+	if err := json.Unmarshal(b, got); err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if got.URL == nil || got.URLTemplate != "" {
+		t.Errorf("Job should have the URL %s; got %v, %s", tmpl, got.URL, got.URLTemplate)
+	}
+}
+
+func TestJobSecretNames(t *testing.T) {
+	j := &Job{
+		Template:    true,
+		URLTemplate: `https://svc.test.com/reports?key={{secret "api-key"}}`,
+		Headers:     map[string]string{"Authorization": `Bearer {{secret "api-token"}}`, "X-Team": "{{.Vars.missing}}"},
+		Body:        `{"user": "{{secret "api-user"}}", "password": "{{secret "api-token"}}"}`,
+	}
+
+	want := []string{"api-key", "api-token", "api-user"}
+	if got := j.SecretNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Secret names should be %v; got %v", want, got)
+	}
+
+	// Not templated jobs don't reference secrets
+	j.Template = false
+	if got := j.SecretNames(); len(got) != 0 {
+		t.Errorf("Secret names should be empty; got %v", got)
+	}
+}
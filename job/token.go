@@ -0,0 +1,177 @@
+package job
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+const (
+	// RoleViewer can read the resources
+	RoleViewer = "viewer"
+	// RoleOperator can read the resources and manage the jobs and results
+	RoleOperator = "operator"
+	// RoleAdmin can do everything
+	RoleAdmin = "admin"
+)
+
+const (
+	// PermissionRead allows reading the resources
+	PermissionRead = "read"
+	// PermissionOperate allows creating, updating, deleting and running the
+	// jobs and deleting their results
+	PermissionOperate = "operate"
+	// PermissionAdmin allows managing the namespaces, calendars, host limits
+	// and secrets
+	PermissionAdmin = "admin"
+)
+
+// AllNamespaces is the namespace scope of the tokens that can access every
+// namespace
+const AllNamespaces = "*"
+
+const (
+	// tokenIDLen is the number of random bytes of the token IDs
+	tokenIDLen = 8
+	// tokenSecretLen is the number of random bytes of the token secrets
+	tokenSecretLen = 32
+	// tokenSaltLen is the number of random bytes of the salt of the hashes
+	tokenSaltLen = 16
+	// tokenSeparator separates the ID from the random part of the secrets
+	tokenSeparator = "_"
+)
+
+// rolePermissions are the permissions granted by every role
+var rolePermissions = map[string][]string{
+	RoleViewer:   {PermissionRead},
+	RoleOperator: {PermissionRead, PermissionOperate},
+	RoleAdmin:    {PermissionRead, PermissionOperate, PermissionAdmin},
+}
+
+// Token is an authentication token of the API, it belongs to a namespace and
+// its role grants the permissions on the resources. Only the salted hash of
+// the secret is stored, the secret is returned once when the token is created
+type Token struct {
+	// ID identifies the token, the secret starts with it
+	ID   string
+	Name string
+	Role string
+	// Namespaces are the other namespaces the token can access besides its
+	// own, AllNamespaces for every one. Only used by the tokens of the
+	// default namespace
+	Namespaces []string `json:",omitempty"`
+	// Selector is the label selector of the jobs the token can access, empty
+	// means all the jobs
+	Selector string `json:",omitempty"`
+	// Salt and Hash are the salted SHA-256 hash of the secret, never returned
+	// by the API
+	Salt []byte `json:",omitempty"`
+	Hash []byte `json:",omitempty"`
+	// Legacy flag is up on the tokens migrated from the clear text ones, their
+	// secrets don't start with the ID
+	Legacy    bool `json:",omitempty"`
+	CreatedAt time.Time
+	// LastUsedAt is the last time the token authenticated a request
+	LastUsedAt *time.Time `json:",omitempty"`
+	// ExpiresAt is the time the token stops being valid, nil means never
+	ExpiresAt *time.Time `json:",omitempty"`
+}
+
+// NewTokenID returns a new random token ID
+func NewTokenID() (string, error) {
+	return randomHex(tokenIDLen)
+}
+
+// TokenID returns the ID of a token secret, empty if the secret doesn't have
+// one (e.g the tokens stored before the hashes)
+func TokenID(secret string) string {
+	if i := strings.Index(secret, tokenSeparator); i > 0 {
+		return secret[:i]
+	}
+	return ""
+}
+
+// Generate sets a new ID and a new random secret on the token and returns the
+// secret, the secret can't be obtained again from the token
+func (t *Token) Generate() (string, error) {
+	id, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+	r, err := randomHex(tokenSecretLen)
+	if err != nil {
+		return "", err
+	}
+
+	secret := id + tokenSeparator + r
+	if err := t.SetSecret(secret); err != nil {
+		return "", err
+	}
+	t.ID = id
+	return secret, nil
+}
+
+// SetSecret stores the salted hash of a secret on the token
+func (t *Token) SetSecret(secret string) error {
+	salt := make([]byte, tokenSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	t.Salt = salt
+	t.Hash = hashSecret(salt, secret)
+	return nil
+}
+
+// Verify checks if the secret is the one of the token
+func (t *Token) Verify(secret string) bool {
+	if len(t.Hash) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare(t.Hash, hashSecret(t.Salt, secret)) == 1
+}
+
+// Expired checks if the token isn't valid at a time
+func (t *Token) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Can checks if the role of the token grants the permission
+func (t *Token) Can(permission string) bool {
+	for _, p := range rolePermissions[t.Role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// InNamespaceScope checks if the token can access a namespace that isn't its
+// own one
+func (t *Token) InNamespaceScope(ns string) bool {
+	for _, n := range t.Namespaces {
+		if n == ns || n == AllNamespaces {
+			return true
+		}
+	}
+	return false
+}
+
+// hashSecret returns the SHA-256 hash of the salted secret
+func hashSecret(salt []byte, secret string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(secret))
+	return h.Sum(nil)
+}
+
+// randomHex returns n random bytes hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,113 @@
+package job
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenCan(t *testing.T) {
+	tests := []struct {
+		givenRole       string
+		givenPermission string
+		want            bool
+	}{
+		{givenRole: RoleViewer, givenPermission: PermissionRead, want: true},
+		{givenRole: RoleViewer, givenPermission: PermissionOperate, want: false},
+		{givenRole: RoleOperator, givenPermission: PermissionOperate, want: true},
+		{givenRole: RoleOperator, givenPermission: PermissionAdmin, want: false},
+		{givenRole: RoleAdmin, givenPermission: PermissionAdmin, want: true},
+		{givenRole: "", givenPermission: PermissionRead, want: false},
+		{givenRole: "root", givenPermission: PermissionRead, want: false},
+	}
+
+	for _, test := range tests {
+		tk := &Token{ID: "123456789", Role: test.givenRole}
+		if got := tk.Can(test.givenPermission); got != test.want {
+			t.Errorf("Role '%s' with permission '%s' should be %t; got %t", test.givenRole, test.givenPermission, test.want, got)
+		}
+	}
+}
+
+func TestTokenInNamespaceScope(t *testing.T) {
+	tests := []struct {
+		givenNamespaces []string
+		givenNamespace  string
+		want            bool
+	}{
+		{givenNamespaces: nil, givenNamespace: "payments", want: false},
+		{givenNamespaces: []string{"payments"}, givenNamespace: "payments", want: true},
+		{givenNamespaces: []string{"payments"}, givenNamespace: "search", want: false},
+		{givenNamespaces: []string{AllNamespaces}, givenNamespace: "search", want: true},
+	}
+
+	for _, test := range tests {
+		tk := &Token{ID: "123456789", Role: RoleAdmin, Namespaces: test.givenNamespaces}
+		if got := tk.InNamespaceScope(test.givenNamespace); got != test.want {
+			t.Errorf("Scope %v with namespace '%s' should be %t; got %t", test.givenNamespaces, test.givenNamespace, test.want, got)
+		}
+	}
+}
+
+func TestTokenGenerate(t *testing.T) {
+	tk := &Token{Name: "ci", Role: RoleOperator}
+	secret, err := tk.Generate()
+	if err != nil {
+		t.Fatalf("Secret should be generated: %v", err)
+	}
+
+	if tk.ID == "" || TokenID(secret) != tk.ID {
+		t.Errorf("Secret should start with the token ID; got '%s' and '%s'", secret, tk.ID)
+	}
+	if strings.Contains(string(tk.Hash), secret) || len(tk.Salt) == 0 {
+		t.Error("Token should only have the salted hash of the secret")
+	}
+	if !tk.Verify(secret) {
+		t.Error("Token should verify its secret")
+	}
+	if tk.Verify(secret+"0") || tk.Verify(tk.ID) {
+		t.Error("Token shouldn't verify other secrets")
+	}
+
+	// The same secret has a different hash on other tokens
+	other := &Token{}
+	other.SetSecret(secret)
+	if string(other.Hash) == string(tk.Hash) {
+		t.Error("Hashes of the same secret should be salted")
+	}
+	if (&Token{}).Verify("") {
+		t.Error("Token without hash shouldn't verify any secret")
+	}
+}
+
+func TestTokenID(t *testing.T) {
+	tests := []struct {
+		givenSecret string
+		wantID      string
+	}{
+		{givenSecret: "3c1d5a8e0b7f2964_e01357", wantID: "3c1d5a8e0b7f2964"},
+		{givenSecret: "123456789", wantID: ""},
+		{givenSecret: "_123456789", wantID: ""},
+	}
+
+	for _, test := range tests {
+		if got := TokenID(test.givenSecret); got != test.wantID {
+			t.Errorf("ID of secret '%s' should be '%s'; got '%s'", test.givenSecret, test.wantID, got)
+		}
+	}
+}
+
+func TestTokenExpired(t *testing.T) {
+	now := time.Date(2017, time.December, 25, 0, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	if (&Token{}).Expired(now) {
+		t.Error("Token without expiration shouldn't expire")
+	}
+	if (&Token{ExpiresAt: &later}).Expired(now) {
+		t.Error("Token shouldn't be expired before its expiration")
+	}
+	if !(&Token{ExpiresAt: &now}).Expired(now) {
+		t.Error("Token should be expired on its expiration")
+	}
+}
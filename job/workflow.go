@@ -0,0 +1,88 @@
+package job
+
+import (
+	"time"
+)
+
+const (
+	// TriggerSuccess runs the downstream job when the upstream job ends ok
+	TriggerSuccess = "success"
+	// TriggerFailure runs the downstream job when the upstream job doesn't end ok
+	TriggerFailure = "failure"
+	// TriggerAlways runs the downstream job when the upstream job ends in any way
+	TriggerAlways = "always"
+)
+
+const (
+	// WorkflowRunning means that the workflow run has jobs running
+	WorkflowRunning = "running"
+	// WorkflowSucceeded means that all the executed jobs of the workflow run ended ok
+	WorkflowSucceeded = "succeeded"
+	// WorkflowFailed means that any executed job of the workflow run didn't end ok
+	WorkflowFailed = "failed"
+)
+
+// Dependency is an upstream job of a job and the condition of its result to
+// trigger the job
+type Dependency struct {
+	JobID int
+	On    string
+}
+
+// Triggers checks if the result status of the upstream job triggers the
+// downstream job. Skipped upstream jobs only trigger always dependencies
+func (d *Dependency) Triggers(status int) bool {
+	switch d.On {
+	case TriggerAlways:
+		return true
+	case TriggerFailure:
+		return status != ResultOK && status != ResultSkipped
+	default:
+		return status == ResultOK
+	}
+}
+
+// WorkflowRun is an execution of the jobs of a workflow, started by a job
+// execution that isn't part of another workflow run (e.g scheduled) and
+// followed by the executions of its downstream jobs
+type WorkflowRun struct {
+	ID int
+	// JobID is the job that started the workflow run
+	JobID int
+	// Namespace is the namespace of the jobs, empty means the default one
+	Namespace string
+	Status    string
+	Start     time.Time
+	Finish    time.Time
+	// Steps are the jobs of the workflow run in the order they were triggered
+	Steps []*WorkflowStep
+}
+
+// NamespaceOrDefault returns the namespace of the workflow run, the default one
+// if not set
+func (w *WorkflowRun) NamespaceOrDefault() string {
+	if w.Namespace == "" {
+		return DefaultNamespace
+	}
+	return w.Namespace
+}
+
+// Step returns the step of a job on the workflow run, nil if not present
+func (w *WorkflowRun) Step(jobID int) *WorkflowStep {
+	for _, s := range w.Steps {
+		if s.JobID == jobID {
+			return s
+		}
+	}
+	return nil
+}
+
+// WorkflowStep is the execution of a job on a workflow run
+type WorkflowStep struct {
+	JobID int
+	// ResultID is the result of the job execution, 0 means not executed
+	ResultID int
+	Status   int
+	Start    time.Time
+	Finish   time.Time
+}
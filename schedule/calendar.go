@@ -0,0 +1,57 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+// calendarSkip returns the reason to skip an activation of a job by its
+// calendars, empty if the job can run. The activation is skipped if it is
+// inside the window of an exclude calendar or, when the job has include
+// calendars, outside all their windows. Missing calendars are ignored
+func (c *Cron) calendarSkip(j *job.Job, t time.Time) string {
+	includes := []string{}
+	included := false
+	for _, cr := range j.Calendars {
+		cal, err := c.storage.GetCalendar(cr.CalendarID)
+		if err != nil {
+			logrus.Warningf("error checking calendar '%d' of job '%d': %v", cr.CalendarID, j.ID, err)
+			continue
+		}
+
+		w := cal.Window(t)
+		switch cr.Mode {
+		case job.CalendarInclude:
+			includes = append(includes, cal.Name)
+			if w != nil {
+				included = true
+			}
+		default:
+			if w != nil {
+				return fmt.Sprintf("skipped, %v is excluded by calendar '%s' (%s)", t, cal.Name, w.Name)
+			}
+		}
+	}
+
+	if len(includes) > 0 && !included {
+		return fmt.Sprintf("skipped, %v is not included by calendars %q", t, includes)
+	}
+	return ""
+}
+
+// skippedResult returns the result of a job activation that was not executed
+func skippedResult(j *job.Job, scheduled time.Time, reason string) *job.Result {
+	now := time.Now().UTC()
+	return &job.Result{
+		Job:       j,
+		Out:       reason,
+		Status:    job.ResultSkipped,
+		Scheduled: scheduled.UTC(),
+		Start:     now,
+		Finish:    now,
+	}
+}
@@ -0,0 +1,87 @@
+package schedule
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/slok/khronos/config"
+	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/storage"
+)
+
+func TestCalendarSkip(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+	day := func(d, h int) time.Time { return time.Date(2017, time.December, d, h, 0, 0, 0, time.UTC) }
+
+	// 1: holidays, 2: working hours of two days
+	stCli.SaveCalendar(&job.Calendar{Name: "holidays", Windows: []*job.Window{
+		{Name: "christmas", Start: day(25, 0), End: day(26, 0)},
+	}})
+	stCli.SaveCalendar(&job.Calendar{Name: "office", Windows: []*job.Window{
+		{Name: "monday", Start: day(25, 9), End: day(25, 18)},
+		{Name: "tuesday", Start: day(26, 9), End: day(26, 18)},
+	}})
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+
+	exclude := []*job.CalendarRule{{CalendarID: 1, Mode: job.CalendarExclude}}
+	both := []*job.CalendarRule{{CalendarID: 1, Mode: job.CalendarExclude}, {CalendarID: 2, Mode: job.CalendarInclude}}
+	missing := []*job.CalendarRule{{CalendarID: 3, Mode: job.CalendarExclude}}
+
+	tests := []struct {
+		name           string
+		givenCalendars []*job.CalendarRule
+		givenTime      time.Time
+		wantReason     string
+	}{
+		{name: "no calendars", givenTime: day(25, 10), wantReason: ""},
+		{name: "excluded", givenCalendars: exclude, givenTime: day(25, 10), wantReason: "excluded by calendar 'holidays' (christmas)"},
+		{name: "not excluded", givenCalendars: exclude, givenTime: day(26, 10), wantReason: ""},
+		{name: "included", givenCalendars: both, givenTime: day(26, 10), wantReason: ""},
+		{name: "not included", givenCalendars: both, givenTime: day(26, 20), wantReason: "not included by calendars"},
+		{name: "excluded and included", givenCalendars: both, givenTime: day(25, 10), wantReason: "excluded by calendar 'holidays'"},
+		{name: "missing calendar", givenCalendars: missing, givenTime: day(25, 10), wantReason: ""},
+	}
+
+	for _, test := range tests {
+		j := &job.Job{ID: 1, Calendars: test.givenCalendars}
+		got := dCron.calendarSkip(j, test.givenTime)
+		if test.wantReason == "" && got != "" {
+			t.Errorf("%s: Job shouldn't be skipped; got: %s", test.name, got)
+		} else if !strings.Contains(got, test.wantReason) {
+			t.Errorf("%s: Job skip reason should contain '%s'; got: '%s'", test.name, test.wantReason, got)
+		}
+	}
+}
+
+func TestCronJobCalendarSkip(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+
+	now := time.Now()
+	stCli.SaveCalendar(&job.Calendar{Name: "freeze", Windows: []*job.Window{
+		{Name: "release", Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+	}})
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: true, Calendars: []*job.CalendarRule{{CalendarID: 1, Mode: job.CalendarExclude}}}
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	results := make(chan *job.Result, 10)
+	dCron.Start(func(r *job.Result) { results <- r })
+	defer dCron.Stop()
+
+	dCron.RegisterCronJob(j)
+	r := <-results
+
+	// The activation is recorded as skipped with the reason
+	if r.Status != job.ResultSkipped || r.Scheduled.IsZero() {
+		t.Errorf("Result should be a skipped activation; got: %+v", r)
+	}
+	if !strings.Contains(r.Out, "excluded by calendar 'freeze' (release)") {
+		t.Errorf("Result should have the skip reason; got: %s", r.Out)
+	}
+}
@@ -0,0 +1,110 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/robfig/cron"
+
+	"github.com/slok/khronos/job"
+)
+
+// maxMissedRuns is the max number of missed runs of a job that will be caught
+// up, only the latest ones are taken
+const maxMissedRuns = 100
+
+// missedRuns returns the activation times of a schedule after last and not after
+// now, only the latest maxMissedRuns are returned
+func missedRuns(sch cron.Schedule, last, now time.Time) []time.Time {
+	runs := []time.Time{}
+	for t := sch.Next(last); !t.IsZero() && !t.After(now); t = sch.Next(t) {
+		runs = append(runs, t)
+		if len(runs) > maxMissedRuns {
+			runs = runs[1:]
+		}
+	}
+	return runs
+}
+
+// catchUpJob applies the catch up policy of a stored job to the missed runs
+// since its last scheduled run. The missed runs older than the job starting
+// deadline are abandoned and sent as abandoned results
+func (c *Cron) catchUpJob(j *job.Job) {
+	// Paused, one-shot and triggered jobs don't catch up
+	if j.OneShot() || j.Triggered() || !j.Active || j.LastScheduledRun == nil {
+		return
+	}
+
+	sch, err := jobSchedule(j)
+	if err != nil {
+		logrus.Errorf("error catching up job '%d': %v", j.ID, err)
+		return
+	}
+
+	missed := missedRuns(sch, *j.LastScheduledRun, time.Now())
+	if len(missed) == 0 {
+		return
+	}
+	logrus.Infof("Job '%d' missed %d runs since %v", j.ID, len(missed), *j.LastScheduledRun)
+
+	// The missed runs are handled now, don't catch up them again on the next start
+	c.saveLastScheduledRun(j, missed[len(missed)-1])
+
+	switch j.CatchUp {
+	case job.CatchUpOnce:
+		missed = missed[len(missed)-1:]
+	case job.CatchUpAll:
+	default:
+		logrus.Infof("Skipping %d missed runs of job '%d'", len(missed), j.ID)
+		return
+	}
+
+	// The missed runs are in-flight runs of the cron until all are sent
+	ctx, ok := c.beginRun()
+	if !ok {
+		logrus.Warningf("Skipping %d missed runs of job '%d', the cron is stopped", len(missed), j.ID)
+		return
+	}
+	go func() {
+		defer c.endRun()
+		for _, t := range missed {
+			if ctx.Err() != nil {
+				logrus.Warningf("Abandoning the missed runs of job '%d', the cron is stopped", j.ID)
+				return
+			}
+			if j.StartingDeadline > 0 && time.Since(t) > j.StartingDeadline {
+				logrus.Warningf("Abandoning missed run of job '%d' at %v", j.ID, t)
+				now := time.Now().UTC()
+				c.Results <- &job.Result{
+					Job:       j,
+					Out:       fmt.Sprintf("abandoned, missed run at %v is older than the starting deadline", t),
+					Status:    job.ResultAbandoned,
+					Scheduled: t.UTC(),
+					Start:     now,
+					Finish:    now,
+					CatchUp:   true,
+				}
+				continue
+			}
+
+			if reason := c.calendarSkip(j, t); reason != "" {
+				logrus.Infof("Skipping missed run of job '%d' at %v by calendar", j.ID, t)
+				r := skippedResult(j, t, reason)
+				r.CatchUp = true
+				c.Results <- r
+				continue
+			}
+
+			logrus.Debugf("Catching up missed run of job '%d' at %v", j.ID, t)
+			c.Results <- c.runJob(ctx, &job.Result{Job: j, Scheduled: t.UTC(), CatchUp: true})
+		}
+	}()
+}
+
+// saveLastScheduledRun stores the last activation time of a job schedule
+func (c *Cron) saveLastScheduledRun(j *job.Job, t time.Time) {
+	if err := c.storage.SetLastScheduledRun(j.NamespaceOrDefault(), j.ID, t); err != nil {
+		logrus.Warningf("error saving last scheduled run of job '%d': %v", j.ID, err)
+	}
+}
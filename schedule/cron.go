@@ -1,7 +1,11 @@
 package schedule
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -10,6 +14,7 @@ import (
 
 	"github.com/slok/khronos/config"
 	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/secret"
 	"github.com/slok/khronos/storage"
 )
 
@@ -39,19 +44,89 @@ type Cron struct {
 
 	// Storage client
 	storage storage.Client
+
+	// running has the running executions of the jobs by job ID
+	running      map[int][]*execution
+	runningMutex *sync.Mutex
+
+	// registry has the registered jobs by job ID
+	registry      map[int]*entry
+	registryMutex *sync.Mutex
+
+	// workflows has the running workflow runs by ID, only used by the result
+	// processor
+	workflows map[int]*job.WorkflowRun
+
+	// upstreams has the last ended step of the upstream jobs by downstream job
+	// ID and upstream job ID since the downstream job was triggered, only used
+	// by the result processor. Like the running workflow runs it's only kept on
+	// memory, so it's lost when the process is restarted
+	upstreams map[int]map[int]*job.WorkflowStep
+
+	// pool bounds the concurrent executions of the jobs
+	pool *pool
+
+	// limiter has the limits of the calls to the destination hosts of the jobs
+	limiter *HostLimiter
+
+	// runs has the in-flight runs that can send their result, stopCtx is
+	// canceled when the cron is stopping so the waiting runs give up
+	runs       *sync.WaitGroup
+	runsMutex  *sync.Mutex
+	stopCtx    context.Context
+	cancelRuns context.CancelFunc
+
+	// processed is closed when the result processor has handled all the results
+	processed chan struct{}
+}
+
+// entry is a registered job on the cron. The low level cron can't remove its
+// jobs, so the unregistered entries are flagged and their executions ignored
+type entry struct {
+	// active flag is down when the job is paused
+	active bool
+	// removed flag is up when the job has been unregistered
+	removed bool
+	// prev is the last time the job was executed by the schedule
+	prev time.Time
+	// run is the execution of the job
+	run func()
+	// job is the registered job
+	job *job.Job
+}
+
+// Run runs the job of the entry, satisfies the low level cron job interface
+func (e *entry) Run() {
+	e.run()
+}
+
+// execution is a running execution of a job
+type execution struct {
+	cancel context.CancelFunc
 }
 
 // NewSimpleCron creates a new instance of a cron initialized with the basic functionality
 func NewSimpleCron(cfg *config.AppConfig, storage storage.Client) *Cron {
+	limiter := newConfigHostLimiter(cfg)
 	return &Cron{
 		runner:            cron.New(),
-		scheduler:         SimpleRun(),
+		scheduler:         SimpleRun(time.Duration(cfg.JobDefaultTimeoutSeconds)*time.Second, limiter, secret.NewStore(cfg.SecretsKey, storage)),
 		Results:           nil, // Create on startResultProcesser and close on stop
 		started:           false,
 		startMutex:        &sync.Mutex{},
 		storedlJobsLoaded: false,
 		cfg:               cfg,
 		storage:           storage,
+		running:           map[int][]*execution{},
+		runningMutex:      &sync.Mutex{},
+		registry:          map[int]*entry{},
+		registryMutex:     &sync.Mutex{},
+		workflows:         map[int]*job.WorkflowRun{},
+		upstreams:         map[int]map[int]*job.WorkflowStep{},
+		pool:              newPool(cfg.WorkerPoolSize, time.Duration(cfg.WorkerPoolMaxQueueWaitSeconds)*time.Second),
+		limiter:           limiter,
+		runs:              &sync.WaitGroup{},
+		runsMutex:         &sync.Mutex{},
 	}
 }
 
@@ -67,9 +142,29 @@ func NewDummyCron(cfg *config.AppConfig, storage storage.Client, exitStatus int,
 		storedlJobsLoaded: false,
 		cfg:               cfg,
 		storage:           storage,
+		running:           map[int][]*execution{},
+		runningMutex:      &sync.Mutex{},
+		registry:          map[int]*entry{},
+		registryMutex:     &sync.Mutex{},
+		workflows:         map[int]*job.WorkflowRun{},
+		upstreams:         map[int]map[int]*job.WorkflowStep{},
+		pool:              newPool(cfg.WorkerPoolSize, time.Duration(cfg.WorkerPoolMaxQueueWaitSeconds)*time.Second),
+		limiter:           newConfigHostLimiter(cfg),
+		runs:              &sync.WaitGroup{},
+		runsMutex:         &sync.Mutex{},
 	}
 }
 
+// newConfigHostLimiter creates a host limiter with the default limits of the
+// configuration
+func newConfigHostLimiter(cfg *config.AppConfig) *HostLimiter {
+	return NewHostLimiter(&job.HostLimit{
+		Rate:           cfg.HostRateLimit,
+		Burst:          cfg.HostRateBurst,
+		MaxConcurrency: cfg.HostMaxConcurrency,
+	})
+}
+
 // startResultProcesser starts the processor for the results (runs in a goroutine)
 func (c *Cron) startResultProcesser(f func(*job.Result)) error {
 	// Started already aquired from Start
@@ -96,12 +191,15 @@ func (c *Cron) startResultProcesser(f func(*job.Result)) error {
 
 	// Create results channel (will be closed on stop)
 	c.Results = make(chan *job.Result, c.cfg.ResultBufferLen)
+	c.processed = make(chan struct{})
 
 	// Start job runner in a gouroutine. This anom func will execute the received
-	// func for each result
+	// func for each result and follows the workflow runs of the results
 	go func() {
+		defer close(c.processed)
 		for r := range c.Results {
 			f(r)
+			c.processWorkflow(r)
 		}
 	}()
 	return nil
@@ -119,11 +217,21 @@ func (c *Cron) Start(f func(*job.Result)) error {
 		return errors.New("Already running")
 	}
 
+	// Start the result processor
+	if err := c.startResultProcesser(f); err != nil {
+		return err
+	}
+
+	// The runs can start from now on
+	c.runsMutex.Lock()
+	c.stopCtx, c.cancelRuns = context.WithCancel(context.Background())
+	c.runsMutex.Unlock()
+
 	// Start the cron runner
 	c.runner.Start()
 
-	// Start the result processor
-	if err := c.startResultProcesser(f); err != nil {
+	// Load the limits of the hosts set through the API
+	if err := c.loadStoredHostLimits(); err != nil {
 		return err
 	}
 
@@ -139,7 +247,9 @@ func (c *Cron) Start(f func(*job.Result)) error {
 	return nil
 }
 
-// Stop stops cron job scheduler and result listener
+// Stop stops cron job scheduler and result listener. The runs waiting to
+// start give up and the running ones are waited before closing the results,
+// the sent results are processed before returning
 func (c *Cron) Stop() error {
 	c.startMutex.Lock()
 	defer c.startMutex.Unlock()
@@ -148,41 +258,512 @@ func (c *Cron) Stop() error {
 		return errors.New("Not running")
 	}
 	c.runner.Stop()
+
+	// No new runs, the in-flight ones still send their results
+	c.runsMutex.Lock()
+	c.cancelRuns()
+	c.runsMutex.Unlock()
+	c.runs.Wait()
+
 	close(c.Results)
+	<-c.processed
 	c.started = false
 	return nil
 }
 
+// beginRun registers an in-flight run that will send its result, returns the
+// context canceled when the cron stops and false if the cron isn't running.
+// The run needs to call endRun when finished
+func (c *Cron) beginRun() (context.Context, bool) {
+	c.runsMutex.Lock()
+	defer c.runsMutex.Unlock()
+	if c.stopCtx == nil || c.stopCtx.Err() != nil {
+		return nil, false
+	}
+	c.runs.Add(1)
+	return c.stopCtx, true
+}
+
+// endRun unregisters a finished in-flight run
+func (c *Cron) endRun() {
+	c.runs.Done()
+}
+
+// sleep waits for a duration, returns false if the context is done before
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // RegisterCronJob registers a cron to be run when its it's time
 func (c *Cron) RegisterCronJob(j *job.Job) {
 	logrus.Debugf("Registering cron job: '%d'", j.ID)
 
+	// Completed one-shot jobs will not run again
+	if j.OneShot() && j.Completed {
+		logrus.Debugf("Not registering completed one-shot cron job: '%d'", j.ID)
+		return
+	}
+
+	// Jobs without schedule only run when triggered by their upstream jobs
+	var sch cron.Schedule
+	if !j.Triggered() {
+		var err error
+		if sch, err = jobSchedule(j); err != nil {
+			logrus.Errorf("error registering cron job '%d': %v", j.ID, err)
+			return
+		}
+	}
+
+	// Replace the previous registration of the job if any
+	e := &entry{active: j.Active, job: j}
+	c.registryMutex.Lock()
+	if prev, ok := c.registry[j.ID]; ok {
+		prev.removed = true
+		e.prev = prev.prev
+	}
+	c.registry[j.ID] = e
+	c.registryMutex.Unlock()
+
 	// Wrap the execution of job
-	jobExec := func() {
+	e.run = func() {
+		// The ticks of a stopped cron don't run
+		ctx, ok := c.beginRun()
+		if !ok {
+			logrus.Debugf("Skipping cron '%d', the cron is stopped", j.ID)
+			return
+		}
+		defer c.endRun()
+
+		// Record the activations of the recurring jobs, paused ones too, so the
+		// missed runs are known after a downtime (the low level cron activates on
+		// whole seconds)
+		scheduled := time.Now().UTC().Truncate(time.Second)
+		if j.OneShot() {
+			scheduled = j.At.UTC()
+		} else if !c.entryRemoved(e) {
+			c.saveLastScheduledRun(j, scheduled)
+		}
+
+		// Spread out the executions of the jobs with the same schedule
+		if d := jitterDelay(j); d > 0 {
+			logrus.Debugf("Delaying cron '%d' %v by jitter", j.ID, d)
+			if !sleep(ctx, d) {
+				logrus.Debugf("Skipping delayed cron '%d', the cron is stopped", j.ID)
+				return
+			}
+		}
+
+		// Unregistered and paused jobs don't run
+		if !c.startEntryRun(e) {
+			logrus.Debugf("Skipping inactive cron '%d' at %v", j.ID, time.Now().UTC())
+			return
+		}
+		// One-shot jobs are completed before running so they don't run again
+		if j.OneShot() {
+			c.completeOneShotJob(j, e)
+		}
+		// Blackout windows of the job calendars skip the activation
+		if reason := c.calendarSkip(j, scheduled); reason != "" {
+			logrus.Infof("Skipping cron '%d' at %v by calendar", j.ID, scheduled)
+			c.Results <- skippedResult(j, scheduled, reason)
+			return
+		}
 		logrus.Debugf("Start running cron '%d' at %v", j.ID, time.Now().UTC())
-		r := &job.Result{Job: j}
-		c.scheduler.Run(r, j)
-		c.Results <- r
+		c.Results <- c.runJob(ctx, &job.Result{Job: j, Scheduled: scheduled})
 		logrus.Debugf("Finished running cron '%d' at %v", j.ID, time.Now().UTC())
 	}
 
+	// Past one-shot jobs (e.g registered after a restart) run right away
+	if j.OneShot() && !j.At.After(time.Now()) {
+		go e.Run()
+		return
+	}
+	if j.Triggered() {
+		return
+	}
+
 	// Add job to  cron
-	c.runner.AddFunc(j.When, jobExec)
+	c.runner.Schedule(sch, e)
+}
+
+// completeOneShotJob marks a one-shot job as completed on the storage and
+// removes its registration
+func (c *Cron) completeOneShotJob(j *job.Job, e *entry) {
+	c.registryMutex.Lock()
+	e.removed = true
+	if c.registry[j.ID] == e {
+		delete(c.registry, j.ID)
+	}
+	c.registryMutex.Unlock()
+
+	// Get the stored job, could be updated after the registration
+	sj, err := c.storage.GetJob(j.NamespaceOrDefault(), j.ID)
+	if err != nil {
+		logrus.Errorf("error completing one-shot job '%d': %v", j.ID, err)
+		return
+	}
+	sj.Completed = true
+	if err := c.storage.SaveJob(sj); err != nil {
+		logrus.Errorf("error completing one-shot job '%d': %v", j.ID, err)
+		return
+	}
+	logrus.Debugf("Completed one-shot job '%d'", j.ID)
+}
+
+// NextRuns returns the next activation times of a job schedule after a time,
+// count is the max number of times returned
+func NextRuns(j *job.Job, from time.Time, count int) ([]time.Time, error) {
+	// Jobs without schedule only run when triggered by their upstream jobs
+	if j.Triggered() {
+		return []time.Time{}, nil
+	}
+
+	sch, err := jobSchedule(j)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []time.Time{}
+	// Completed one-shot jobs will not run again
+	if j.OneShot() && j.Completed {
+		return runs, nil
+	}
+	t := from
+	for i := 0; i < count; i++ {
+		// Zero time means no more activations
+		if t = sch.Next(t); t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+	return runs, nil
+}
+
+// jobSchedule returns the schedule of a job, evaluated on the job time zone if
+// it has one
+func jobSchedule(j *job.Job) (cron.Schedule, error) {
+	if j.OneShot() {
+		return &onceSchedule{at: *j.At}, nil
+	}
+
+	sch, err := cron.Parse(j.When)
+	if err != nil {
+		return nil, err
+	}
+	if j.TimeZone == "" {
+		return sch, nil
+	}
+
+	loc, err := time.LoadLocation(j.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the wall clock schedules depend on the time zone (not @every)
+	if _, ok := sch.(*cron.SpecSchedule); !ok {
+		return sch, nil
+	}
+	return &zoneSchedule{schedule: sch, location: loc}, nil
+}
+
+// RunCronJob runs a job on demand out of its schedule. The result is stored
+// before the execution to get its ID, and as the scheduled executions, it will be
+// sent to the results when finished. The returned channel receives a copy of the
+// result when the execution finishes
+func (c *Cron) RunCronJob(j *job.Job) (int, <-chan *job.Result, error) {
+	c.startMutex.Lock()
+	defer c.startMutex.Unlock()
+
+	if !c.started {
+		return 0, nil, errors.New("Not running")
+	}
+	logrus.Debugf("Running cron job on demand: '%d'", j.ID)
+
+	r := &job.Result{
+		Job:    j,
+		Status: job.ResultRunning,
+		Start:  time.Now().UTC(),
+		Manual: true,
+	}
+	ctx, ok := c.beginRun()
+	if !ok {
+		return 0, nil, errors.New("Not running")
+	}
+	// The stored running result is a copy, the execution updates its own
+	// result and the result processor stores it over the running one
+	sr := *r
+	if err := c.storage.SaveResult(&sr); err != nil {
+		c.endRun()
+		return 0, nil, err
+	}
+	id := sr.ID
+	r.ID = id
+
+	done := make(chan *job.Result, 1)
+	go func() {
+		defer c.endRun()
+		logrus.Debugf("Start running cron '%d' on demand at %v", j.ID, time.Now().UTC())
+		r = c.runJob(ctx, r)
+		dr := *r
+		c.Results <- r
+		done <- &dr
+		logrus.Debugf("Finished running cron '%d' on demand at %v", j.ID, time.Now().UTC())
+	}()
+
+	return id, done, nil
+}
+
+// UnregisterCronJob removes a registered job from the cron, it will not be
+// executed anymore
+func (c *Cron) UnregisterCronJob(id int) error {
+	logrus.Debugf("Unregistering cron job: '%d'", id)
+	c.registryMutex.Lock()
+	defer c.registryMutex.Unlock()
+
+	e, ok := c.registry[id]
+	if !ok {
+		return fmt.Errorf("job '%d' not registered", id)
+	}
+	e.removed = true
+	delete(c.registry, id)
+	return nil
+}
+
+// PauseCronJob stops the executions of a registered job until is resumed
+func (c *Cron) PauseCronJob(id int) error {
+	return c.setEntryActive(id, false)
+}
+
+// ResumeCronJob restarts the executions of a paused registered job
+func (c *Cron) ResumeCronJob(id int) error {
+	return c.setEntryActive(id, true)
+}
+
+// setEntryActive sets the active flag of a registered job
+func (c *Cron) setEntryActive(id int, active bool) error {
+	c.registryMutex.Lock()
+	defer c.registryMutex.Unlock()
+
+	e, ok := c.registry[id]
+	if !ok {
+		return fmt.Errorf("job '%d' not registered", id)
+	}
+	e.active = active
+	return nil
+}
+
+// startEntryRun checks if a registered job is active and not removed, if it
+// is, the run is recorded as the previous execution
+func (c *Cron) startEntryRun(e *entry) bool {
+	c.registryMutex.Lock()
+	defer c.registryMutex.Unlock()
+	if !e.active || e.removed {
+		return false
+	}
+	e.prev = time.Now().UTC()
+	return true
+}
+
+// entryActive checks if a registered job is active and not removed
+func (c *Cron) entryActive(e *entry) bool {
+	c.registryMutex.Lock()
+	defer c.registryMutex.Unlock()
+	return e.active && !e.removed
+}
+
+// entryRemoved checks if a registered job has been unregistered
+func (c *Cron) entryRemoved(e *entry) bool {
+	c.registryMutex.Lock()
+	defer c.registryMutex.Unlock()
+	return e.removed
+}
+
+// CronJobRuns returns the previous and next executions by the schedule of a
+// registered job, zero times mean that the job didn't run yet or it will not
+// run (e.g paused job)
+func (c *Cron) CronJobRuns(id int) (prev, next time.Time, err error) {
+	c.registryMutex.Lock()
+	e, ok := c.registry[id]
+	if !ok {
+		c.registryMutex.Unlock()
+		return prev, next, fmt.Errorf("job '%d' not registered", id)
+	}
+	prev = e.prev
+	active := e.active
+	c.registryMutex.Unlock()
+
+	if !active {
+		return
+	}
+	for _, ce := range c.runner.Entries() {
+		if ce.Job == e {
+			next = ce.Next
+			break
+		}
+	}
+	return
+}
+
+// runJob runs the job of a result on a worker of the execution pool applying
+// its concurrency policy and returns the result. The wait for a free worker
+// and the execution (e.g the request or the retry backoff) end when the stop
+// context is done
+func (c *Cron) runJob(stopCtx context.Context, r *job.Result) *job.Result {
+	j := r.Job
+	r.RunID = newRunID()
+	if !c.pool.acquire(stopCtx, j) {
+		r.Start = time.Now().UTC()
+		r.Finish = r.Start
+		r.Status = job.ResultDropped
+		if stopCtx.Err() != nil {
+			logrus.Warningf("Dropping cron '%d', the cron is stopped", j.ID)
+			r.Out = "dropped, the cron was stopped while waiting for a free worker"
+			return r
+		}
+		logrus.Warningf("Dropping cron '%d', no free worker after %v", j.ID, c.pool.maxWait)
+		r.Out = fmt.Sprintf("dropped, waited more than %v for a free worker", c.pool.maxWait)
+		return r
+	}
+	defer c.pool.release()
+
+	ctx, cancel := context.WithCancel(stopCtx)
+	defer cancel()
+	r.Context = ctx
+
+	e := &execution{cancel: cancel}
+	if !c.startExecution(j, e) {
+		logrus.Warningf("Skipping cron '%d', previous execution still running", j.ID)
+		r.Start = time.Now().UTC()
+		r.Finish = r.Start
+		r.Status = job.ResultSkipped
+		r.Out = "skipped, previous execution still running"
+		return r
+	}
+	defer c.finishExecution(j, e)
+
+	c.scheduler.Run(r, j)
+
+	// Canceled by the stop of the cron or by a new execution
+	switch {
+	case stopCtx.Err() != nil:
+		r.Status = job.ResultCanceled
+		r.Out = fmt.Sprintf("canceled, the cron was stopped\n\n%s", r.Out)
+	case ctx.Err() != nil:
+		r.Status = job.ResultCanceled
+		r.Out = fmt.Sprintf("canceled, replaced by a new execution\n\n%s", r.Out)
+	}
+	return r
+}
+
+// newRunID returns a random unique identifier for an execution
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		logrus.Errorf("error generating run ID: %v", err)
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// PoolState returns the state of the execution pool
+func (c *Cron) PoolState() *PoolState {
+	return c.pool.state()
+}
+
+// loadStoredHostLimits sets the host limits of the storage on the limiter
+func (c *Cron) loadStoredHostLimits() error {
+	ls, err := c.storage.GetHostLimits()
+	if err != nil {
+		return fmt.Errorf("error loading host limits: %v", err)
+	}
+	for _, l := range ls {
+		c.limiter.SetLimit(l)
+	}
+	logrus.Infof("Loaded %d host limits", len(ls))
+	return nil
+}
+
+// HostLimit returns the limits applied to the calls to a host
+func (c *Cron) HostLimit(host string) *job.HostLimit {
+	return c.limiter.Limit(host)
+}
+
+// SetHostLimit sets the limits of the calls to a host, replacing the default
+// ones of the configuration
+func (c *Cron) SetHostLimit(l *job.HostLimit) {
+	c.limiter.SetLimit(l)
+}
+
+// RemoveHostLimit removes the limits of the calls to a host, the default ones
+// of the configuration will be used
+func (c *Cron) RemoveHostLimit(host string) {
+	c.limiter.RemoveLimit(host)
+}
+
+// startExecution registers a new running execution of a job based on the job
+// concurrency policy, returns false if the execution shouldn't start
+func (c *Cron) startExecution(j *job.Job, e *execution) bool {
+	c.runningMutex.Lock()
+	defer c.runningMutex.Unlock()
+
+	running := c.running[j.ID]
+	if len(running) > 0 {
+		switch j.Concurrency {
+		case job.ConcurrencyForbid:
+			return false
+		case job.ConcurrencyReplace:
+			for _, re := range running {
+				re.cancel()
+			}
+		}
+	}
+
+	c.running[j.ID] = append(running, e)
+	return true
+}
+
+// finishExecution unregisters a running execution of a job
+func (c *Cron) finishExecution(j *job.Job, e *execution) {
+	c.runningMutex.Lock()
+	defer c.runningMutex.Unlock()
+
+	running := c.running[j.ID]
+	for i, re := range running {
+		if re == e {
+			running = append(running[:i], running[i+1:]...)
+			break
+		}
+	}
+
+	if len(running) == 0 {
+		delete(c.running, j.ID)
+		return
+	}
+	c.running[j.ID] = running
 }
 
 // registerStoredCronJobs registers all the stored cron jobs
 func (c *Cron) registerStoredCronJobs() error {
 	logrus.Debug("Registering stored jobs")
 
-	// Get storage jobs
-	js, err := c.storage.GetJobs(0, 0)
+	// Get storage jobs of all the namespaces
+	js, err := storage.AllJobs(c.storage)
 	if err != nil {
 		return err
 	}
 
-	// Register all the jobs
+	// Register all the jobs, inactive ones too so they can be resumed, the
+	// completed one-shot jobs will not be registered. The missed runs while
+	// the cron was down are caught up based on the job policy
 	for _, j := range js {
-		// TODO: Register only active ones (check or retrieve only active ones from database?)
+		c.catchUpJob(j)
 		c.RegisterCronJob(j)
 	}
 	return nil
@@ -1,10 +1,12 @@
 package schedule
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"net/url"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -104,7 +106,7 @@ func TestRegisterCronJobStoreResults(t *testing.T) {
 
 	for _, r := range results {
 		if r.Job != j {
-			t.Errorf("Wrong result Job; expected: %d; got: %d", j, r.Job)
+			t.Errorf("Wrong result Job; expected: %v; got: %v", j, r.Job)
 		}
 		if r.Status != wantExitStatus {
 			t.Errorf("Wrong result status; expected: %d; got: %d", wantExitStatus, r.Status)
@@ -163,6 +165,44 @@ func TestStopCronEngine(t *testing.T) {
 
 }
 
+func TestStopCronEngineCancelsRetries(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+	dCron := NewDummyCron(cfg, stCli, 0, "")
+
+	// Every attempt fails so the run waits on the retry backoff
+	attempts := make(chan struct{}, 5)
+	dCron.scheduler = RetryScheduler(SchedulerFunc(func(r *job.Result, j *job.Job) {
+		r.Status = job.ResultError
+		attempts <- struct{}{}
+	}))
+	results := make(chan *job.Result, 1)
+	dCron.Start(func(r *job.Result) { results <- r })
+
+	j := &job.Job{ID: 1, When: "@yearly", Active: true, Retry: &job.Retry{MaxAttempts: 5, Backoff: 5 * time.Second, On: []int{job.ResultError}}}
+	if _, _, err := dCron.RunCronJob(j); err != nil {
+		t.Fatalf("Running a job should not get an error: %v", err)
+	}
+	select {
+	case <-attempts:
+	case <-time.After(time.Second):
+		t.Fatalf("Job should run its first attempt")
+	}
+
+	start := time.Now()
+	if err := dCron.Stop(); err != nil {
+		t.Fatalf("Stopping should not get an error: %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Stop should cancel the retry backoff; took %v", d)
+	}
+
+	r := <-results
+	if r.Status != job.ResultCanceled || len(r.Attempts) != 1 {
+		t.Errorf("Run should be canceled after the first attempt; got status %d with %d attempts", r.Status, len(r.Attempts))
+	}
+}
+
 func TestRegisterStoredCronJobsOnStart(t *testing.T) {
 	// Create configuration, storage and test vars
 	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
@@ -222,3 +262,601 @@ func TestRegisterStoredCronJobsOnStart(t *testing.T) {
 		t.Errorf("Wrong number of registered stored jobs after starting the cron engine a second time; expected: %d; got: %d", len(js), len(stCli.Results))
 	}
 }
+
+func TestCronJobConcurrencyPolicy(t *testing.T) {
+	tests := []struct {
+		givenConcurrency string
+		wantFirstStatus  int
+		wantSecondStatus int
+	}{
+		{givenConcurrency: "", wantFirstStatus: job.ResultOK, wantSecondStatus: job.ResultOK},
+		{givenConcurrency: job.ConcurrencyAllow, wantFirstStatus: job.ResultOK, wantSecondStatus: job.ResultOK},
+		{givenConcurrency: job.ConcurrencyForbid, wantFirstStatus: job.ResultOK, wantSecondStatus: job.ResultSkipped},
+		{givenConcurrency: job.ConcurrencyReplace, wantFirstStatus: job.ResultCanceled, wantSecondStatus: job.ResultOK},
+	}
+
+	for _, test := range tests {
+		cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+		dCron := NewDummyCron(cfg, storage.NewDummy(), job.ResultOK, "")
+
+		// Slow scheduler that can be stopped
+		dCron.scheduler = SchedulerFunc(func(r *job.Result, j *job.Job) {
+			select {
+			case <-time.After(200 * time.Millisecond):
+			case <-r.Ctx().Done():
+			}
+			r.Status = job.ResultOK
+		})
+
+		u, _ := url.Parse("http://test.org/test")
+		j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: true, Concurrency: test.givenConcurrency}
+
+		// Run two overlapping executions
+		first := make(chan *job.Result)
+		go func() { first <- dCron.runJob(context.Background(), &job.Result{Job: j}) }()
+		time.Sleep(50 * time.Millisecond)
+		second := dCron.runJob(context.Background(), &job.Result{Job: j})
+		firstRes := <-first
+
+		if firstRes.Status != test.wantFirstStatus {
+			t.Errorf("Wrong first execution status with '%s' policy; expected: %d; got: %d", test.givenConcurrency, test.wantFirstStatus, firstRes.Status)
+		}
+		if second.Status != test.wantSecondStatus {
+			t.Errorf("Wrong second execution status with '%s' policy; expected: %d; got: %d", test.givenConcurrency, test.wantSecondStatus, second.Status)
+		}
+
+		// No running executions left
+		if len(dCron.running) != 0 {
+			t.Errorf("Wrong running executions; expected: 0; got: %d", len(dCron.running))
+		}
+	}
+}
+
+func TestRegisterInactiveCronJob(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+	iterations := 2
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: false}
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	var results []*job.Result
+	dCron.Start(func(r *job.Result) {
+		results = append(results, r)
+	})
+	dCron.RegisterCronJob(j)
+	time.Sleep(time.Duration(iterations) * time.Second)
+	dCron.Stop()
+
+	// Inactive jobs should not be executed
+	if len(results) != 0 {
+		t.Errorf("Wrong result list; expected: %d; got: %d", 0, len(results))
+	}
+}
+
+func TestPauseResumeCronJob(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+	iterations := 2
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: true}
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	resultsMutex := &sync.Mutex{}
+	var results []*job.Result
+	dCron.Start(func(r *job.Result) {
+		resultsMutex.Lock()
+		defer resultsMutex.Unlock()
+		results = append(results, r)
+	})
+	defer dCron.Stop()
+
+	// Not registered jobs can't be paused
+	if err := dCron.PauseCronJob(j.ID); err == nil {
+		t.Errorf("Pausing a not registered job should get an error")
+	}
+
+	dCron.RegisterCronJob(j)
+	if err := dCron.PauseCronJob(j.ID); err != nil {
+		t.Errorf("Pausing a registered job should not get an error: %v", err)
+	}
+	time.Sleep(time.Duration(iterations) * time.Second)
+
+	resultsMutex.Lock()
+	if len(results) != 0 {
+		t.Errorf("Wrong result list of paused job; expected: %d; got: %d", 0, len(results))
+	}
+	resultsMutex.Unlock()
+
+	if err := dCron.ResumeCronJob(j.ID); err != nil {
+		t.Errorf("Resuming a registered job should not get an error: %v", err)
+	}
+	time.Sleep(time.Duration(iterations) * time.Second)
+
+	resultsMutex.Lock()
+	if len(results) != iterations {
+		t.Errorf("Wrong result list of resumed job; expected: %d; got: %d", iterations, len(results))
+	}
+	resultsMutex.Unlock()
+}
+
+func TestUnregisterCronJob(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+	iterations := 2
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: true}
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	resultsMutex := &sync.Mutex{}
+	var results []*job.Result
+	dCron.Start(func(r *job.Result) {
+		resultsMutex.Lock()
+		defer resultsMutex.Unlock()
+		results = append(results, r)
+	})
+	defer dCron.Stop()
+
+	// Not registered jobs can't be unregistered
+	if err := dCron.UnregisterCronJob(j.ID); err == nil {
+		t.Errorf("Unregistering a not registered job should get an error")
+	}
+
+	dCron.RegisterCronJob(j)
+	time.Sleep(time.Duration(iterations) * time.Second)
+
+	if err := dCron.UnregisterCronJob(j.ID); err != nil {
+		t.Errorf("Unregistering a registered job should not get an error: %v", err)
+	}
+	time.Sleep(time.Duration(iterations) * time.Second)
+
+	// Only the executions before unregistering
+	resultsMutex.Lock()
+	if len(results) != iterations {
+		t.Errorf("Wrong result list; expected: %d; got: %d", iterations, len(results))
+	}
+	resultsMutex.Unlock()
+
+	// Unregistered jobs can't be paused or unregistered again
+	if err := dCron.PauseCronJob(j.ID); err == nil {
+		t.Errorf("Pausing an unregistered job should get an error")
+	}
+	if err := dCron.UnregisterCronJob(j.ID); err == nil {
+		t.Errorf("Unregistering an unregistered job should get an error")
+	}
+}
+
+func TestRegisterCronJobTwice(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+	iterations := 2
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: true}
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	resultsMutex := &sync.Mutex{}
+	var results []*job.Result
+	dCron.Start(func(r *job.Result) {
+		resultsMutex.Lock()
+		defer resultsMutex.Unlock()
+		results = append(results, r)
+	})
+
+	// The second registration replaces the first one
+	dCron.RegisterCronJob(j)
+	dCron.RegisterCronJob(j)
+	time.Sleep(time.Duration(iterations) * time.Second)
+	dCron.Stop()
+
+	resultsMutex.Lock()
+	defer resultsMutex.Unlock()
+	if len(results) != iterations {
+		t.Errorf("Wrong result list; expected: %d; got: %d", iterations, len(results))
+	}
+}
+
+func TestRunCronJob(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@yearly", Active: true}
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "manual")
+
+	// Not running crons can't run jobs
+	if _, _, err := dCron.RunCronJob(j); err == nil {
+		t.Errorf("Running a job on a stopped cron should get an error")
+	}
+
+	processed := make(chan *job.Result, 1)
+	dCron.Start(func(r *job.Result) {
+		stCli.SaveResult(r)
+		processed <- r
+	})
+	defer dCron.Stop()
+
+	id, done, err := dCron.RunCronJob(j)
+	if err != nil {
+		t.Fatalf("Running a job should not get an error: %v", err)
+	}
+	if id != 1 {
+		t.Errorf("Wrong result ID; expected: %d; got: %d", 1, id)
+	}
+
+	r := <-done
+	if pr := <-processed; pr.ID != r.ID || pr.RunID != r.RunID {
+		t.Errorf("The result should be sent to the results channel")
+	}
+	if r.ID != id || !r.Manual || r.Status != job.ResultOK || r.Out != "manual" {
+		t.Errorf("Wrong manual result: %+v", r)
+	}
+
+	// The result is updated, not duplicated
+	if rs, _ := stCli.GetResults(j, 0, 0); len(rs) != 1 {
+		t.Errorf("Wrong stored results; expected: %d; got: %d", 1, len(rs))
+	}
+}
+
+func TestNextRuns(t *testing.T) {
+	from := time.Date(2017, time.January, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		givenJob   *job.Job
+		givenCount int
+		wantRuns   []time.Time
+		wantError  bool
+	}{
+		{
+			givenJob:   &job.Job{When: "0 0 9 * * *", TimeZone: "UTC"},
+			givenCount: 2,
+			wantRuns: []time.Time{
+				time.Date(2017, time.January, 11, 9, 0, 0, 0, time.UTC),
+				time.Date(2017, time.January, 12, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			givenJob:   &job.Job{When: "@every 90s"},
+			givenCount: 2,
+			wantRuns: []time.Time{
+				time.Date(2017, time.January, 10, 12, 1, 30, 0, time.UTC),
+				time.Date(2017, time.January, 10, 12, 3, 0, 0, time.UTC),
+			},
+		},
+		{
+			givenJob:   &job.Job{When: "0 0 9 30 2 *", TimeZone: "UTC"},
+			givenCount: 2,
+			wantRuns:   []time.Time{},
+		},
+		{
+			givenJob:  &job.Job{When: "wrong"},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		runs, err := NextRuns(test.givenJob, from, test.givenCount)
+		if test.wantError {
+			if err == nil {
+				t.Errorf("Expected error on '%s'", test.givenJob.When)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Didn't expect error: %v", err)
+			continue
+		}
+
+		if len(runs) != len(test.wantRuns) {
+			t.Errorf("Wrong runs on '%s'; expected: %v; got: %v", test.givenJob.When, test.wantRuns, runs)
+			continue
+		}
+		for i, r := range runs {
+			if !r.Equal(test.wantRuns[i]) {
+				t.Errorf("Wrong run on '%s'; expected: %v; got: %v", test.givenJob.When, test.wantRuns[i], r)
+			}
+		}
+	}
+}
+
+func TestCronJobRuns(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: true}
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	dCron.Start(func(r *job.Result) {})
+	defer dCron.Stop()
+
+	// Not registered jobs don't have runs
+	if _, _, err := dCron.CronJobRuns(j.ID); err == nil {
+		t.Errorf("Getting the runs of a not registered job should get an error")
+	}
+
+	dCron.RegisterCronJob(j)
+	prev, next, err := dCron.CronJobRuns(j.ID)
+	if err != nil {
+		t.Fatalf("Getting the runs of a registered job should not get an error: %v", err)
+	}
+	if !prev.IsZero() || next.IsZero() {
+		t.Errorf("Not executed job should only have next run; got prev: %v; next: %v", prev, next)
+	}
+
+	time.Sleep(1500 * time.Millisecond)
+	prev, next, _ = dCron.CronJobRuns(j.ID)
+	if prev.IsZero() || !next.After(prev) {
+		t.Errorf("Executed job should have previous and next runs; got prev: %v; next: %v", prev, next)
+	}
+
+	// Paused jobs don't have next run
+	dCron.PauseCronJob(j.ID)
+	prev, next, _ = dCron.CronJobRuns(j.ID)
+	if prev.IsZero() || !next.IsZero() {
+		t.Errorf("Paused job should only have previous run; got prev: %v; next: %v", prev, next)
+	}
+}
+
+func TestOneShotCronJob(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	cfg.DontScheduleJobsStart = false
+
+	u, _ := url.Parse("http://test.org/test")
+
+	tests := []struct {
+		name          string
+		givenAt       time.Duration
+		givenJob      *job.Job
+		wantResults   int
+		wantCompleted bool
+	}{
+		{
+			name:          "future one-shot job runs once",
+			givenAt:       1 * time.Second,
+			givenJob:      &job.Job{ID: 1, URL: u, Active: true},
+			wantResults:   1,
+			wantCompleted: true,
+		},
+		{
+			name:          "past one-shot job runs right away",
+			givenAt:       -1 * time.Hour,
+			givenJob:      &job.Job{ID: 1, URL: u, Active: true},
+			wantResults:   1,
+			wantCompleted: true,
+		},
+		{
+			name:          "completed one-shot job doesn't run",
+			givenAt:       -1 * time.Hour,
+			givenJob:      &job.Job{ID: 1, URL: u, Completed: true, Active: true},
+			wantResults:   0,
+			wantCompleted: true,
+		},
+		{
+			name:          "paused one-shot job doesn't run",
+			givenAt:       1 * time.Second,
+			givenJob:      &job.Job{ID: 1, URL: u, Active: false},
+			wantResults:   0,
+			wantCompleted: false,
+		},
+	}
+
+	for _, test := range tests {
+		at := time.Now().Add(test.givenAt)
+		test.givenJob.At = &at
+
+		// Stored jobs are registered on start
+		stCli := storage.NewDummy()
+		stCli.Jobs = map[string]*job.Job{"job:1": test.givenJob}
+		stCli.JobCounter = 1
+
+		dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+		resultsMutex := &sync.Mutex{}
+		var results []*job.Result
+		dCron.Start(func(r *job.Result) {
+			resultsMutex.Lock()
+			defer resultsMutex.Unlock()
+			results = append(results, r)
+		})
+
+		time.Sleep(2500 * time.Millisecond)
+		dCron.Stop()
+
+		resultsMutex.Lock()
+		if len(results) != test.wantResults {
+			t.Errorf("%s: Wrong result list; expected: %d; got: %d", test.name, test.wantResults, len(results))
+		}
+		resultsMutex.Unlock()
+
+		sj, _ := stCli.GetJob(job.DefaultNamespace, 1)
+		if sj.Completed != test.wantCompleted {
+			t.Errorf("%s: Wrong stored job completion; expected: %t; got: %t", test.name, test.wantCompleted, sj.Completed)
+		}
+
+		// Completed jobs are not registered
+		if _, _, err := dCron.CronJobRuns(1); (err != nil) != test.wantCompleted {
+			t.Errorf("%s: Wrong job registration; completed: %t; got error: %v", test.name, test.wantCompleted, err)
+		}
+	}
+}
+
+func TestCatchUpStoredCronJobs(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	cfg.DontScheduleJobsStart = false
+
+	u, _ := url.Parse("http://test.org/test")
+
+	tests := []struct {
+		name          string
+		givenJob      *job.Job
+		wantRun       int
+		wantAbandoned int
+	}{
+		{
+			name:     "skip policy doesn't run missed runs",
+			givenJob: &job.Job{ID: 1, URL: u, When: "@every 1h", Active: true, CatchUp: job.CatchUpSkip},
+		},
+		{
+			name:     "once policy runs once",
+			givenJob: &job.Job{ID: 1, URL: u, When: "@every 1h", Active: true, CatchUp: job.CatchUpOnce},
+			wantRun:  1,
+		},
+		{
+			name:     "all policy runs every missed run",
+			givenJob: &job.Job{ID: 1, URL: u, When: "@every 1h", Active: true, CatchUp: job.CatchUpAll},
+			wantRun:  3,
+		},
+		{
+			name:          "all policy abandons missed runs older than deadline",
+			givenJob:      &job.Job{ID: 1, URL: u, When: "@every 1h", Active: true, CatchUp: job.CatchUpAll, StartingDeadline: 2 * time.Hour},
+			wantRun:       2,
+			wantAbandoned: 1,
+		},
+		{
+			name:          "once policy abandons missed run older than deadline",
+			givenJob:      &job.Job{ID: 1, URL: u, When: "@every 1h", Active: true, CatchUp: job.CatchUpOnce, StartingDeadline: 10 * time.Minute},
+			wantAbandoned: 1,
+		},
+		{
+			name:     "paused jobs don't catch up",
+			givenJob: &job.Job{ID: 1, URL: u, When: "@every 1h", Active: false, CatchUp: job.CatchUpAll},
+		},
+	}
+
+	for _, test := range tests {
+		// Missed 3 runs, 2h30m, 1h30m and 30m ago
+		last := time.Now().Add(-3*time.Hour - 30*time.Minute).Truncate(time.Second)
+		test.givenJob.LastScheduledRun = &last
+
+		stCli := storage.NewDummy()
+		stCli.Jobs = map[string]*job.Job{"job:1": test.givenJob}
+		stCli.JobCounter = 1
+
+		dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+		resultsMutex := &sync.Mutex{}
+		var results []*job.Result
+		dCron.Start(func(r *job.Result) {
+			resultsMutex.Lock()
+			defer resultsMutex.Unlock()
+			results = append(results, r)
+		})
+		time.Sleep(500 * time.Millisecond)
+		dCron.Stop()
+
+		resultsMutex.Lock()
+		var gotRun, gotAbandoned int
+		for _, r := range results {
+			if !r.CatchUp {
+				t.Errorf("%s: Result should be flagged as catch up", test.name)
+			}
+			switch r.Status {
+			case job.ResultOK:
+				gotRun++
+			case job.ResultAbandoned:
+				gotAbandoned++
+			}
+		}
+		resultsMutex.Unlock()
+
+		if gotRun != test.wantRun {
+			t.Errorf("%s: Wrong caught up runs; expected: %d; got: %d", test.name, test.wantRun, gotRun)
+		}
+		if gotAbandoned != test.wantAbandoned {
+			t.Errorf("%s: Wrong abandoned runs; expected: %d; got: %d", test.name, test.wantAbandoned, gotAbandoned)
+		}
+
+		// Missed runs of active jobs are handled and will not be caught up again
+		sj, _ := stCli.GetJob(job.DefaultNamespace, 1)
+		wantLast := last.Add(3 * time.Hour)
+		if !test.givenJob.Active {
+			wantLast = last
+		}
+		if !sj.LastScheduledRun.Equal(wantLast) {
+			t.Errorf("%s: Wrong last scheduled run; expected: %v; got: %v", test.name, wantLast, sj.LastScheduledRun)
+		}
+	}
+}
+
+func TestSaveLastScheduledRun(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: false}
+	stCli.Jobs = map[string]*job.Job{"job:1": j}
+	stCli.JobCounter = 1
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	dCron.Start(nil)
+	defer dCron.Stop()
+
+	// Paused jobs record their activations too
+	start := time.Now()
+	dCron.RegisterCronJob(j)
+	time.Sleep(1500 * time.Millisecond)
+
+	sj, _ := stCli.GetJob(job.DefaultNamespace, 1)
+	if sj.LastScheduledRun == nil || sj.LastScheduledRun.Before(start.Truncate(time.Second)) {
+		t.Errorf("Wrong last scheduled run; got: %v", sj.LastScheduledRun)
+	}
+}
+
+func TestCronJobJitter(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: true, Jitter: 500 * time.Millisecond, JitterMode: job.JitterHash}
+	wantDelay := jitterDelay(j)
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	results := make(chan *job.Result, 10)
+	dCron.Start(func(r *job.Result) { results <- r })
+	defer dCron.Stop()
+
+	dCron.RegisterCronJob(j)
+	r := <-results
+
+	// The execution starts after the scheduled time by the jitter delay
+	if r.Scheduled.IsZero() || r.Scheduled.Nanosecond() != 0 {
+		t.Errorf("Result should have the scheduled time; got: %v", r.Scheduled)
+	}
+	if d := r.Start.Sub(r.Scheduled); d < wantDelay {
+		t.Errorf("Result start should be delayed at least %v; got: %v", wantDelay, d)
+	}
+}
+
+func TestStopCronWithPendingJitter(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+
+	u, _ := url.Parse("http://test.org/test")
+	j := &job.Job{ID: 1, URL: u, When: "@every 1s", Active: true, Jitter: time.Hour, JitterMode: job.JitterHash}
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	results := make(chan *job.Result, 10)
+	dCron.Start(func(r *job.Result) { results <- r })
+	dCron.RegisterCronJob(j)
+
+	// Let the tick fire and wait for the jitter delay
+	time.Sleep(1500 * time.Millisecond)
+
+	stopped := make(chan error)
+	go func() { stopped <- dCron.Stop() }()
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("Stopping should not get an error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Stop should not wait the pending jitter delay")
+	}
+
+	if len(results) != 0 {
+		t.Errorf("Pending runs should not send results; got: %d", len(results))
+	}
+}
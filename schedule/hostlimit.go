@@ -0,0 +1,228 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+// HostLimiter limits the calls made by the jobs to the same destination host,
+// every host has a rate (token bucket) and a concurrency limit. The hosts use
+// the default limits unless they have their own ones
+type HostLimiter struct {
+	defaults *job.HostLimit
+
+	mutex  *sync.Mutex
+	limits map[string]*job.HostLimit
+	hosts  map[string]*hostState
+}
+
+// hostState is the usage of the limits of a host
+type hostState struct {
+	// tokens are the calls that can be made right now by the rate limit
+	tokens float64
+	// last is the last time the tokens were refilled
+	last    time.Time
+	running int
+	// released is closed when a running call finishes
+	released chan struct{}
+}
+
+// NewHostLimiter creates a host limiter with the limits applied to the hosts
+// without their own ones
+func NewHostLimiter(defaults *job.HostLimit) *HostLimiter {
+	return &HostLimiter{
+		defaults: defaults,
+		mutex:    &sync.Mutex{},
+		limits:   map[string]*job.HostLimit{},
+		hosts:    map[string]*hostState{},
+	}
+}
+
+// hostKey returns the host of a job URL as used by the limiter
+func hostKey(host string) string {
+	return strings.ToLower(host)
+}
+
+// Limit returns the limits applied to a host
+func (l *HostLimiter) Limit(host string) *job.HostLimit {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	hl := *l.limit(hostKey(host))
+	return &hl
+}
+
+// limit returns the limits of a host, the limiter should be locked
+func (l *HostLimiter) limit(host string) *job.HostLimit {
+	if hl, ok := l.limits[host]; ok {
+		return hl
+	}
+	hl := *l.defaults
+	hl.Host = host
+	return &hl
+}
+
+// Limits returns the hosts with their own limits ordered by host
+func (l *HostLimiter) Limits() []*job.HostLimit {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	hosts := []string{}
+	for h := range l.limits {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+
+	ls := []*job.HostLimit{}
+	for _, h := range hosts {
+		hl := *l.limits[h]
+		ls = append(ls, &hl)
+	}
+	return ls
+}
+
+// SetLimit sets the own limits of a host, replacing the default ones
+func (l *HostLimiter) SetLimit(hl *job.HostLimit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	nhl := *hl
+	nhl.Host = hostKey(hl.Host)
+	l.limits[nhl.Host] = &nhl
+	// Wake up the waiting calls, they could go with the new limits
+	if st, ok := l.hosts[nhl.Host]; ok {
+		st.wake()
+	}
+}
+
+// RemoveLimit removes the own limits of a host, the default ones will be used
+func (l *HostLimiter) RemoveLimit(host string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	host = hostKey(host)
+	delete(l.limits, host)
+	if st, ok := l.hosts[host]; ok {
+		st.wake()
+	}
+}
+
+// acquire waits until a call to a host can be made by the limits of the host
+// and returns the time waited. Returns error if the context is done before
+func (l *HostLimiter) acquire(ctx context.Context, host string) (time.Duration, error) {
+	start := time.Now()
+	for {
+		l.mutex.Lock()
+		hl := l.limit(host)
+		st, ok := l.hosts[host]
+		now := time.Now()
+		if !ok {
+			st = &hostState{tokens: float64(burst(hl)), last: now, released: make(chan struct{})}
+			l.hosts[host] = st
+		}
+
+		// Refill the tokens of the rate since the last time
+		if hl.Rate > 0 {
+			st.tokens = math.Min(float64(burst(hl)), st.tokens+now.Sub(st.last).Seconds()*hl.Rate)
+		}
+		st.last = now
+
+		var wait time.Duration
+		released := st.released
+		full := hl.MaxConcurrency > 0 && st.running >= hl.MaxConcurrency
+		if !full {
+			if hl.Rate <= 0 || st.tokens >= 1 {
+				if hl.Rate > 0 {
+					st.tokens--
+				}
+				st.running++
+				l.mutex.Unlock()
+				return time.Since(start), nil
+			}
+			wait = time.Duration((1 - st.tokens) / hl.Rate * float64(time.Second))
+		}
+		l.mutex.Unlock()
+
+		// Wait for a running call to finish or the next token of the rate
+		var timeout <-chan time.Time
+		var t *time.Timer
+		if wait > 0 {
+			t = time.NewTimer(wait)
+			timeout = t.C
+		}
+		select {
+		case <-released:
+		case <-timeout:
+		case <-ctx.Done():
+			if t != nil {
+				t.Stop()
+			}
+			return time.Since(start), ctx.Err()
+		}
+		if t != nil {
+			t.Stop()
+		}
+	}
+}
+
+// release finishes a call to a host
+func (l *HostLimiter) release(host string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	st := l.hosts[host]
+	st.running--
+	st.wake()
+}
+
+// wake wakes up the calls waiting for the host, the limiter should be locked
+func (st *hostState) wake() {
+	close(st.released)
+	st.released = make(chan struct{})
+}
+
+// burst returns the calls that can be made at once over the rate of a host
+func burst(hl *job.HostLimit) int {
+	if hl.Burst < 1 {
+		return 1
+	}
+	return hl.Burst
+}
+
+// HostLimitScheduler waits before running the wrapped scheduler until the
+// limits of the job destination host (host of the job URL) allow the call,
+// the time waited is registered on the result. The jobs without URL are not
+// limited
+func HostLimitScheduler(l *HostLimiter, s Scheduler) Scheduler {
+	return SchedulerFunc(func(r *job.Result, j *job.Job) {
+		if j.URL == nil || j.URL.Host == "" {
+			s.Run(r, j)
+			return
+		}
+
+		host := hostKey(j.URL.Host)
+		w, err := l.acquire(r.Ctx(), host)
+		r.HostWait += w
+		if err != nil {
+			logrus.Warningf("Cron '%d' stopped while waiting for the limits of host '%s'", j.ID, host)
+			r.Status = job.ResultCanceled
+			r.Out = fmt.Sprintf("canceled while waiting %v for the limits of host '%s'", w, host)
+			return
+		}
+		defer l.release(host)
+
+		if w > 0 {
+			logrus.Infof("Cron '%d' waited %v for the limits of host '%s'", j.ID, w, host)
+		}
+		s.Run(r, j)
+	})
+}
@@ -0,0 +1,117 @@
+package schedule
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestHostLimiterRate(t *testing.T) {
+	// 20 calls per second, 2 at once
+	l := NewHostLimiter(&job.HostLimit{Rate: 20, Burst: 2})
+
+	waits := []time.Duration{}
+	for i := 0; i < 4; i++ {
+		w, err := l.acquire(context.Background(), "example.org")
+		if err != nil {
+			t.Fatalf("Call shouldn't fail: %v", err)
+		}
+		l.release("example.org")
+		waits = append(waits, w)
+	}
+
+	// The burst doesn't wait, the rest wait for their turn
+	if waits[0] > 10*time.Millisecond || waits[1] > 10*time.Millisecond {
+		t.Errorf("Burst calls shouldn't wait; waited: %v", waits)
+	}
+	if waits[2] < 40*time.Millisecond || waits[3] < 40*time.Millisecond {
+		t.Errorf("Calls over the burst should wait the rate; waited: %v", waits)
+	}
+
+	// Other hosts have their own limits
+	if w, _ := l.acquire(context.Background(), "example.com"); w > 10*time.Millisecond {
+		t.Errorf("Call to other host shouldn't wait; waited: %v", w)
+	}
+}
+
+func TestHostLimiterConcurrency(t *testing.T) {
+	l := NewHostLimiter(&job.HostLimit{})
+	l.SetLimit(&job.HostLimit{Host: "Example.org", MaxConcurrency: 2})
+
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.acquire(context.Background(), "example.org")
+			mutex.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			l.release("example.org")
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning != 2 {
+		t.Errorf("Max concurrent calls should be 2; got: %d", maxRunning)
+	}
+
+	// Without own limits the defaults are used
+	l.RemoveLimit("example.org")
+	if got := l.Limit("example.org"); got.MaxConcurrency != 0 || got.Host != "example.org" {
+		t.Errorf("Default limits should be used; got: %+v", got)
+	}
+	if ls := l.Limits(); len(ls) != 0 {
+		t.Errorf("Hosts shouldn't have own limits; got: %+v", ls)
+	}
+}
+
+func TestHostLimitScheduler(t *testing.T) {
+	l := NewHostLimiter(&job.HostLimit{MaxConcurrency: 1})
+	u, _ := url.Parse("http://example.org/test")
+	j := &job.Job{ID: 1, URL: u}
+	s := HostLimitScheduler(l, SchedulerFunc(func(r *job.Result, j *job.Job) {
+		r.Status = job.ResultOK
+	}))
+
+	// Busy host
+	l.acquire(context.Background(), "example.org")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l.release("example.org")
+	}()
+
+	r := &job.Result{}
+	s.Run(r, j)
+	if r.Status != job.ResultOK {
+		t.Errorf("Job should run; got status: %d", r.Status)
+	}
+	if r.HostWait < 50*time.Millisecond {
+		t.Errorf("Wait for the host should be registered; got: %v", r.HostWait)
+	}
+
+	// Stopped while waiting
+	l.acquire(context.Background(), "example.org")
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	r = &job.Result{Context: ctx}
+	s.Run(r, j)
+	if r.Status != job.ResultCanceled {
+		t.Errorf("Job should be canceled; got status: %d", r.Status)
+	}
+}
@@ -0,0 +1,25 @@
+package schedule
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/slok/khronos/job"
+)
+
+// jitterDelay returns the delay of a job execution after the schedule activation
+// based on the job jitter window. The hash mode delay is stable for the same job
+func jitterDelay(j *job.Job) time.Duration {
+	if j.Jitter <= 0 {
+		return 0
+	}
+
+	if j.JitterMode == job.JitterHash {
+		h := fnv.New64a()
+		h.Write([]byte(strconv.Itoa(j.ID)))
+		return time.Duration(h.Sum64() % uint64(j.Jitter))
+	}
+	return time.Duration(rand.Int63n(int64(j.Jitter)))
+}
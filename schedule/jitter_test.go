@@ -0,0 +1,46 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestJitterDelay(t *testing.T) {
+	tests := []struct {
+		givenJob   *job.Job
+		wantStable bool
+	}{
+		{givenJob: &job.Job{ID: 1}, wantStable: true},
+		{givenJob: &job.Job{ID: 1, Jitter: time.Minute}, wantStable: false},
+		{givenJob: &job.Job{ID: 1, Jitter: time.Minute, JitterMode: job.JitterRandom}, wantStable: false},
+		{givenJob: &job.Job{ID: 1, Jitter: time.Minute, JitterMode: job.JitterHash}, wantStable: true},
+		{givenJob: &job.Job{ID: 2, Jitter: time.Minute, JitterMode: job.JitterHash}, wantStable: true},
+	}
+
+	for _, test := range tests {
+		first := jitterDelay(test.givenJob)
+		stable := true
+		for i := 0; i < 10; i++ {
+			d := jitterDelay(test.givenJob)
+			if d < 0 || (test.givenJob.Jitter > 0 && d >= test.givenJob.Jitter) || (test.givenJob.Jitter == 0 && d != 0) {
+				t.Errorf("Delay should be on the jitter window %v; got %v", test.givenJob.Jitter, d)
+			}
+			if d != first {
+				stable = false
+			}
+		}
+
+		if stable != test.wantStable {
+			t.Errorf("Delay of job %+v stability should be %t; got %t", test.givenJob, test.wantStable, stable)
+		}
+	}
+
+	// Hash delays are different for different jobs
+	j1 := &job.Job{ID: 1, Jitter: time.Minute, JitterMode: job.JitterHash}
+	j2 := &job.Job{ID: 2, Jitter: time.Minute, JitterMode: job.JitterHash}
+	if jitterDelay(j1) == jitterDelay(j2) {
+		t.Errorf("Hash delays of different jobs should be different")
+	}
+}
@@ -0,0 +1,18 @@
+package schedule
+
+import (
+	"time"
+)
+
+// onceSchedule is a schedule that activates only once at a specific time
+type onceSchedule struct {
+	at time.Time
+}
+
+// Next returns the activation time if it's after t, zero time if not
+func (o *onceSchedule) Next(t time.Time) time.Time {
+	if o.at.After(t) {
+		return o.at
+	}
+	return time.Time{}
+}
@@ -0,0 +1,192 @@
+package schedule
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/slok/khronos/job"
+)
+
+// PoolState is the state of the execution pool of a cron
+type PoolState struct {
+	// Size is the max number of concurrent executions
+	Size    int
+	Running int
+	// MaxQueueWait is the max time a run waits on the queue before being
+	// dropped, 0 means no limit
+	MaxQueueWait time.Duration
+	// Dropped is the number of runs dropped since the cron was created
+	Dropped int
+	// Queue has the runs waiting for a free worker in the order they will run
+	Queue []*QueuedRun
+}
+
+// QueuedRun is a job run waiting for a free worker of the execution pool
+type QueuedRun struct {
+	JobID    int
+	Priority int
+	Since    time.Time
+}
+
+// pool bounds the number of concurrent executions of the jobs, the runs wait
+// on a queue for a free worker ordered by job priority and arrival
+type pool struct {
+	size    int
+	maxWait time.Duration
+
+	mutex   *sync.Mutex
+	running int
+	dropped int
+	queue   waitQueue
+	// seq is the arrival order of the queued runs
+	seq uint64
+}
+
+// newPool creates an execution pool, max wait 0 means that the runs wait
+// until a worker is free
+func newPool(size int, maxWait time.Duration) *pool {
+	return &pool{
+		size:    size,
+		maxWait: maxWait,
+		mutex:   &sync.Mutex{},
+		queue:   waitQueue{},
+	}
+}
+
+// acquire takes a worker of the pool for a job run, waiting on the queue if
+// there isn't a free one. Returns false if the run has been dropped after
+// waiting the max queue wait or the context is done while waiting
+func (p *pool) acquire(ctx context.Context, j *job.Job) bool {
+	p.mutex.Lock()
+	if p.running < p.size && p.queue.Len() == 0 {
+		p.running++
+		p.mutex.Unlock()
+		return true
+	}
+
+	p.seq++
+	w := &waiter{
+		run:   &QueuedRun{JobID: j.ID, Priority: j.Priority, Since: time.Now().UTC()},
+		seq:   p.seq,
+		ready: make(chan struct{}),
+	}
+	heap.Push(&p.queue, w)
+	p.mutex.Unlock()
+
+	var timeout <-chan time.Time
+	if p.maxWait > 0 {
+		t := time.NewTimer(p.maxWait)
+		defer t.Stop()
+		timeout = t.C
+	}
+
+	select {
+	case <-w.ready:
+		return true
+	case <-timeout:
+	case <-ctx.Done():
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	// The worker could have been given while waiting for the lock
+	if w.index < 0 {
+		return true
+	}
+	heap.Remove(&p.queue, w.index)
+	p.dropped++
+	return false
+}
+
+// release frees a worker of the pool, the worker is given to the first run of
+// the queue if any
+func (p *pool) release() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.queue.Len() > 0 {
+		w := heap.Pop(&p.queue).(*waiter)
+		close(w.ready)
+		return
+	}
+	p.running--
+}
+
+// state returns the present state of the pool
+func (p *pool) state() *PoolState {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	// Sort a copy of the queue to get the runs in order, the heap is only
+	// partially sorted
+	ws := append(waitersByOrder{}, p.queue...)
+	sort.Sort(ws)
+	runs := []*QueuedRun{}
+	for _, w := range ws {
+		runs = append(runs, &QueuedRun{JobID: w.run.JobID, Priority: w.run.Priority, Since: w.run.Since})
+	}
+
+	return &PoolState{
+		Size:         p.size,
+		Running:      p.running,
+		MaxQueueWait: p.maxWait,
+		Dropped:      p.dropped,
+		Queue:        runs,
+	}
+}
+
+// waiter is a run waiting on the pool queue
+type waiter struct {
+	run   *QueuedRun
+	seq   uint64
+	ready chan struct{}
+	// index on the queue heap, -1 when out of the queue
+	index int
+}
+
+// runsBefore checks if a waiting run goes before another one, higher priority
+// first and then the older ones
+func runsBefore(a, b *waiter) bool {
+	if a.run.Priority != b.run.Priority {
+		return a.run.Priority > b.run.Priority
+	}
+	return a.seq < b.seq
+}
+
+// waitersByOrder sorts the waiting runs in the order they will run
+type waitersByOrder []*waiter
+
+func (s waitersByOrder) Len() int           { return len(s) }
+func (s waitersByOrder) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s waitersByOrder) Less(i, j int) bool { return runsBefore(s[i], s[j]) }
+
+// waitQueue is a heap of waiting runs in the order they will run, satisfies
+// heap.Interface
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int           { return len(q) }
+func (q waitQueue) Less(i, j int) bool { return runsBefore(q[i], q[j]) }
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x interface{}) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
@@ -0,0 +1,109 @@
+package schedule
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/slok/khronos/config"
+	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/storage"
+)
+
+func TestPoolPriority(t *testing.T) {
+	p := newPool(1, 0)
+	if !p.acquire(context.Background(), &job.Job{ID: 1}) {
+		t.Fatalf("Free worker should be acquired")
+	}
+
+	// Queue the runs with different priorities
+	started := make(chan int, 3)
+	for _, j := range []*job.Job{{ID: 2}, {ID: 3, Priority: 10}, {ID: 4}} {
+		go func(j *job.Job) {
+			if p.acquire(context.Background(), j) {
+				started <- j.ID
+				p.release()
+			}
+		}(j)
+		// Keep the arrival order
+		for len(p.state().Queue) < j.ID-1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	st := p.state()
+	if st.Size != 1 || st.Running != 1 || len(st.Queue) != 3 {
+		t.Fatalf("Wrong pool state: %+v", st)
+	}
+	wantOrder := []int{3, 2, 4}
+	for i, r := range st.Queue {
+		if r.JobID != wantOrder[i] {
+			t.Errorf("Queued run %d should be job '%d'; got '%d'", i, wantOrder[i], r.JobID)
+		}
+	}
+
+	p.release()
+	for _, want := range wantOrder {
+		if got := <-started; got != want {
+			t.Errorf("Job '%d' should run; got '%d'", want, got)
+		}
+	}
+
+	if st := p.state(); st.Running != 0 || len(st.Queue) != 0 {
+		t.Errorf("Pool should be empty; got: %+v", st)
+	}
+}
+
+func TestPoolMaxQueueWait(t *testing.T) {
+	p := newPool(1, 50*time.Millisecond)
+	p.acquire(context.Background(), &job.Job{ID: 1})
+
+	start := time.Now()
+	if p.acquire(context.Background(), &job.Job{ID: 2}) {
+		t.Errorf("Run should be dropped")
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Errorf("Run should wait the max queue wait; waited: %v", d)
+	}
+	if st := p.state(); st.Dropped != 1 || len(st.Queue) != 0 || st.Running != 1 {
+		t.Errorf("Wrong pool state: %+v", st)
+	}
+
+	// Released workers are acquired again
+	p.release()
+	if !p.acquire(context.Background(), &job.Job{ID: 3}) {
+		t.Errorf("Free worker should be acquired")
+	}
+}
+
+func TestCronJobDropped(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	dCron.pool = newPool(1, 10*time.Millisecond)
+
+	// Take the only worker
+	dCron.pool.acquire(context.Background(), &job.Job{ID: 1})
+	r := dCron.runJob(context.Background(), &job.Result{Job: &job.Job{ID: 2}})
+	if r.Status != job.ResultDropped || r.Out == "" {
+		t.Errorf("Run should be dropped; got: %+v", r)
+	}
+}
+
+func TestPoolAcquireCanceled(t *testing.T) {
+	p := newPool(1, 0)
+	p.acquire(context.Background(), &job.Job{ID: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if p.acquire(ctx, &job.Job{ID: 2}) {
+		t.Errorf("Run should not be acquired after canceling")
+	}
+	if st := p.state(); len(st.Queue) != 0 || st.Running != 1 {
+		t.Errorf("Wrong pool state: %+v", st)
+	}
+}
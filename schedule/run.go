@@ -4,16 +4,23 @@ import (
 	"time"
 
 	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/secret"
 )
 
-const timeout = 2 * time.Second
-
-// SimpleRun has the simples execution flow of a job, log, time, and http
-func SimpleRun() Scheduler {
+// SimpleRun has the simples execution flow of a job, log, time, templates,
+// retry and http or command based on the job type; the timeout will be used on
+// the jobs that don't have a custom timeout, the HTTP calls wait for the limits
+// of their destination host and their templates use the secrets of the store
+func SimpleRun(timeout time.Duration, limiter *HostLimiter, secrets *secret.Store) Scheduler {
 	final := SchedulerFunc(func(r *job.Result, j *job.Job) {})
 	s := LogScheduler(
 		TimingScheduler(
-			HTTPScheduler(timeout, final))) // TODO: Custom timeout per job
+			TemplateScheduler(secrets,
+				RetryScheduler(
+					TypeScheduler(map[string]Scheduler{
+						job.TypeHTTP:    HostLimitScheduler(limiter, HTTPScheduler(timeout, final)),
+						job.TypeCommand: CommandScheduler(timeout, final),
+					})))))
 	return s
 }
 
@@ -2,8 +2,14 @@
 package schedule
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"os/exec"
+	"strings"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -31,7 +37,7 @@ func DummyScheduler(exitStatus int, resultOut string, s Scheduler) Scheduler {
 	return SchedulerFunc(func(r *job.Result, j *job.Job) {
 		r.Out = resultOut
 		r.Status = exitStatus
-		logrus.Infof("Dummy HTTP request: %v", j.URL.String())
+		logrus.Infof("Dummy execution of job: %d", j.ID)
 		// s.Run(r, j)
 	})
 }
@@ -45,22 +51,66 @@ func TimingScheduler(s Scheduler) Scheduler {
 	})
 }
 
-// HTTPScheduler makes an http call to the job destination and registers the result
-// http executed jobs should return 200 if job went ok and 500 if it went wrong,
-// everything else will be interpreted as unknown
+// httpResultStatus returns the result status of an HTTP status code. If the job
+// has success codes everything out of them will be an error, if not 2xx will be
+// ok, 5xx error and everything else will be interpreted as unknown
+func httpResultStatus(code int, successCodes []job.StatusRange) int {
+	if len(successCodes) > 0 {
+		for _, sc := range successCodes {
+			if sc.Contains(code) {
+				return job.ResultOK
+			}
+		}
+		return job.ResultError
+	}
+
+	switch {
+	case code >= 200 && code < 300:
+		return job.ResultOK
+	case code >= 500 && code < 600:
+		return job.ResultError
+	default:
+		return job.ResultUnknow
+	}
+}
+
+// HTTPScheduler makes an http call to the job destination and registers the result,
+// the request is made with the method, headers and body of the job.
+// The result status is based on the job success codes (see httpResultStatus),
+// and an ok result will end in error if any of the job assertions fails.
+// The timeout will be used when the job doesn't have a custom one.
 func HTTPScheduler(timeout time.Duration, s Scheduler) Scheduler {
 	return SchedulerFunc(func(r *job.Result, j *job.Job) {
-		// Create a custom client for each request based on the timeout
-		c := http.Client{Timeout: timeout}
+		// Create a custom client for each request based on the job timeout
+		to := timeout
+		if j.Timeout > 0 {
+			to = j.Timeout
+		}
+		c := http.Client{Timeout: to}
+
+		// Default to GET if the job doesn't set a method
+		method := j.Method
+		if method == "" {
+			method = job.DefaultMethod
+		}
 
 		// Using if else because we never return, always continue the scheduler flow to the next one
 		// Get the http call
-		req, err := http.NewRequest("GET", j.URL.String(), nil)
+		req, err := http.NewRequest(method, j.URL.String(), strings.NewReader(j.Body))
 		if err != nil {
 			logrus.Errorf("Error creating response '%s': %s", j.URL.String(), err)
 			r.Status = job.ResultInternalError
 			r.Out = err.Error()
 		} else {
+			req = req.WithContext(r.Ctx())
+			for k, v := range j.Headers {
+				// Host header is ignored by the client, needs to be set on the request
+				if strings.EqualFold(k, "Host") {
+					req.Host = v
+					continue
+				}
+				req.Header.Set(k, v)
+			}
 			resp, err := c.Do(req)
 			// If err then set as internal error executing job
 			if err != nil {
@@ -70,17 +120,8 @@ func HTTPScheduler(timeout time.Duration, s Scheduler) Scheduler {
 			} else {
 				defer resp.Body.Close()
 
-				switch resp.StatusCode {
-				//if 200 then ok
-				case 200:
-					r.Status = job.ResultOK
-					// if 500 then error
-				case 500:
-					r.Status = job.ResultError
-					//else then unknown
-				default:
-					r.Status = job.ResultUnknow
-				}
+				r.Status = httpResultStatus(resp.StatusCode, j.SuccessCodes)
+
 				// If error getting the job result then mark as wrong
 				b, err := ioutil.ReadAll(resp.Body)
 				if err != nil {
@@ -89,6 +130,16 @@ func HTTPScheduler(timeout time.Duration, s Scheduler) Scheduler {
 					r.Out = err.Error()
 				} else {
 					r.Out = string(b)
+					// Only ok results need to pass the assertions
+					if r.Status == job.ResultOK {
+						for _, a := range j.Assertions {
+							if err := a.Check(b); err != nil {
+								r.Status = job.ResultError
+								r.Out = fmt.Sprintf("Assertion failed: %s: %v\n\n%s", a, err, r.Out)
+								break
+							}
+						}
+					}
 				}
 			}
 		}
@@ -96,6 +147,136 @@ func HTTPScheduler(timeout time.Duration, s Scheduler) Scheduler {
 	})
 }
 
+// TypeScheduler executes the job with the scheduler of its type, jobs without
+// type are HTTP jobs
+func TypeScheduler(schedulers map[string]Scheduler) Scheduler {
+	return SchedulerFunc(func(r *job.Result, j *job.Job) {
+		t := j.Type
+		if t == "" {
+			t = job.TypeHTTP
+		}
+
+		s, ok := schedulers[t]
+		if !ok {
+			logrus.Errorf("Job '%d' has a not supported type '%s'", j.ID, t)
+			r.Status = job.ResultInternalError
+			r.Out = fmt.Sprintf("not supported job type '%s'", t)
+			return
+		}
+		s.Run(r, j)
+	})
+}
+
+// CommandScheduler runs the local command of the job and registers the result,
+// the stdout, stderr and exit code of the command are set on the result.
+// Commands that exit with 0 will be ok and the rest errors; commands that can't
+// be run or are killed by the timeout will be internal errors.
+// The timeout will be used when the job doesn't have a custom one.
+func CommandScheduler(timeout time.Duration, s Scheduler) Scheduler {
+	return SchedulerFunc(func(r *job.Result, j *job.Job) {
+		to := timeout
+		if j.Timeout > 0 {
+			to = j.Timeout
+		}
+
+		if j.Command == nil {
+			logrus.Errorf("Job '%d' doesn't have a command", j.ID)
+			r.Status = job.ResultInternalError
+			r.Out = "missing command"
+			s.Run(r, j)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Ctx(), to)
+		defer cancel()
+
+		cmd := exec.CommandContext(ctx, j.Command.Path, j.Command.Args...)
+		cmd.Dir = j.Command.Dir
+		cmd.Env = os.Environ()
+		for k, v := range j.Command.Env {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+		}
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		err := cmd.Run()
+		r.Out = stdout.String()
+		r.Stderr = stderr.String()
+
+		exitErr, exited := err.(*exec.ExitError)
+		switch {
+		// Killed by the timeout
+		case ctx.Err() != nil:
+			logrus.Errorf("Error running command '%s': %s", j.Command.Path, ctx.Err())
+			r.Status = job.ResultInternalError
+			r.ExitCode = -1
+			r.Stderr = ctx.Err().Error()
+		// Ended but not ok
+		case exited:
+			r.Status = job.ResultError
+			r.ExitCode = exitErr.ExitCode()
+		// Couldn't run
+		case err != nil:
+			logrus.Errorf("Error running command '%s': %s", j.Command.Path, err)
+			r.Status = job.ResultInternalError
+			r.ExitCode = -1
+			r.Stderr = err.Error()
+		default:
+			r.Status = job.ResultOK
+			r.ExitCode = 0
+		}
+
+		s.Run(r, j)
+	})
+}
+
+// RetryScheduler retries the execution of the wrapped scheduler based on the retry
+// policy of the job, each one of the executions is registered as an attempt on
+// the result and the last one will be the final status of the result
+func RetryScheduler(s Scheduler) Scheduler {
+	return SchedulerFunc(func(r *job.Result, j *job.Job) {
+		// No policy, no retries
+		if j.Retry == nil {
+			s.Run(r, j)
+			return
+		}
+
+		for n := 1; ; n++ {
+			// Each attempt is executed on its own result
+			ar := &job.Result{Job: r.Job, Context: r.Context}
+			a := &job.Attempt{Number: n, Start: time.Now().UTC()}
+			s.Run(ar, j)
+			a.Finish = time.Now().UTC()
+			a.Status = ar.Status
+			a.Out = ar.Out
+			a.Stderr = ar.Stderr
+			a.ExitCode = ar.ExitCode
+			r.Attempts = append(r.Attempts, a)
+
+			// Last attempt is the result
+			r.Status = ar.Status
+			r.Out = ar.Out
+			r.Stderr = ar.Stderr
+			r.ExitCode = ar.ExitCode
+			r.HostWait += ar.HostWait
+
+			if n >= j.Retry.MaxAttempts || !j.Retry.Retryable(ar.Status) {
+				return
+			}
+
+			w := j.Retry.Wait(n)
+			logrus.Warningf("Attempt %d of job '%d' failed with status %d, retrying in %v", n, j.ID, ar.Status, w)
+			select {
+			case <-time.After(w):
+			case <-r.Ctx().Done():
+				// Stopped execution, don't retry
+				return
+			}
+		}
+	})
+}
+
 // LogScheduler logs the execution of a job
 func LogScheduler(s Scheduler) Scheduler {
 	return SchedulerFunc(func(r *job.Result, j *job.Job) {
@@ -1,10 +1,13 @@
 package schedule
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -120,3 +123,420 @@ func TestHTTPScheduler(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPSchedulerRequest(t *testing.T) {
+	tests := []struct {
+		givenMethod  string
+		givenHeaders map[string]string
+		givenBody    string
+		wantMethod   string
+	}{
+		{
+			wantMethod: "GET",
+		},
+		{
+			givenMethod:  "POST",
+			givenHeaders: map[string]string{"Content-Type": "application/json", "Authorization": "Bearer 123456789"},
+			givenBody:    `{"msg": "hello world"}`,
+			wantMethod:   "POST",
+		},
+		{
+			givenMethod:  "DELETE",
+			givenHeaders: map[string]string{"X-Khronos": "test"},
+			wantMethod:   "DELETE",
+		},
+	}
+
+	for _, test := range tests {
+		var gotMethod, gotBody string
+		gotHeaders := http.Header{}
+
+		// Create our fake server that will record the request
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotMethod = r.Method
+			gotHeaders = r.Header
+			b, _ := ioutil.ReadAll(r.Body)
+			gotBody = string(b)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer ts.Close()
+
+		// Create the job
+		u, _ := url.Parse(ts.URL)
+		j := &job.Job{
+			URL:     u,
+			Method:  test.givenMethod,
+			Headers: test.givenHeaders,
+			Body:    test.givenBody,
+		}
+		r := &job.Result{}
+		HTTPScheduler(2*time.Second, SchedulerFunc(func(r *job.Result, j *job.Job) {})).Run(r, j)
+
+		if r.Status != job.ResultOK {
+			t.Errorf("result exit status should be: %d; got: %d", job.ResultOK, r.Status)
+		}
+		if gotMethod != test.wantMethod {
+			t.Errorf("request method should be: %s; got: %s", test.wantMethod, gotMethod)
+		}
+		if gotBody != test.givenBody {
+			t.Errorf("request body should be: %s; got: %s", test.givenBody, gotBody)
+		}
+		for k, v := range test.givenHeaders {
+			if gotHeaders.Get(k) != v {
+				t.Errorf("request header '%s' should be: %s; got: %s", k, v, gotHeaders.Get(k))
+			}
+		}
+	}
+}
+
+func TestHTTPSchedulerJobTimeout(t *testing.T) {
+	tests := []struct {
+		givenTimeout    time.Duration
+		givenJobTimeout time.Duration
+		wantStatus      int
+	}{
+		{givenTimeout: 2 * time.Second, givenJobTimeout: 0, wantStatus: job.ResultOK},
+		{givenTimeout: 2 * time.Second, givenJobTimeout: 10 * time.Millisecond, wantStatus: job.ResultInternalError},
+		{givenTimeout: 10 * time.Millisecond, givenJobTimeout: 2 * time.Second, wantStatus: job.ResultOK},
+		{givenTimeout: 10 * time.Millisecond, givenJobTimeout: 0, wantStatus: job.ResultInternalError},
+	}
+
+	// Create our fake slow server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	for _, test := range tests {
+		u, _ := url.Parse(ts.URL)
+		j := &job.Job{
+			URL:     u,
+			Timeout: test.givenJobTimeout,
+		}
+		r := &job.Result{}
+		HTTPScheduler(test.givenTimeout, SchedulerFunc(func(r *job.Result, j *job.Job) {})).Run(r, j)
+
+		if test.wantStatus != r.Status {
+			t.Errorf("result exit status should be: %d; got: %d", test.wantStatus, r.Status)
+		}
+	}
+}
+
+func TestRetryScheduler(t *testing.T) {
+	tests := []struct {
+		givenRetry     *job.Retry
+		givenStatuses  []int
+		wantAttempts   int
+		wantStatus     int
+		wantExecutions int
+	}{
+		{
+			givenRetry:     nil,
+			givenStatuses:  []int{job.ResultError, job.ResultOK},
+			wantAttempts:   0,
+			wantStatus:     job.ResultError,
+			wantExecutions: 1,
+		},
+		{
+			givenRetry:     &job.Retry{MaxAttempts: 3, Backoff: time.Millisecond, Factor: 2, On: []int{job.ResultError}},
+			givenStatuses:  []int{job.ResultOK},
+			wantAttempts:   1,
+			wantStatus:     job.ResultOK,
+			wantExecutions: 1,
+		},
+		{
+			givenRetry:     &job.Retry{MaxAttempts: 3, Backoff: time.Millisecond, Factor: 2, On: []int{job.ResultError}},
+			givenStatuses:  []int{job.ResultError, job.ResultError, job.ResultOK},
+			wantAttempts:   3,
+			wantStatus:     job.ResultOK,
+			wantExecutions: 3,
+		},
+		{
+			givenRetry:     &job.Retry{MaxAttempts: 2, Backoff: time.Millisecond, Factor: 2, On: []int{job.ResultError}},
+			givenStatuses:  []int{job.ResultError, job.ResultError, job.ResultOK},
+			wantAttempts:   2,
+			wantStatus:     job.ResultError,
+			wantExecutions: 2,
+		},
+		{
+			givenRetry:     &job.Retry{MaxAttempts: 3, Backoff: time.Millisecond, Factor: 2, On: []int{job.ResultError}},
+			givenStatuses:  []int{job.ResultInternalError, job.ResultOK},
+			wantAttempts:   1,
+			wantStatus:     job.ResultInternalError,
+			wantExecutions: 1,
+		},
+	}
+
+	for _, test := range tests {
+		executions := 0
+		j := &job.Job{Retry: test.givenRetry}
+		r := &job.Result{Job: j}
+
+		// Each execution will return the next status
+		RetryScheduler(SchedulerFunc(func(r *job.Result, j *job.Job) {
+			r.Status = test.givenStatuses[executions]
+			r.Out = fmt.Sprintf("execution %d", executions)
+			executions++
+		})).Run(r, j)
+
+		if executions != test.wantExecutions {
+			t.Errorf("executions should be: %d; got: %d", test.wantExecutions, executions)
+		}
+		if len(r.Attempts) != test.wantAttempts {
+			t.Errorf("result attempts should be: %d; got: %d", test.wantAttempts, len(r.Attempts))
+		}
+		if r.Status != test.wantStatus {
+			t.Errorf("result exit status should be: %d; got: %d", test.wantStatus, r.Status)
+		}
+
+		// Check attempts are in order and the last one is the result
+		for i, a := range r.Attempts {
+			if a.Number != i+1 {
+				t.Errorf("attempt number should be: %d; got: %d", i+1, a.Number)
+			}
+			if a.Status != test.givenStatuses[i] {
+				t.Errorf("attempt status should be: %d; got: %d", test.givenStatuses[i], a.Status)
+			}
+		}
+		if len(r.Attempts) > 0 && r.Out != r.Attempts[len(r.Attempts)-1].Out {
+			t.Errorf("result output should be the last attempt output: %s; got: %s", r.Attempts[len(r.Attempts)-1].Out, r.Out)
+		}
+	}
+}
+
+func TestRetrySchedulerFailingCommand(t *testing.T) {
+	j := &job.Job{
+		Type:    job.TypeCommand,
+		Command: &job.Command{Path: "sh", Args: []string{"-c", "echo -n failed >&2; exit 3"}},
+		Retry:   &job.Retry{MaxAttempts: 2, Backoff: time.Millisecond, Factor: 2, On: []int{job.ResultError}},
+	}
+	r := &job.Result{Job: j}
+	RetryScheduler(CommandScheduler(2*time.Second, SchedulerFunc(func(r *job.Result, j *job.Job) {}))).Run(r, j)
+
+	if len(r.Attempts) != 2 {
+		t.Fatalf("result attempts should be: %d; got: %d", 2, len(r.Attempts))
+	}
+	for _, a := range r.Attempts {
+		if a.ExitCode != 3 || a.Stderr != "failed" {
+			t.Errorf("attempt should have the command exit code and stderr; got: %d, %s", a.ExitCode, a.Stderr)
+		}
+	}
+	if r.Status != job.ResultError || r.ExitCode != 3 || r.Stderr != "failed" {
+		t.Errorf("result should have the last attempt status, exit code and stderr; got: %d, %d, %s", r.Status, r.ExitCode, r.Stderr)
+	}
+}
+
+func TestHTTPSchedulerSuccessCriteria(t *testing.T) {
+	tests := []struct {
+		givenCode         int
+		givenBody         string
+		givenSuccessCodes []job.StatusRange
+		givenAssertions   []*job.Assertion
+		wantStatus        int
+		wantOutPrefix     string
+	}{
+		{
+			givenCode:  http.StatusNoContent,
+			wantStatus: job.ResultOK,
+		},
+		{
+			givenCode:  http.StatusServiceUnavailable,
+			wantStatus: job.ResultError,
+		},
+		{
+			givenCode:         http.StatusAccepted,
+			givenSuccessCodes: []job.StatusRange{{From: 200, To: 200}},
+			wantStatus:        job.ResultError,
+		},
+		{
+			givenCode:         http.StatusNotFound,
+			givenSuccessCodes: []job.StatusRange{{From: 200, To: 299}, {From: 404, To: 404}},
+			wantStatus:        job.ResultOK,
+		},
+		{
+			givenCode:       http.StatusOK,
+			givenBody:       `{"status": "ok"}`,
+			givenAssertions: []*job.Assertion{{Type: job.AssertionJSONPath, Path: "$.status", Value: "ok"}, {Type: job.AssertionContains, Value: "ok"}},
+			wantStatus:      job.ResultOK,
+		},
+		{
+			givenCode:       http.StatusOK,
+			givenBody:       `{"status": "failed"}`,
+			givenAssertions: []*job.Assertion{{Type: job.AssertionContains, Value: "status"}, {Type: job.AssertionRegex, Value: `"status": "ok"`}},
+			wantStatus:      job.ResultError,
+			wantOutPrefix:   `Assertion failed: regex "\"status\": \"ok\""`,
+		},
+		{
+			givenCode:       http.StatusInternalServerError,
+			givenBody:       `{"status": "failed"}`,
+			givenAssertions: []*job.Assertion{{Type: job.AssertionContains, Value: "ok"}},
+			wantStatus:      job.ResultError,
+			wantOutPrefix:   `{"status": "failed"}`,
+		},
+	}
+
+	for _, test := range tests {
+		// Create our fake server
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.givenCode)
+			fmt.Fprint(w, test.givenBody)
+		}))
+		defer ts.Close()
+
+		u, _ := url.Parse(ts.URL)
+		j := &job.Job{
+			URL:          u,
+			SuccessCodes: test.givenSuccessCodes,
+			Assertions:   test.givenAssertions,
+		}
+		r := &job.Result{}
+		HTTPScheduler(2*time.Second, SchedulerFunc(func(r *job.Result, j *job.Job) {})).Run(r, j)
+
+		if test.wantStatus != r.Status {
+			t.Errorf("result exit status should be: %d; got: %d", test.wantStatus, r.Status)
+		}
+		if !strings.HasPrefix(r.Out, test.wantOutPrefix) {
+			t.Errorf("result output should start with: %s; got: %s", test.wantOutPrefix, r.Out)
+		}
+	}
+}
+
+func TestCommandScheduler(t *testing.T) {
+	tests := []struct {
+		givenCommand *job.Command
+		givenTimeout time.Duration
+		wantStatus   int
+		wantExitCode int
+		wantOut      string
+		wantStderr   string
+		checkStderr  bool
+	}{
+		{
+			givenCommand: &job.Command{Path: "sh", Args: []string{"-c", "echo -n hello"}},
+			givenTimeout: 2 * time.Second,
+			wantStatus:   job.ResultOK,
+			wantExitCode: 0,
+			wantOut:      "hello",
+			checkStderr:  true,
+		},
+		{
+			givenCommand: &job.Command{Path: "sh", Args: []string{"-c", "echo -n failed >&2; exit 3"}},
+			givenTimeout: 2 * time.Second,
+			wantStatus:   job.ResultError,
+			wantExitCode: 3,
+			wantStderr:   "failed",
+			checkStderr:  true,
+		},
+		{
+			givenCommand: &job.Command{Path: "sh", Args: []string{"-c", "echo -n $KHRONOS_TEST"}, Env: map[string]string{"KHRONOS_TEST": "test"}},
+			givenTimeout: 2 * time.Second,
+			wantStatus:   job.ResultOK,
+			wantOut:      "test",
+		},
+		{
+			givenCommand: &job.Command{Path: "pwd", Dir: "/"},
+			givenTimeout: 2 * time.Second,
+			wantStatus:   job.ResultOK,
+			wantOut:      "/\n",
+		},
+		{
+			givenCommand: &job.Command{Path: "sleep", Args: []string{"1"}},
+			givenTimeout: 10 * time.Millisecond,
+			wantStatus:   job.ResultInternalError,
+			wantExitCode: -1,
+		},
+		{
+			givenCommand: &job.Command{Path: "/khronos/not/present"},
+			givenTimeout: 2 * time.Second,
+			wantStatus:   job.ResultInternalError,
+			wantExitCode: -1,
+		},
+		{
+			givenCommand: nil,
+			givenTimeout: 2 * time.Second,
+			wantStatus:   job.ResultInternalError,
+			wantOut:      "missing command",
+		},
+	}
+
+	for _, test := range tests {
+		j := &job.Job{Type: job.TypeCommand, Command: test.givenCommand}
+		r := &job.Result{}
+		CommandScheduler(test.givenTimeout, SchedulerFunc(func(r *job.Result, j *job.Job) {})).Run(r, j)
+
+		if test.wantStatus != r.Status {
+			t.Errorf("result exit status should be: %d; got: %d", test.wantStatus, r.Status)
+		}
+		if test.wantExitCode != r.ExitCode {
+			t.Errorf("result exit code should be: %d; got: %d", test.wantExitCode, r.ExitCode)
+		}
+		if test.wantOut != r.Out {
+			t.Errorf("result output should be: %s; got: %s", test.wantOut, r.Out)
+		}
+		if test.checkStderr && test.wantStderr != r.Stderr {
+			t.Errorf("result stderr should be: %s; got: %s", test.wantStderr, r.Stderr)
+		}
+	}
+}
+
+func TestTypeScheduler(t *testing.T) {
+	tests := []struct {
+		givenType  string
+		wantOut    string
+		wantStatus int
+	}{
+		{givenType: "", wantOut: "http", wantStatus: job.ResultOK},
+		{givenType: job.TypeHTTP, wantOut: "http", wantStatus: job.ResultOK},
+		{givenType: job.TypeCommand, wantOut: "command", wantStatus: job.ResultOK},
+		{givenType: "ftp", wantOut: "not supported job type 'ftp'", wantStatus: job.ResultInternalError},
+	}
+
+	final := SchedulerFunc(func(r *job.Result, j *job.Job) {})
+	s := TypeScheduler(map[string]Scheduler{
+		job.TypeHTTP:    DummyScheduler(job.ResultOK, "http", final),
+		job.TypeCommand: DummyScheduler(job.ResultOK, "command", final),
+	})
+
+	for _, test := range tests {
+		j := &job.Job{Type: test.givenType}
+		r := &job.Result{}
+		s.Run(r, j)
+
+		if test.wantOut != r.Out {
+			t.Errorf("result output should be: %s; got: %s", test.wantOut, r.Out)
+		}
+		if test.wantStatus != r.Status {
+			t.Errorf("result exit status should be: %d; got: %d", test.wantStatus, r.Status)
+		}
+	}
+}
+
+func TestHTTPSchedulerCanceled(t *testing.T) {
+	// Create our fake slow server
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	j := &job.Job{URL: u}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &job.Result{Context: ctx}
+
+	// Cancel while the request is being made
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	start := time.Now()
+	HTTPScheduler(2*time.Second, SchedulerFunc(func(r *job.Result, j *job.Job) {})).Run(r, j)
+
+	if r.Status != job.ResultInternalError {
+		t.Errorf("result exit status should be: %d; got: %d", job.ResultInternalError, r.Status)
+	}
+	if time.Since(start) >= 200*time.Millisecond {
+		t.Errorf("canceled request should not wait the response")
+	}
+}
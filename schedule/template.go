@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/secret"
+)
+
+// TemplateScheduler renders the templates of the HTTP request of the job with
+// the values of the execution and the secrets of the job namespace and runs the wrapped
+// scheduler with the rendered request, the request is registered on the result.
+// The values of the secrets are masked on the result. The not HTTP jobs run as
+// they are
+func TemplateScheduler(secrets *secret.Store, s Scheduler) Scheduler {
+	return SchedulerFunc(func(r *job.Result, j *job.Job) {
+		if j.Type != "" && j.Type != job.TypeHTTP {
+			s.Run(r, j)
+			return
+		}
+
+		st := r.Scheduled
+		if st.IsZero() {
+			st = time.Now().UTC()
+		}
+		values := []string{}
+		req, err := j.RenderRequest(&job.TemplateData{
+			JobID:         j.ID,
+			JobName:       j.Name,
+			RunID:         r.RunID,
+			ScheduledTime: st,
+			Vars:          j.Variables,
+			Secret: func(name string) (string, error) {
+				v, err := secrets.Value(j.NamespaceOrDefault(), name)
+				if err == nil {
+					values = append(values, v)
+				}
+				return v, err
+			},
+		})
+		if err != nil {
+			logrus.Errorf("Error rendering request of job '%d': %s", j.ID, err)
+			r.Status = job.ResultInternalError
+			r.Out = fmt.Sprintf("error rendering request: %v", err)
+			return
+		}
+		r.Request = maskedRequest(req, values)
+
+		// Run with a copy of the job with the rendered request
+		u, _ := url.ParseRequestURI(req.URL)
+		rj := *j
+		rj.URL = u
+		rj.URLTemplate = ""
+		rj.Method = req.Method
+		rj.Headers = req.Headers
+		rj.Body = req.Body
+		s.Run(r, &rj)
+
+		// The responses could have the secrets (e.g echo of the request)
+		r.Out = secret.MaskValues(r.Out, values)
+		r.Stderr = secret.MaskValues(r.Stderr, values)
+		for _, a := range r.Attempts {
+			a.Out = secret.MaskValues(a.Out, values)
+		}
+	})
+}
+
+// maskedRequest returns a copy of a request with the secret values masked
+func maskedRequest(req *job.Request, values []string) *job.Request {
+	mr := &job.Request{
+		Method: req.Method,
+		URL:    secret.MaskValues(req.URL, values),
+		Body:   secret.MaskValues(req.Body, values),
+	}
+	if req.Headers != nil {
+		mr.Headers = make(map[string]string, len(req.Headers))
+		for k, v := range req.Headers {
+			mr.Headers[k] = secret.MaskValues(v, values)
+		}
+	}
+	return mr
+}
@@ -0,0 +1,119 @@
+package schedule
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/secret"
+	"github.com/slok/khronos/storage"
+)
+
+func TestTemplateScheduler(t *testing.T) {
+	var gotURI, gotHeader, gotAuth, gotBody string
+	secrets := secret.NewStore("MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=", storage.NewDummy())
+	secrets.Save(job.DefaultNamespace, &job.Secret{Name: "api-token"}, "s3cr3t")
+
+	// Create our fake server that will record the request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURI = r.URL.RequestURI()
+		gotHeader = r.Header.Get("X-Run")
+		gotAuth = r.Header.Get("Authorization")
+		b, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(b)
+		// Echo the credentials
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("authorized with " + gotAuth))
+	}))
+	defer ts.Close()
+
+	j := &job.Job{
+		ID:          3,
+		Template:    true,
+		URLTemplate: ts.URL + `/{{.Vars.team}}/reports?date={{.ScheduledTime | date "2006-01-02"}}`,
+		Method:      "POST",
+		Headers:     map[string]string{"X-Run": "{{.RunID}}", "Authorization": `Bearer {{secret "api-token"}}`},
+		Body:        `{"job": {{.JobID}}}`,
+		Variables:   map[string]string{"team": "billing"},
+	}
+	r := &job.Result{RunID: "8a1f3c", Scheduled: time.Date(2017, time.December, 25, 2, 0, 0, 0, time.UTC)}
+	TemplateScheduler(secrets, HTTPScheduler(2*time.Second, SchedulerFunc(func(r *job.Result, j *job.Job) {}))).Run(r, j)
+
+	if r.Status != job.ResultOK {
+		t.Errorf("result exit status should be: %d; got: %d", job.ResultOK, r.Status)
+	}
+	if want := "/billing/reports?date=2017-12-25"; gotURI != want {
+		t.Errorf("request URI should be: %s; got: %s", want, gotURI)
+	}
+	if gotHeader != "8a1f3c" || gotBody != `{"job": 3}` {
+		t.Errorf("request should be rendered; got header: %s, body: %s", gotHeader, gotBody)
+	}
+
+	// The secrets are sent but masked on the result
+	if gotAuth != "Bearer s3cr3t" {
+		t.Errorf("request should have the secret; got: %s", gotAuth)
+	}
+	if r.Out != "authorized with Bearer ******" || r.Request.Headers["Authorization"] != "Bearer ******" {
+		t.Errorf("result should have the secret masked; got: %s, %#v", r.Out, r.Request)
+	}
+
+	// The rendered request is registered
+	if r.Request == nil || r.Request.URL != ts.URL+"/billing/reports?date=2017-12-25" || r.Request.Body != gotBody {
+		t.Errorf("result should have the rendered request; got: %#v", r.Request)
+	}
+	// The job is not changed
+	if j.URL != nil || j.Body != `{"job": {{.JobID}}}` {
+		t.Errorf("job shouldn't be changed; got: %#v", j)
+	}
+
+	// Not valid templates and missing secrets don't run
+	j.Body = `{{secret "missing"}}`
+	r = &job.Result{}
+	TemplateScheduler(secrets, SchedulerFunc(func(r *job.Result, j *job.Job) {
+		t.Errorf("job with not valid template shouldn't run")
+	})).Run(r, j)
+	if r.Status != job.ResultInternalError {
+		t.Errorf("result exit status should be: %d; got: %d", job.ResultInternalError, r.Status)
+	}
+
+	// The secrets of other namespaces can't be used
+	j.Namespace = "payments"
+	j.Body = `{{secret "api-token"}}`
+	r = &job.Result{}
+	TemplateScheduler(secrets, SchedulerFunc(func(r *job.Result, j *job.Job) {
+		t.Errorf("job with a secret of other namespace shouldn't run")
+	})).Run(r, j)
+	if r.Status != job.ResultInternalError {
+		t.Errorf("result exit status should be: %d; got: %d", job.ResultInternalError, r.Status)
+	}
+}
+
+func TestTemplateSchedulerNotTemplatedJob(t *testing.T) {
+	var gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	// The literal template actions are sent as they are
+	u, _ := url.Parse(ts.URL + "/render")
+	j := &job.Job{ID: 3, URL: u, Method: "POST", Body: `{"template": "Hello {{.Name}}"}`}
+	r := &job.Result{RunID: "8a1f3c"}
+	TemplateScheduler(nil, HTTPScheduler(2*time.Second, SchedulerFunc(func(r *job.Result, j *job.Job) {}))).Run(r, j)
+
+	if r.Status != job.ResultOK {
+		t.Errorf("result exit status should be: %d; got: %d", job.ResultOK, r.Status)
+	}
+	if gotBody != j.Body {
+		t.Errorf("request body should be: %s; got: %s", j.Body, gotBody)
+	}
+	if r.Request == nil || r.Request.URL != u.String() || r.Request.Body != j.Body {
+		t.Errorf("result should have the request; got: %#v", r.Request)
+	}
+}
@@ -0,0 +1,182 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+// processWorkflow adds a result to its workflow run and triggers the downstream
+// jobs of the result job. The results that aren't part of a workflow run start
+// a new one if the job has downstream jobs. Only the executed results start or
+// advance the workflow runs, the not executed ones only end their step. Only
+// called by the result processor
+func (c *Cron) processWorkflow(r *job.Result) {
+	var w *job.WorkflowRun
+	if r.WorkflowRun == 0 {
+		if !r.Executed() || len(c.downstreamEntries(r.Job.ID)) == 0 {
+			return
+		}
+		w = &job.WorkflowRun{
+			JobID:     r.Job.ID,
+			Namespace: r.Job.NamespaceOrDefault(),
+			Status:    job.WorkflowRunning,
+			Start:     r.Start,
+			Steps:     []*job.WorkflowStep{{JobID: r.Job.ID}},
+		}
+		if err := c.storage.SaveWorkflowRun(w); err != nil {
+			logrus.Errorf("error starting workflow run of job '%d': %v", r.Job.ID, err)
+			return
+		}
+		c.workflows[w.ID] = w
+		logrus.Debugf("Started workflow run '%d' by job '%d'", w.ID, r.Job.ID)
+
+		// Link the stored result with its workflow run
+		r.WorkflowRun = w.ID
+		if r.ID != 0 {
+			if err := c.storage.SaveResult(r); err != nil {
+				logrus.Errorf("error saving result '%d' from job '%d'", r.ID, r.Job.ID)
+			}
+		}
+	} else {
+		var ok bool
+		if w, ok = c.workflows[r.WorkflowRun]; !ok {
+			logrus.Warningf("Workflow run '%d' of job '%d' result is not running", r.WorkflowRun, r.Job.ID)
+			return
+		}
+	}
+
+	s := w.Step(r.Job.ID)
+	if s == nil {
+		logrus.Warningf("Job '%d' is not a step of workflow run '%d'", r.Job.ID, w.ID)
+		return
+	}
+	s.ResultID = r.ID
+	s.Status = r.Status
+	s.Start = r.Start
+	s.Finish = r.Finish
+
+	if r.Executed() {
+		c.triggerDownstreamJobs(w, r.Job.ID)
+	}
+	c.finishWorkflowRun(w)
+
+	if err := c.storage.SaveWorkflowRun(w); err != nil {
+		logrus.Errorf("error saving workflow run '%d': %v", w.ID, err)
+	}
+}
+
+// triggerDownstreamJobs starts the downstream jobs of a job on a workflow run
+// when all their upstream jobs have ended since they were last triggered, on
+// this or on previous workflow runs, so the upstream jobs scheduled
+// independently also trigger them. The conditions are checked with the last
+// step of every upstream job, the downstream jobs that don't match them or are
+// paused are skipped
+func (c *Cron) triggerDownstreamJobs(w *job.WorkflowRun, jobID int) {
+	us := w.Step(jobID)
+	for _, e := range c.downstreamEntries(jobID) {
+		dj := e.job
+
+		ups, ok := c.upstreams[dj.ID]
+		if !ok {
+			ups = map[int]*job.WorkflowStep{}
+			c.upstreams[dj.ID] = ups
+		}
+		ups[jobID] = us
+
+		// Already triggered on the workflow run
+		if w.Step(dj.ID) != nil {
+			continue
+		}
+
+		ready, triggered := true, true
+		for _, d := range dj.DependsOn {
+			s, ok := ups[d.JobID]
+			if !ok {
+				ready = false
+				break
+			}
+			if !d.Triggers(s.Status) {
+				triggered = false
+			}
+		}
+		if !ready {
+			continue
+		}
+		// The next trigger waits for new steps of all the upstream jobs
+		delete(c.upstreams, dj.ID)
+
+		now := time.Now().UTC()
+		s := &job.WorkflowStep{JobID: dj.ID, Status: job.ResultRunning, Start: now}
+		w.Steps = append(w.Steps, s)
+
+		if !triggered || !c.entryActive(e) {
+			logrus.Debugf("Skipping job '%d' on workflow run '%d'", dj.ID, w.ID)
+			s.Status = job.ResultSkipped
+			s.Finish = now
+			c.triggerDownstreamJobs(w, dj.ID)
+			continue
+		}
+
+		logrus.Debugf("Triggering job '%d' on workflow run '%d'", dj.ID, w.ID)
+		ctx, ok := c.beginRun()
+		if !ok {
+			logrus.Warningf("Not triggering job '%d' on workflow run '%d', the cron is stopped", dj.ID, w.ID)
+			s.Status = job.ResultDropped
+			s.Finish = now
+			continue
+		}
+		go func(dj *job.Job, id int) {
+			defer c.endRun()
+			c.Results <- c.runJob(ctx, &job.Result{Job: dj, WorkflowRun: id})
+		}(dj, w.ID)
+	}
+}
+
+// finishWorkflowRun ends a workflow run if it doesn't have running jobs
+func (c *Cron) finishWorkflowRun(w *job.WorkflowRun) {
+	status := job.WorkflowSucceeded
+	for _, s := range w.Steps {
+		switch s.Status {
+		case job.ResultRunning:
+			return
+		case job.ResultOK, job.ResultSkipped:
+		default:
+			status = job.WorkflowFailed
+		}
+	}
+
+	w.Status = status
+	w.Finish = time.Now().UTC()
+	delete(c.workflows, w.ID)
+	logrus.Debugf("Finished workflow run '%d' with status: %s", w.ID, status)
+}
+
+// downstreamEntries returns the registered jobs that depend on a job sorted by ID
+func (c *Cron) downstreamEntries(jobID int) []*entry {
+	c.registryMutex.Lock()
+	defer c.registryMutex.Unlock()
+
+	es := []*entry{}
+	for _, e := range c.registry {
+		for _, d := range e.job.DependsOn {
+			if d.JobID == jobID {
+				es = append(es, e)
+				break
+			}
+		}
+	}
+
+	sort.Sort(entriesByJobID(es))
+	return es
+}
+
+// entriesByJobID sorts the registered jobs by ID
+type entriesByJobID []*entry
+
+func (s entriesByJobID) Len() int           { return len(s) }
+func (s entriesByJobID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s entriesByJobID) Less(i, j int) bool { return s[i].job.ID < s[j].job.ID }
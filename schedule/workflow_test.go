@@ -0,0 +1,229 @@
+package schedule
+
+import (
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/slok/khronos/config"
+	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/storage"
+)
+
+func TestWorkflowRun(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+
+	u, _ := url.Parse("http://test.org/test")
+	dep := func(id int, on string) *job.Dependency { return &job.Dependency{JobID: id, On: on} }
+	jobs := []*job.Job{
+		{ID: 1, URL: u, When: "@yearly", Active: true},
+		{ID: 2, URL: u, Active: true, DependsOn: []*job.Dependency{dep(1, job.TriggerSuccess)}},
+		{ID: 3, URL: u, Active: true, DependsOn: []*job.Dependency{dep(2, job.TriggerFailure)}},
+		{ID: 4, URL: u, Active: true, DependsOn: []*job.Dependency{dep(2, job.TriggerSuccess)}},
+		{ID: 5, URL: u, Active: true, DependsOn: []*job.Dependency{dep(4, job.TriggerAlways)}},
+		{ID: 6, URL: u, Active: true, DependsOn: []*job.Dependency{dep(3, job.TriggerSuccess), dep(5, job.TriggerSuccess)}},
+		{ID: 7, URL: u, Active: false, DependsOn: []*job.Dependency{dep(1, job.TriggerSuccess)}},
+	}
+	wantSteps := map[int]int{
+		1: job.ResultOK,
+		2: job.ResultError,
+		3: job.ResultOK,
+		4: job.ResultSkipped,
+		5: job.ResultOK,
+		6: job.ResultOK,
+		7: job.ResultSkipped,
+	}
+
+	// Job 2 fails, the rest end ok
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	dCron.scheduler = SchedulerFunc(func(r *job.Result, j *job.Job) {
+		r.Start = time.Now().UTC()
+		r.Status = job.ResultOK
+		if j.ID == 2 {
+			r.Status = job.ResultError
+		}
+		r.Finish = time.Now().UTC()
+	})
+
+	results := make(chan *job.Result, 10)
+	dCron.Start(func(r *job.Result) {
+		stCli.SaveResult(r)
+		results <- r
+	})
+	for _, j := range jobs {
+		dCron.RegisterCronJob(j)
+	}
+
+	if _, _, err := dCron.RunCronJob(jobs[0]); err != nil {
+		t.Fatalf("Running a job should not get an error: %v", err)
+	}
+
+	// Only the triggered jobs run
+	got := map[int]*job.Result{}
+	for i := 0; i < 5; i++ {
+		select {
+		case r := <-results:
+			got[r.Job.ID] = r
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Workflow jobs should run; got: %d results", len(got))
+		}
+	}
+	for _, id := range []int{1, 2, 3, 5, 6} {
+		if _, ok := got[id]; !ok {
+			t.Errorf("Job '%d' should run on the workflow", id)
+		}
+	}
+
+	// Wait until the workflow run ends
+	var w *job.WorkflowRun
+	for i := 0; i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+		if sw, err := stCli.GetWorkflowRun(1); err == nil && sw.Status != job.WorkflowRunning {
+			w = sw
+			break
+		}
+	}
+	if w == nil {
+		t.Fatalf("Workflow run should end")
+	}
+
+	if w.JobID != 1 || w.Status != job.WorkflowFailed || w.Finish.IsZero() {
+		t.Errorf("Wrong workflow run: %+v", w)
+	}
+	if len(w.Steps) != len(wantSteps) {
+		t.Errorf("Wrong workflow run steps; expected: %d; got: %d", len(wantSteps), len(w.Steps))
+	}
+	for id, st := range wantSteps {
+		s := w.Step(id)
+		if s == nil || s.Status != st {
+			t.Errorf("Job '%d' step status should be %d; got: %+v", id, st, s)
+			continue
+		}
+		if r, ok := got[id]; ok && (s.ResultID != r.ID || r.WorkflowRun != w.ID) {
+			t.Errorf("Job '%d' result should be on the workflow run; got: %+v", id, r)
+		}
+	}
+
+	// A triggered job doesn't have schedule
+	if runs, err := NextRuns(jobs[1], time.Now(), 5); err != nil || len(runs) != 0 {
+		t.Errorf("Triggered job shouldn't have next runs; got: %v, %v", runs, err)
+	}
+}
+
+func TestWorkflowRunFanIn(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+
+	// Jobs 1 and 2 are scheduled independently, job 3 needs both
+	u, _ := url.Parse("http://test.org/test")
+	jobs := []*job.Job{
+		{ID: 1, URL: u, When: "@yearly", Active: true},
+		{ID: 2, URL: u, When: "@yearly", Active: true},
+		{ID: 3, URL: u, Active: true, DependsOn: []*job.Dependency{
+			{JobID: 1, On: job.TriggerSuccess},
+			{JobID: 2, On: job.TriggerSuccess},
+		}},
+	}
+
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+	results := make(chan *job.Result, 10)
+	dCron.Start(func(r *job.Result) {
+		stCli.SaveResult(r)
+		results <- r
+	})
+	defer dCron.Stop()
+	for _, j := range jobs {
+		dCron.RegisterCronJob(j)
+	}
+
+	run := func(j *job.Job) *job.Result {
+		if _, _, err := dCron.RunCronJob(j); err != nil {
+			t.Fatalf("Running a job should not get an error: %v", err)
+		}
+		select {
+		case r := <-results:
+			return r
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Job '%d' should run", j.ID)
+		}
+		return nil
+	}
+
+	// The first upstream job doesn't trigger the downstream job alone
+	if r := run(jobs[0]); r.Job.ID != 1 {
+		t.Fatalf("Job '1' should run; got: %d", r.Job.ID)
+	}
+	select {
+	case r := <-results:
+		t.Fatalf("Job '%d' shouldn't run with only one upstream job ended", r.Job.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// The second upstream job triggers it on its workflow run
+	r2 := run(jobs[1])
+	var r3 *job.Result
+	select {
+	case r3 = <-results:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Job '3' should run when both upstream jobs ended")
+	}
+	if r3.Job.ID != 3 || r3.WorkflowRun != r2.WorkflowRun || r2.WorkflowRun == 0 {
+		t.Errorf("Job '3' should run on the workflow run of job '2'; got: %+v, %+v", r3, r2)
+	}
+
+	// The next trigger needs new executions of both upstream jobs
+	run(jobs[0])
+	select {
+	case r := <-results:
+		t.Errorf("Job '%d' shouldn't run again with only one upstream job ended", r.Job.ID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWorkflowRunNotExecutedResults(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	stCli := storage.NewDummy()
+	dCron := NewDummyCron(cfg, stCli, job.ResultOK, "")
+
+	u, _ := url.Parse("http://test.org/test")
+	jobs := []*job.Job{
+		{ID: 1, URL: u, When: "@yearly", Active: true},
+		{ID: 2, URL: u, Active: true, DependsOn: []*job.Dependency{{JobID: 1, On: job.TriggerFailure}}},
+		{ID: 3, URL: u, Active: true, DependsOn: []*job.Dependency{{JobID: 2, On: job.TriggerAlways}}},
+	}
+	for _, j := range jobs {
+		dCron.RegisterCronJob(j)
+	}
+
+	// The not executed runs don't start workflow runs
+	for _, st := range []int{job.ResultSkipped, job.ResultAbandoned, job.ResultDropped} {
+		dCron.processWorkflow(&job.Result{Job: jobs[0], Status: st})
+	}
+	if w, err := stCli.GetWorkflowRun(1); err == nil {
+		t.Errorf("Not executed results shouldn't start workflow runs; got: %+v", w)
+	}
+	if len(dCron.upstreams) != 0 {
+		t.Errorf("Not executed results shouldn't be upstream steps; got: %v", dCron.upstreams)
+	}
+
+	// The not executed runs end their step without triggering the downstream jobs
+	w := &job.WorkflowRun{JobID: 1, Status: job.WorkflowRunning, Steps: []*job.WorkflowStep{
+		{JobID: 1, ResultID: 1, Status: job.ResultError},
+		{JobID: 2, Status: job.ResultRunning},
+	}}
+	stCli.SaveWorkflowRun(w)
+	dCron.workflows[w.ID] = w
+	dCron.processWorkflow(&job.Result{Job: jobs[1], WorkflowRun: w.ID, Status: job.ResultDropped})
+
+	if s := w.Step(3); s != nil {
+		t.Errorf("Dropped job shouldn't trigger its downstream jobs; got: %+v", s)
+	}
+	if s := w.Step(2); s == nil || s.Status != job.ResultDropped {
+		t.Errorf("Dropped job should end its step; got: %+v", s)
+	}
+	if w.Status != job.WorkflowFailed {
+		t.Errorf("Workflow run should end failed; got: %s", w.Status)
+	}
+}
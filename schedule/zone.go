@@ -0,0 +1,61 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// zoneSchedule is a cron schedule evaluated on the wall clock of a location.
+// On DST gaps (clock jumps forward) the times that don't exist are shifted
+// forward by the gap length (e.g 02:30 on Europe/Madrid runs at 03:30 CEST), on
+// DST overlaps (clock jumps backward) the times that happen twice only run on
+// the first occurrence (e.g 02:30 on Europe/Madrid runs at 02:30 CEST)
+type zoneSchedule struct {
+	schedule cron.Schedule
+	location *time.Location
+}
+
+// Next returns the next activation time after t
+func (z *zoneSchedule) Next(t time.Time) time.Time {
+	// Evaluate the schedule on a location without DST using the wall clock
+	w := wallClock(t.In(z.location))
+	for {
+		w = z.schedule.Next(w)
+		if w.IsZero() {
+			return w
+		}
+		// The wall times of an overlap second occurrence could be before t
+		if n := zoneTime(w, z.location); n.After(t) {
+			return n
+		}
+	}
+}
+
+// wallClock returns the wall clock of t as UTC time
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// zoneTime returns the time of a wall clock (as UTC time) on a location. The wall
+// clocks of a gap are shifted forward by the gap length and the wall clocks of
+// an overlap are the first occurrence
+func zoneTime(w time.Time, loc *time.Location) time.Time {
+	// Offsets of the location around the wall clock
+	_, before := w.Add(-24 * time.Hour).In(loc).Zone()
+	_, after := w.Add(24 * time.Hour).In(loc).Zone()
+
+	// The earlier offset first, on an overlap is the first occurrence
+	first := w.Add(-time.Duration(before) * time.Second).In(loc)
+	if _, off := first.Zone(); off == before {
+		return first
+	}
+	second := w.Add(-time.Duration(after) * time.Second).In(loc)
+	if _, off := second.Zone(); off == after {
+		return second
+	}
+
+	// On a gap none of the offsets are valid, with the offset before the gap
+	// the time is shifted forward by the gap length
+	return first
+}
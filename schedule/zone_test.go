@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestJobScheduleTimeZone(t *testing.T) {
+	now := time.Date(2017, time.January, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		givenTimeZone string
+		wantNext      time.Time
+	}{
+		{givenTimeZone: "Europe/Madrid", wantNext: time.Date(2017, time.January, 11, 8, 0, 0, 0, time.UTC)},
+		{givenTimeZone: "America/New_York", wantNext: time.Date(2017, time.January, 10, 14, 0, 0, 0, time.UTC)},
+		{givenTimeZone: "Asia/Tokyo", wantNext: time.Date(2017, time.January, 11, 0, 0, 0, 0, time.UTC)},
+		{givenTimeZone: "UTC", wantNext: time.Date(2017, time.January, 11, 9, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		j := &job.Job{When: "0 0 9 * * *", TimeZone: test.givenTimeZone}
+		sch, err := jobSchedule(j)
+		if err != nil {
+			t.Errorf("Didn't expect error: %v", err)
+			continue
+		}
+
+		if got := sch.Next(now); !got.Equal(test.wantNext) {
+			t.Errorf("Next run on %s should be %v; got %v", test.givenTimeZone, test.wantNext, got)
+		}
+	}
+}
+
+func TestJobScheduleEveryTimeZone(t *testing.T) {
+	j := &job.Job{When: "@every 1h", TimeZone: "Europe/Madrid"}
+	sch, err := jobSchedule(j)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+
+	// Constant delays don't depend on the time zone
+	if _, ok := sch.(cron.ConstantDelaySchedule); !ok {
+		t.Errorf("Schedule should be a constant delay; got %T", sch)
+	}
+}
+
+func TestZoneScheduleDST(t *testing.T) {
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+
+	tests := []struct {
+		name      string
+		givenWhen string
+		givenFrom time.Time
+		wantNexts []time.Time
+	}{
+		{
+			name:      "gap shifts forward",
+			givenWhen: "0 30 2 * * *",
+			givenFrom: time.Date(2017, time.March, 25, 12, 0, 0, 0, madrid),
+			wantNexts: []time.Time{
+				time.Date(2017, time.March, 26, 1, 30, 0, 0, time.UTC), // 03:30 CEST
+				time.Date(2017, time.March, 27, 0, 30, 0, 0, time.UTC), // 02:30 CEST
+			},
+		},
+		{
+			name:      "gap doesn't duplicate",
+			givenWhen: "0 30 2,3 * * *",
+			givenFrom: time.Date(2017, time.March, 25, 12, 0, 0, 0, madrid),
+			wantNexts: []time.Time{
+				time.Date(2017, time.March, 26, 1, 30, 0, 0, time.UTC), // 03:30 CEST
+				time.Date(2017, time.March, 27, 0, 30, 0, 0, time.UTC), // 02:30 CEST
+			},
+		},
+		{
+			name:      "overlap runs once",
+			givenWhen: "0 30 2 * * *",
+			givenFrom: time.Date(2017, time.October, 28, 12, 0, 0, 0, madrid),
+			wantNexts: []time.Time{
+				time.Date(2017, time.October, 29, 0, 30, 0, 0, time.UTC), // 02:30 CEST
+				time.Date(2017, time.October, 30, 1, 30, 0, 0, time.UTC), // 02:30 CET
+			},
+		},
+		{
+			name:      "overlap second occurrence is skipped",
+			givenWhen: "0 */30 2 * * *",
+			givenFrom: time.Date(2017, time.October, 29, 1, 10, 0, 0, time.UTC), // 02:10 CET
+			wantNexts: []time.Time{
+				time.Date(2017, time.October, 30, 1, 0, 0, 0, time.UTC), // 02:00 CET
+			},
+		},
+	}
+
+	for _, test := range tests {
+		sch, err := cron.Parse(test.givenWhen)
+		if err != nil {
+			t.Fatalf("%s: Didn't expect error: %v", test.name, err)
+		}
+		z := &zoneSchedule{schedule: sch, location: madrid}
+
+		got := test.givenFrom
+		for _, want := range test.wantNexts {
+			got = z.Next(got)
+			if !got.Equal(want) {
+				t.Errorf("%s: Next run should be %v; got %v", test.name, want, got)
+				break
+			}
+		}
+	}
+}
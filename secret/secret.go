@@ -0,0 +1,125 @@
+// Package secret implements the store of the job secrets, the values of the
+// secrets are encrypted at rest with AES-256-GCM
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/storage"
+)
+
+// keyLen is the length of the secrets key (AES-256)
+const keyLen = 32
+
+// Mask is the text that replaces the values of the secrets on the job results
+const Mask = "******"
+
+var (
+	// ErrDisabled is returned when the secrets are used without a key
+	ErrDisabled = errors.New("secrets are disabled, no secrets key")
+)
+
+// Store stores and retrieves the secrets, encrypting and decrypting their
+// values
+type Store struct {
+	storage storage.Client
+	aead    cipher.AEAD
+	// err is the error of the key, all the operations fail with it
+	err error
+}
+
+// NewStore creates a secrets store with a base64 encoded 32 byte key, the
+// store without a valid key fails on every operation
+func NewStore(key string, storage storage.Client) *Store {
+	s := &Store{storage: storage}
+	if key == "" {
+		s.err = ErrDisabled
+		return s
+	}
+
+	k, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(k) != keyLen {
+		s.err = fmt.Errorf("not valid secrets key, should be %d bytes base64 encoded", keyLen)
+		return s
+	}
+	b, err := aes.NewCipher(k)
+	if err != nil {
+		s.err = fmt.Errorf("not valid secrets key: %v", err)
+		return s
+	}
+	if s.aead, err = cipher.NewGCM(b); err != nil {
+		s.err = fmt.Errorf("not valid secrets key: %v", err)
+	}
+	return s
+}
+
+// Save encrypts the value of a secret and stores it on a namespace, replacing
+// the previous secret with the same name
+func (s *Store) Save(ns string, sec *job.Secret, value string) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("error encrypting secret '%s': %v", sec.Name, err)
+	}
+	// The name is authenticated so the values can't be swapped between secrets
+	sec.Value = s.aead.Seal(nonce, nonce, []byte(value), additionalData(ns, sec.Name))
+	sec.UpdatedAt = time.Now().UTC()
+
+	logrus.Debugf("Encrypted secret '%s' of namespace '%s'", sec.Name, ns)
+	return s.storage.SaveSecret(ns, sec)
+}
+
+// Value returns the decrypted value of a secret of a namespace
+func (s *Store) Value(ns, name string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+
+	sec, err := s.storage.GetSecret(ns, name)
+	if err != nil {
+		return "", fmt.Errorf("secret '%s' doesn't exist", name)
+	}
+
+	n := s.aead.NonceSize()
+	if len(sec.Value) < n {
+		return "", fmt.Errorf("error decrypting secret '%s': value too short", name)
+	}
+	v, err := s.aead.Open(nil, sec.Value[:n], sec.Value[n:], additionalData(ns, sec.Name))
+	if err != nil {
+		return "", fmt.Errorf("error decrypting secret '%s': %v", name, err)
+	}
+	return string(v), nil
+}
+
+// additionalData returns the authenticated data of the value of a secret, the
+// namespace and the name. Only the name on the default namespace, as the
+// secrets stored before the namespaces
+func additionalData(ns, name string) []byte {
+	if ns == job.DefaultNamespace {
+		return []byte(name)
+	}
+	return []byte(ns + "/" + name)
+}
+
+// MaskValues replaces the secret values of a text with the mask
+func MaskValues(text string, values []string) string {
+	for _, v := range values {
+		if v != "" {
+			text = strings.Replace(text, v, Mask, -1)
+		}
+	}
+	return text
+}
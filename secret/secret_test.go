@@ -0,0 +1,92 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/storage"
+)
+
+const testKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+func TestStoreSaveValue(t *testing.T) {
+	st := storage.NewDummy()
+	s := NewStore(testKey, st)
+
+	if err := s.Save(job.DefaultNamespace, &job.Secret{Name: "api-token"}, "s3cr3t"); err != nil {
+		t.Fatalf("Secret should be saved: %v", err)
+	}
+
+	// Encrypted at rest
+	sec, _ := st.GetSecret(job.DefaultNamespace, "api-token")
+	if bytes.Contains(sec.Value, []byte("s3cr3t")) || sec.UpdatedAt.IsZero() {
+		t.Errorf("Secret should be stored encrypted; got: %#v", sec)
+	}
+
+	if v, err := s.Value(job.DefaultNamespace, "api-token"); err != nil || v != "s3cr3t" {
+		t.Errorf("Secret value should be 's3cr3t'; got: '%s', %v", v, err)
+	}
+	if _, err := s.Value(job.DefaultNamespace, "missing"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+
+	// Values can't be moved to other secrets
+	st.SaveSecret(job.DefaultNamespace, &job.Secret{Name: "other", Value: sec.Value})
+	if _, err := s.Value(job.DefaultNamespace, "other"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+
+	// Values can't be moved to other namespaces
+	st.SaveNamespace(&job.Namespace{Name: "payments"})
+	st.SaveSecret("payments", &job.Secret{Name: "api-token", Value: sec.Value})
+	if _, err := s.Value("payments", "api-token"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+
+	// Other keys can't decrypt
+	other := NewStore("ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=", st)
+	if _, err := other.Value(job.DefaultNamespace, "api-token"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+}
+
+func TestStoreWithoutKey(t *testing.T) {
+	for _, key := range []string{"", "not base64", "c2hvcnQ="} {
+		s := NewStore(key, storage.NewDummy())
+		if err := s.Save(job.DefaultNamespace, &job.Secret{Name: "api-token"}, "s3cr3t"); err == nil {
+			t.Errorf("Expected error with key '%s' but didn't got", key)
+		}
+		if _, err := s.Value(job.DefaultNamespace, "api-token"); err == nil {
+			t.Errorf("Expected error with key '%s' but didn't got", key)
+		}
+	}
+}
+
+func TestMaskValues(t *testing.T) {
+	got := MaskValues("token s3cr3t and user admin, s3cr3t", []string{"s3cr3t", "", "admin"})
+	if want := "token ****** and user ******, ******"; got != want {
+		t.Errorf("Masked text should be '%s'; got '%s'", want, got)
+	}
+}
+
+func TestStoreNamespaces(t *testing.T) {
+	st := storage.NewDummy()
+	st.SaveNamespace(&job.Namespace{Name: "payments"})
+	s := NewStore(testKey, st)
+
+	if err := s.Save("payments", &job.Secret{Name: "api-token"}, "s3cr3t"); err != nil {
+		t.Fatalf("Secret should be saved: %v", err)
+	}
+	if v, err := s.Value("payments", "api-token"); err != nil || v != "s3cr3t" {
+		t.Errorf("Secret value should be 's3cr3t'; got: '%s', %v", v, err)
+	}
+
+	// The secrets are only on their namespace
+	if _, err := s.Value(job.DefaultNamespace, "api-token"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+	if err := s.Save("search", &job.Secret{Name: "api-token"}, "s3cr3t"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+}
@@ -1,24 +1,55 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/gorilla/mux"
 
+	"github.com/slok/khronos/job"
+	"github.com/slok/khronos/schedule"
+	"github.com/slok/khronos/secret"
 	"github.com/slok/khronos/service/validate"
+	"github.com/slok/khronos/storage"
 )
 
 const (
 	errorRetrievingAllJobsMsg    = "Error retrieving all jobs"
+	errorRetrievingCalendarsMsg  = "Error retrieving calendars"
+	errorRetrievingCalendarMsg   = "Error retrieving calendar"
+	errorRetrievingHostLimitsMsg = "Error retrieving host limits"
 	errorCreatingJobMsg          = "Error creating job"
+	errorCreatingNamespaceMsg    = "Error creating namespace"
+	errorCreatingSecretMsg       = "Error creating secret"
+	errorCreatingTokenMsg        = "Error creating token"
+	errorCreatingCalendarMsg     = "Error creating calendar"
+	errorDeletingCalendarMsg     = "Error deleting calendar"
+	errorDeletingHostLimitMsg    = "Error deleting host limit"
 	errorDeletingJobMsg          = "Error deleting job"
+	errorDeletingNamespaceMsg    = "Error deleting namespace"
+	errorDeletingSecretMsg       = "Error deleting secret"
+	errorDeletingTokenMsg        = "Error deleting token"
 	errorDeletingResultMsg       = "Error deleting result"
+	errorComputingRunsMsg        = "Error computing next runs"
 	errorRetrievingJobMsg        = "Error retrieving job"
 	errorRetrievingJobResultsMsg = "Error retrieving job results"
+	errorRetrievingNamespacesMsg = "Error retrieving namespaces"
+	errorRetrievingNamespaceMsg  = "Error retrieving namespace"
+	errorRetrievingSecretsMsg    = "Error retrieving secrets"
+	errorRetrievingSecretMsg     = "Error retrieving secret"
+	errorRetrievingTokensMsg     = "Error retrieving tokens"
+	errorRetrievingWorkflowMsg   = "Error retrieving workflow run"
+	errorRunningJobMsg           = "Error running job"
+	errorSettingHostLimitMsg     = "Error setting host limit"
+	errorUpdatingCalendarMsg     = "Error updating calendar"
+	errorUpdatingJobMsg          = "Error updating job"
+	errorUpdatingSecretMsg       = "Error updating secret"
 	wrongParamsMsg               = "Wrong params"
 )
 
@@ -44,18 +75,149 @@ func pageFromRequest(r *http.Request) int {
 	return page
 }
 
-// offsetsFromPage returs the start and end of a range based on the page
+// offsetsFromPage returs the start and end of a range based on the page, the
+// end is never past the length so the last page can be sliced
 func (s *KhronosService) offsetsFromPage(page, length int) (start, end int) {
 	end = s.Config.APIResourcesPerPage * page
 	start = end - s.Config.APIResourcesPerPage
 	// Check if we reached to the last elements, this means that end could not be
 	// a whole page size of resources
-	if start+1 < length && end+1 > length {
-		end = start + (length % s.Config.APIResourcesPerPage)
+	if start < length && end > length {
+		end = length
 	}
 	return
 }
 
+// namespaceFromRequest returns the namespace of the request route, the routes
+// without namespace are on the default one
+func namespaceFromRequest(r *http.Request) string {
+	if ns := mux.Vars(r)["ns"]; ns != "" {
+		return ns
+	}
+	return job.DefaultNamespace
+}
+
+// waitFromRequest returns if the request wants to wait for the execution
+// extracting from the requests querystring param
+func waitFromRequest(r *http.Request) bool {
+	w := r.URL.Query().Get("wait")
+	if w == "" {
+		return false
+	}
+	wait, err := strconv.ParseBool(w)
+	if err != nil {
+		logrus.Warningf("error getting wait querystring param: %v", err)
+		return false
+	}
+	return wait
+}
+
+// countFromRequest returns the number of schedule activation times extracting
+// from the requests querystring param
+func countFromRequest(r *http.Request) int {
+	c := r.URL.Query().Get("count")
+	if c == "" {
+		return validate.DefaultScheduleRuns
+	}
+	count, err := strconv.Atoi(c)
+	if err != nil {
+		logrus.Warningf("error getting count querystring param: %v", err)
+		return validate.DefaultScheduleRuns
+	}
+	// If wrong param then the limits
+	if count <= 0 {
+		count = 1
+	}
+	if count > validate.MaxScheduleRuns {
+		count = validate.MaxScheduleRuns
+	}
+	return count
+}
+
+// withRuns returns a copy of the job with the computed schedule executions of
+// the registered job
+func (s *KhronosService) withRuns(j *job.Job) *job.Job {
+	jr := *j
+	jr.PrevRun, jr.NextRun = nil, nil
+	prev, next, err := s.Cron.CronJobRuns(j.ID)
+	if err != nil {
+		logrus.Debugf("error getting job runs: %v", err)
+		return &jr
+	}
+	if !prev.IsZero() {
+		jr.PrevRun = &prev
+	}
+	if !next.IsZero() {
+		jr.NextRun = &next
+	}
+	return &jr
+}
+
+// validationErrors returns the errors of a not valid validator ready to be returned
+func validationErrors(errs []error) map[string][]string {
+	result := map[string][]string{
+		"errors": []string{},
+	}
+
+	for _, e := range errs {
+		result["errors"] = append(result["errors"], fmt.Sprintf("%v", e))
+	}
+	return result
+}
+
+// referenceErrors returns the errors of the job references to the stored
+// objects (upstream jobs, calendars and secrets)
+func (s *KhronosService) referenceErrors(j *job.Job) ([]error, error) {
+	errs := []error{}
+	if len(j.DependsOn) > 0 {
+		// The upstream jobs are on the same namespace
+		jobs, err := s.Storage.GetJobs(j.NamespaceOrDefault(), 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, validate.ValidDependencies(j, jobs)...)
+	}
+	if len(j.Calendars) > 0 {
+		cals, err := s.Storage.GetCalendars()
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, validate.ValidCalendars(j, cals)...)
+	}
+	if len(j.SecretNames()) > 0 {
+		secs, err := s.Storage.GetSecrets(j.NamespaceOrDefault())
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, validate.ValidSecrets(j, secs)...)
+	}
+	return errs, nil
+}
+
+// secrets returns the store of the secrets
+func (s *KhronosService) secrets() *secret.Store {
+	return secret.NewStore(s.Config.SecretsKey, s.Storage)
+}
+
+// publicSecret returns a copy of a secret without its value
+func publicSecret(sec *job.Secret) *job.Secret {
+	return &job.Secret{Name: sec.Name, Description: sec.Description, UpdatedAt: sec.UpdatedAt}
+}
+
+// createdToken is a created token with its secret, the only time the secret
+// is returned
+type createdToken struct {
+	*job.Token
+	Secret string
+}
+
+// publicToken returns a copy of a token without the hash of its secret
+func publicToken(t *job.Token) *job.Token {
+	pt := *t
+	pt.Salt, pt.Hash = nil, nil
+	return &pt
+}
+
 //#################### endpoints #######################
 
 //Ping informs service is alive
@@ -70,7 +232,23 @@ func (s *KhronosService) GetJobs(r *http.Request) (int, interface{}, error) {
 
 	// Page will set the offset
 	page := pageFromRequest(r)
-	length := s.Storage.JobsLength()
+
+	// The jobs selected by their labels are paginated by the matching ones, the
+	// tokens limited by labels only select their jobs
+	sel := tokenSelector(r)
+	if q := r.URL.Query().Get("selector"); q != "" {
+		qSel, err := job.ParseSelector(q)
+		if err != nil {
+			return http.StatusBadRequest, validationErrors([]error{errors.New("Selector is not a valid label selector")}), nil
+		}
+		sel = append(sel, qSel...)
+	}
+	if len(sel) > 0 {
+		return s.selectedJobs(namespaceFromRequest(r), sel, page)
+	}
+
+	ns := namespaceFromRequest(r)
+	length := s.Storage.JobsLength(ns)
 	start, end := s.offsetsFromPage(page, length)
 
 	// First check if need to query
@@ -78,13 +256,44 @@ func (s *KhronosService) GetJobs(r *http.Request) (int, interface{}, error) {
 		return http.StatusOK, []struct{}{}, nil
 	}
 
-	jobs, err := s.Storage.GetJobs(start, end)
+	jobs, err := s.Storage.GetJobs(ns, start, end)
 
 	if err != nil {
 		logrus.Errorf("Error retrieving all jobs: %v", err)
 		return http.StatusInternalServerError, errorRetrievingAllJobsMsg, nil
 	}
 
+	for i, j := range jobs {
+		jobs[i] = s.withRuns(j)
+	}
+
+	return http.StatusOK, jobs, nil
+}
+
+// selectedJobs returns a page of the jobs of a namespace matching the label
+// selector, the jobs are retrieved one by one after filtering the IDs
+func (s *KhronosService) selectedJobs(ns string, sel job.Selector, page int) (int, interface{}, error) {
+	ids, err := s.Storage.GetJobIDs(ns, sel)
+	if err != nil {
+		logrus.Errorf("Error retrieving selected jobs: %v", err)
+		return http.StatusInternalServerError, errorRetrievingAllJobsMsg, nil
+	}
+
+	start, end := s.offsetsFromPage(page, len(ids))
+	if start >= len(ids) {
+		return http.StatusOK, []struct{}{}, nil
+	}
+
+	jobs := []*job.Job{}
+	for _, id := range ids[start:end] {
+		j, err := s.Storage.GetJob(ns, id)
+		if err != nil {
+			logrus.Errorf("Error retrieving selected job '%d': %v", id, err)
+			return http.StatusInternalServerError, errorRetrievingAllJobsMsg, nil
+		}
+		jobs = append(jobs, s.withRuns(j))
+	}
+
 	return http.StatusOK, jobs, nil
 }
 
@@ -103,16 +312,7 @@ func (s *KhronosService) CreateNewJob(r *http.Request) (int, interface{}, error)
 
 	// Validate received json
 	if err = v.Validate(); err != nil {
-		result := map[string][]string{
-			"errors": []string{},
-		}
-
-		for _, e := range v.Errors {
-			result["errors"] = append(result["errors"], fmt.Sprintf("%v", e))
-		}
-
-		return http.StatusBadRequest, result, nil
-
+		return http.StatusBadRequest, validationErrors(v.Errors), nil
 	}
 
 	// Store the received json
@@ -122,6 +322,25 @@ func (s *KhronosService) CreateNewJob(r *http.Request) (int, interface{}, error)
 		return http.StatusInternalServerError, errorCreatingJobMsg, nil
 
 	}
+
+	// The job belongs to the namespace of the route
+	j.Namespace = namespaceFromRequest(r)
+
+	// The tokens limited by labels only create their jobs
+	if !tokenSelector(r).Matches(j.Labels) {
+		return http.StatusForbidden, forbiddenJobLabelsMsg, nil
+	}
+
+	// The upstream jobs and calendars need to be present
+	errs, err := s.referenceErrors(j)
+	if err != nil {
+		logrus.Errorf("Error checking job references: %v", err)
+		return http.StatusInternalServerError, errorCreatingJobMsg, nil
+	}
+	if len(errs) > 0 {
+		return http.StatusBadRequest, validationErrors(errs), nil
+	}
+
 	err = s.Storage.SaveJob(j)
 	if err != nil {
 		logrus.Errorf("Error storing job: %v", err)
@@ -139,7 +358,7 @@ func (s *KhronosService) CreateNewJob(r *http.Request) (int, interface{}, error)
 func (s *KhronosService) GetJob(r *http.Request) (int, interface{}, error) {
 	// Get resul ID
 	jid, _ := mux.Vars(r)["id"]
-	logrus.Debug("Calling GetJob with id: %s", jid)
+	logrus.Debugf("Calling GetJob with id: %s", jid)
 
 	jobID, err := strconv.Atoi(jid)
 	if err != nil {
@@ -147,21 +366,80 @@ func (s *KhronosService) GetJob(r *http.Request) (int, interface{}, error) {
 		return http.StatusInternalServerError, wrongParamsMsg, nil
 	}
 
-	j, err := s.Storage.GetJob(jobID)
+	j, err := s.Storage.GetJob(namespaceFromRequest(r), jobID)
 
 	if err != nil {
 		logrus.Errorf("Error retrieving job: %v", err)
 		return http.StatusInternalServerError, errorRetrievingJobMsg, nil
 	}
 
-	return http.StatusOK, j, nil
+	return http.StatusOK, s.withRuns(j), nil
+}
+
+// GetJobNextRuns returns the next activation times of a job schedule
+func (s *KhronosService) GetJobNextRuns(r *http.Request) (int, interface{}, error) {
+	// Get job ID
+	jid, _ := mux.Vars(r)["id"]
+	logrus.Debugf("Calling GetJobNextRuns with id: %s", jid)
+
+	jobID, err := strconv.Atoi(jid)
+	if err != nil {
+		logrus.Errorf("error getting job ID: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	j, err := s.Storage.GetJob(namespaceFromRequest(r), jobID)
+	if err != nil {
+		logrus.Errorf("Error retrieving job: %v", err)
+		return http.StatusInternalServerError, errorRetrievingJobMsg, nil
+	}
+
+	runs, err := schedule.NextRuns(j, time.Now(), countFromRequest(r))
+	if err != nil {
+		logrus.Errorf("Error computing job next runs: %v", err)
+		return http.StatusInternalServerError, errorComputingRunsMsg, nil
+	}
+
+	return http.StatusOK, runs, nil
 }
 
-// DeleteJob Deletes a job and its results
+// PreviewSchedule returns the next activation times of a schedule without a job
+func (s *KhronosService) PreviewSchedule(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling PreviewSchedule endpoint")
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	v, err := validate.NewScheduleValidatorFromJSON(string(b))
+	if err != nil {
+		logrus.Errorf("Error unmarshalling json: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	if err = v.Validate(); err != nil {
+		return http.StatusBadRequest, validationErrors(v.Errors), nil
+	}
+
+	j, count, err := v.Instance()
+	if err != nil {
+		logrus.Errorf("Error creating valid schedule: %v", err)
+		return http.StatusInternalServerError, errorComputingRunsMsg, nil
+	}
+
+	runs, err := schedule.NextRuns(j, time.Now(), count)
+	if err != nil {
+		logrus.Errorf("Error computing schedule next runs: %v", err)
+		return http.StatusInternalServerError, errorComputingRunsMsg, nil
+	}
+
+	return http.StatusOK, runs, nil
+}
+
+// DeleteJob Deletes a job and its results, the upstream jobs of other jobs
+// can't be deleted
 func (s *KhronosService) DeleteJob(r *http.Request) (int, interface{}, error) {
 	// Get resul ID
 	jid, _ := mux.Vars(r)["id"]
-	logrus.Debug("Calling DeleteJob with id: %s", jid)
+	logrus.Debugf("Calling DeleteJob with id: %s", jid)
 
 	jobID, err := strconv.Atoi(jid)
 	if err != nil {
@@ -169,21 +447,238 @@ func (s *KhronosService) DeleteJob(r *http.Request) (int, interface{}, error) {
 		return http.StatusInternalServerError, wrongParamsMsg, nil
 	}
 
-	j, err := s.Storage.GetJob(jobID)
+	ns := namespaceFromRequest(r)
+	j, err := s.Storage.GetJob(ns, jobID)
 	// No job, we are ok
 	if err != nil {
 		logrus.Errorf("Error retrieving job: %v", err)
 		return http.StatusNoContent, nil, nil
 	}
 
+	// The upstream jobs can't be deleted, their downstream jobs are on the
+	// same namespace
+	jobs, err := s.Storage.GetJobs(ns, 0, 0)
+	if err != nil {
+		logrus.Errorf("Error retrieving jobs: %v", err)
+		return http.StatusInternalServerError, errorDeletingJobMsg, nil
+	}
+	errs := []error{}
+	for _, dj := range jobs {
+		for _, d := range dj.DependsOn {
+			if d.JobID == j.ID {
+				errs = append(errs, fmt.Errorf("Job is an upstream job of job %d", dj.ID))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return http.StatusBadRequest, validationErrors(errs), nil
+	}
+
 	if err := s.Storage.DeleteJob(j); err != nil {
 		logrus.Errorf("error deleting job ID: %v", err)
 		return http.StatusInternalServerError, errorDeletingJobMsg, nil
 	}
 
+	// Stop scheduling the deleted job
+	if err := s.Cron.UnregisterCronJob(j.ID); err != nil {
+		logrus.Warningf("Error unregistering job from cron: %v", err)
+	}
+
 	return http.StatusNoContent, nil, nil
 }
 
+// UpdateJob replaces a job with the received definition and reschedules it
+func (s *KhronosService) UpdateJob(r *http.Request) (int, interface{}, error) {
+	return s.updateJob(r, false)
+}
+
+// PatchJob updates the received fields of a job and reschedules it
+func (s *KhronosService) PatchJob(r *http.Request) (int, interface{}, error) {
+	return s.updateJob(r, true)
+}
+
+// updateJob updates a job with the received json, a partial update only changes
+// the received fields, the rest will be replaced
+func (s *KhronosService) updateJob(r *http.Request, partial bool) (int, interface{}, error) {
+	// Get job ID
+	jid, _ := mux.Vars(r)["id"]
+	logrus.Debugf("Calling update job (partial: %t) with id: %s", partial, jid)
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	jobID, err := strconv.Atoi(jid)
+	if err != nil {
+		logrus.Errorf("error getting job ID: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	oldJ, err := s.Storage.GetJob(namespaceFromRequest(r), jobID)
+	if err != nil {
+		logrus.Errorf("Error retrieving job: %v", err)
+		return http.StatusInternalServerError, errorRetrievingJobMsg, nil
+	}
+
+	// Partial updates start from the present job
+	var v *validate.JobValidator
+	if partial {
+		v = validate.NewJobValidatorFromJob(oldJ)
+		err = v.MergeJSON(string(b))
+	} else {
+		v, err = validate.NewJobValidatorFromJSON(string(b))
+	}
+	if err != nil {
+		logrus.Errorf("Error unmarshalling json: %v", err)
+		return http.StatusInternalServerError, errorUpdatingJobMsg, nil
+	}
+
+	if err = v.Validate(); err != nil {
+		return http.StatusBadRequest, validationErrors(v.Errors), nil
+	}
+
+	j, err := v.Instance()
+	if err != nil {
+		logrus.Errorf("Error Creating valid job instance: %v", err)
+		return http.StatusInternalServerError, errorUpdatingJobMsg, nil
+	}
+
+	// Store with the same ID to update it, one-shot jobs are run again only if
+	// the time changes
+	j.ID = oldJ.ID
+	j.Namespace = oldJ.Namespace
+	j.LastScheduledRun = oldJ.LastScheduledRun
+	if j.OneShot() && oldJ.OneShot() && j.At.Equal(*oldJ.At) {
+		j.Completed = oldJ.Completed
+	}
+
+	// The tokens limited by labels can't move the job out of their jobs
+	if !tokenSelector(r).Matches(j.Labels) {
+		return http.StatusForbidden, forbiddenJobLabelsMsg, nil
+	}
+
+	// The upstream jobs and calendars need to be present, without dependency cycles
+	errs, err := s.referenceErrors(j)
+	if err != nil {
+		logrus.Errorf("Error checking job references: %v", err)
+		return http.StatusInternalServerError, errorUpdatingJobMsg, nil
+	}
+	if len(errs) > 0 {
+		return http.StatusBadRequest, validationErrors(errs), nil
+	}
+
+	if err = s.Storage.SaveJob(j); err != nil {
+		logrus.Errorf("Error storing job: %v", err)
+		return http.StatusInternalServerError, errorUpdatingJobMsg, nil
+	}
+
+	// Registering again replaces the previous registration
+	s.Cron.RegisterCronJob(j)
+
+	return http.StatusOK, j, nil
+}
+
+// RunJob runs a job on demand, by default returns the ID of the result without
+// waiting for the execution to finish
+func (s *KhronosService) RunJob(r *http.Request) (int, interface{}, error) {
+	// Get job ID
+	jid, _ := mux.Vars(r)["id"]
+	logrus.Debugf("Calling run job with id: %s", jid)
+
+	jobID, err := strconv.Atoi(jid)
+	if err != nil {
+		logrus.Errorf("error getting job ID: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	j, err := s.Storage.GetJob(namespaceFromRequest(r), jobID)
+	if err != nil {
+		logrus.Errorf("Error retrieving job: %v", err)
+		return http.StatusInternalServerError, errorRetrievingJobMsg, nil
+	}
+
+	resultID, done, err := s.Cron.RunCronJob(j)
+	if err != nil {
+		logrus.Errorf("Error running job: %v", err)
+		return http.StatusInternalServerError, errorRunningJobMsg, nil
+	}
+
+	if !waitFromRequest(r) {
+		return http.StatusAccepted, map[string]int{"id": resultID}, nil
+	}
+
+	// Don't wait more than the execution could last or the client is there,
+	// the execution goes on
+	t := time.NewTimer(s.runWaitTimeout(j))
+	defer t.Stop()
+	select {
+	case res := <-done:
+		return http.StatusOK, res, nil
+	case <-t.C:
+		logrus.Warningf("Stopped waiting for the result '%d' of job '%d'", resultID, j.ID)
+	case <-r.Context().Done():
+	}
+	return http.StatusAccepted, map[string]int{"id": resultID}, nil
+}
+
+// runWaitTimeout returns the maximum time a request waits for a run on demand
+// of a job, the timeout of every one of the attempts
+func (s *KhronosService) runWaitTimeout(j *job.Job) time.Duration {
+	to := time.Duration(s.Config.JobDefaultTimeoutSeconds) * time.Second
+	if j.Timeout > 0 {
+		to = j.Timeout
+	}
+	if j.Retry != nil && j.Retry.MaxAttempts > 1 {
+		to *= time.Duration(j.Retry.MaxAttempts)
+	}
+	return to
+}
+
+// PauseJob deactivates a job, it will not be executed until resumed
+func (s *KhronosService) PauseJob(r *http.Request) (int, interface{}, error) {
+	return s.setJobActive(r, false)
+}
+
+// ResumeJob activates a paused job
+func (s *KhronosService) ResumeJob(r *http.Request) (int, interface{}, error) {
+	return s.setJobActive(r, true)
+}
+
+// setJobActive sets the active state of a job on the storage and the cron
+func (s *KhronosService) setJobActive(r *http.Request, active bool) (int, interface{}, error) {
+	// Get job ID
+	jid, _ := mux.Vars(r)["id"]
+	logrus.Debugf("Calling set job active to %t with id: %s", active, jid)
+
+	jobID, err := strconv.Atoi(jid)
+	if err != nil {
+		logrus.Errorf("error getting job ID: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	j, err := s.Storage.GetJob(namespaceFromRequest(r), jobID)
+	if err != nil {
+		logrus.Errorf("Error retrieving job: %v", err)
+		return http.StatusInternalServerError, errorRetrievingJobMsg, nil
+	}
+
+	j.Active = active
+	if err := s.Storage.SaveJob(j); err != nil {
+		logrus.Errorf("Error storing job: %v", err)
+		return http.StatusInternalServerError, errorUpdatingJobMsg, nil
+	}
+
+	// Apply on the running cron
+	if active {
+		err = s.Cron.ResumeCronJob(j.ID)
+	} else {
+		err = s.Cron.PauseCronJob(j.ID)
+	}
+	if err != nil {
+		logrus.Warningf("Error setting job active state on cron: %v", err)
+	}
+
+	return http.StatusOK, j, nil
+}
+
 // GetResults returns the jobs from an specific job
 func (s *KhronosService) GetResults(r *http.Request) (int, interface{}, error) {
 	// Get job ID
@@ -198,7 +693,7 @@ func (s *KhronosService) GetResults(r *http.Request) (int, interface{}, error) {
 		return http.StatusInternalServerError, wrongParamsMsg, nil
 	}
 
-	j, err := s.Storage.GetJob(jobID)
+	j, err := s.Storage.GetJob(namespaceFromRequest(r), jobID)
 	if err != nil {
 		return http.StatusNoContent, nil, nil
 	}
@@ -241,7 +736,7 @@ func (s *KhronosService) GetResult(r *http.Request) (int, interface{}, error) {
 	}
 	logrus.Debugf("Calling GetResult with id: %d from job '%d'", resultID, jobID)
 
-	j, err := s.Storage.GetJob(jobID)
+	j, err := s.Storage.GetJob(namespaceFromRequest(r), jobID)
 	if err != nil {
 		logrus.Errorf("Error retrieving Job: %v", err)
 		return http.StatusInternalServerError, errorRetrievingJobMsg, nil
@@ -273,7 +768,7 @@ func (s *KhronosService) DeleteResult(r *http.Request) (int, interface{}, error)
 	}
 	logrus.Debugf("Calling DeleteResult with id: %d from job '%d'", resultID, jobID)
 
-	j, err := s.Storage.GetJob(jobID)
+	j, err := s.Storage.GetJob(namespaceFromRequest(r), jobID)
 	if err != nil {
 		logrus.Errorf("Error deleting Job: %v", err)
 		return http.StatusInternalServerError, errorDeletingResultMsg, nil
@@ -292,3 +787,619 @@ func (s *KhronosService) DeleteResult(r *http.Request) (int, interface{}, error)
 
 	return http.StatusNoContent, result, nil
 }
+
+// GetPoolState returns the state of the execution pool of the jobs
+func (s *KhronosService) GetPoolState(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling GetPoolState endpoint")
+	return http.StatusOK, s.Cron.PoolState(), nil
+}
+
+// GetWorkflowRun returns a workflow run of the namespace by id, the tokens
+// limited by labels need to match all the jobs of the workflow run
+func (s *KhronosService) GetWorkflowRun(r *http.Request) (int, interface{}, error) {
+	wid, _ := mux.Vars(r)["id"]
+	logrus.Debugf("Calling GetWorkflowRun with id: %s", wid)
+
+	workflowID, err := strconv.Atoi(wid)
+	if err != nil {
+		logrus.Errorf("error getting workflow run ID: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	w, err := s.Storage.GetWorkflowRun(workflowID)
+	if err == nil && w.NamespaceOrDefault() != namespaceFromRequest(r) {
+		err = fmt.Errorf("workflow run '%d' is not of the namespace", workflowID)
+	}
+	if err != nil {
+		logrus.Errorf("Error retrieving workflow run: %v", err)
+		return http.StatusInternalServerError, errorRetrievingWorkflowMsg, nil
+	}
+
+	// The tokens limited by labels only access the runs of their jobs
+	if sel := tokenSelector(r); len(sel) > 0 {
+		for _, st := range w.Steps {
+			if j, err := s.Storage.GetJob(w.NamespaceOrDefault(), st.JobID); err != nil || !sel.Matches(j.Labels) {
+				return http.StatusForbidden, forbiddenWorkflowMsg, nil
+			}
+		}
+	}
+
+	return http.StatusOK, w, nil
+}
+
+// GetCalendars returns all the calendars
+func (s *KhronosService) GetCalendars(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling GetCalendars endpoint")
+
+	cals, err := s.Storage.GetCalendars()
+	if err != nil {
+		logrus.Errorf("Error retrieving calendars: %v", err)
+		return http.StatusInternalServerError, errorRetrievingCalendarsMsg, nil
+	}
+
+	return http.StatusOK, cals, nil
+}
+
+// CreateCalendar creates a new calendar
+func (s *KhronosService) CreateCalendar(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling CreateCalendar endpoint")
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	v, err := validate.NewCalendarValidatorFromJSON(string(b))
+	if err != nil {
+		logrus.Errorf("Error unmarshalling json: %v", err)
+		return http.StatusInternalServerError, errorCreatingCalendarMsg, nil
+	}
+
+	return s.saveCalendar(v, 0)
+}
+
+// ImportCalendar creates a new calendar from an iCalendar (.ics) file, every
+// event of the file is a window of the calendar. The name and time zone
+// query params override the ones of the file
+func (s *KhronosService) ImportCalendar(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling ImportCalendar endpoint")
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	v, err := validate.NewCalendarValidatorFromICS(string(b))
+	if err != nil {
+		return http.StatusBadRequest, validationErrors([]error{err}), nil
+	}
+	if name := r.URL.Query().Get("name"); name != "" {
+		v.Name = name
+	}
+	if tz := r.URL.Query().Get("timeZone"); tz != "" {
+		v.TimeZone = tz
+	}
+
+	return s.saveCalendar(v, 0)
+}
+
+// GetCalendar returns a single calendar by id
+func (s *KhronosService) GetCalendar(r *http.Request) (int, interface{}, error) {
+	cid, _ := mux.Vars(r)["id"]
+	logrus.Debugf("Calling GetCalendar with id: %s", cid)
+
+	calendarID, err := strconv.Atoi(cid)
+	if err != nil {
+		logrus.Errorf("error getting calendar ID: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	cal, err := s.Storage.GetCalendar(calendarID)
+	if err != nil {
+		logrus.Errorf("Error retrieving calendar: %v", err)
+		return http.StatusInternalServerError, errorRetrievingCalendarMsg, nil
+	}
+
+	return http.StatusOK, cal, nil
+}
+
+// UpdateCalendar replaces a calendar with the received definition, the jobs
+// use the new windows from their next activation
+func (s *KhronosService) UpdateCalendar(r *http.Request) (int, interface{}, error) {
+	cid, _ := mux.Vars(r)["id"]
+	logrus.Debugf("Calling UpdateCalendar with id: %s", cid)
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	calendarID, err := strconv.Atoi(cid)
+	if err != nil {
+		logrus.Errorf("error getting calendar ID: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	if _, err := s.Storage.GetCalendar(calendarID); err != nil {
+		logrus.Errorf("Error retrieving calendar: %v", err)
+		return http.StatusInternalServerError, errorRetrievingCalendarMsg, nil
+	}
+
+	v, err := validate.NewCalendarValidatorFromJSON(string(b))
+	if err != nil {
+		logrus.Errorf("Error unmarshalling json: %v", err)
+		return http.StatusInternalServerError, errorUpdatingCalendarMsg, nil
+	}
+
+	return s.saveCalendar(v, calendarID)
+}
+
+// saveCalendar validates and stores a calendar, 0 id means a new calendar
+func (s *KhronosService) saveCalendar(v *validate.CalendarValidator, id int) (int, interface{}, error) {
+	errMsg := errorCreatingCalendarMsg
+	if id != 0 {
+		errMsg = errorUpdatingCalendarMsg
+	}
+
+	if err := v.Validate(); err != nil {
+		return http.StatusBadRequest, validationErrors(v.Errors), nil
+	}
+
+	cal, err := v.Instance()
+	if err != nil {
+		logrus.Errorf("Error Creating valid calendar instance: %v", err)
+		return http.StatusInternalServerError, errMsg, nil
+	}
+
+	cal.ID = id
+	if err := s.Storage.SaveCalendar(cal); err != nil {
+		logrus.Errorf("Error storing calendar: %v", err)
+		return http.StatusInternalServerError, errMsg, nil
+	}
+
+	if id == 0 {
+		return http.StatusCreated, cal, nil
+	}
+	return http.StatusOK, cal, nil
+}
+
+// DeleteCalendar deletes a calendar, the calendars used by jobs can't be deleted
+func (s *KhronosService) DeleteCalendar(r *http.Request) (int, interface{}, error) {
+	cid, _ := mux.Vars(r)["id"]
+	logrus.Debugf("Calling DeleteCalendar with id: %s", cid)
+
+	calendarID, err := strconv.Atoi(cid)
+	if err != nil {
+		logrus.Errorf("error getting calendar ID: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	cal, err := s.Storage.GetCalendar(calendarID)
+	// No calendar, we are ok
+	if err != nil {
+		logrus.Errorf("Error retrieving calendar: %v", err)
+		return http.StatusNoContent, nil, nil
+	}
+
+	jobs, err := storage.AllJobs(s.Storage)
+	if err != nil {
+		logrus.Errorf("Error retrieving all jobs: %v", err)
+		return http.StatusInternalServerError, errorDeletingCalendarMsg, nil
+	}
+	errs := []error{}
+	for _, j := range jobs {
+		for _, cr := range j.Calendars {
+			if cr.CalendarID == cal.ID {
+				errs = append(errs, fmt.Errorf("Calendar is used by job %d", j.ID))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return http.StatusBadRequest, validationErrors(errs), nil
+	}
+
+	if err := s.Storage.DeleteCalendar(cal); err != nil {
+		logrus.Errorf("error deleting calendar: %v", err)
+		return http.StatusInternalServerError, errorDeletingCalendarMsg, nil
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// GetHostLimits returns the hosts with their own limits, the rest of the hosts
+// use the default limits of the configuration
+func (s *KhronosService) GetHostLimits(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling GetHostLimits endpoint")
+
+	ls, err := s.Storage.GetHostLimits()
+	if err != nil {
+		logrus.Errorf("Error retrieving host limits: %v", err)
+		return http.StatusInternalServerError, errorRetrievingHostLimitsMsg, nil
+	}
+
+	return http.StatusOK, ls, nil
+}
+
+// GetHostLimit returns the limits applied to the calls to a host, its own
+// limits or the default ones
+func (s *KhronosService) GetHostLimit(r *http.Request) (int, interface{}, error) {
+	host, _ := mux.Vars(r)["host"]
+	logrus.Debugf("Calling GetHostLimit with host: %s", host)
+
+	if err := validate.ValidHost(host); err != nil {
+		logrus.Errorf("error getting host: %v", err)
+		return http.StatusInternalServerError, wrongParamsMsg, nil
+	}
+
+	return http.StatusOK, s.Cron.HostLimit(host), nil
+}
+
+// SetHostLimit sets the limits of the calls to a host, replacing the default
+// ones of the configuration
+func (s *KhronosService) SetHostLimit(r *http.Request) (int, interface{}, error) {
+	host, _ := mux.Vars(r)["host"]
+	logrus.Debugf("Calling SetHostLimit with host: %s", host)
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	v, err := validate.NewHostLimitValidatorFromJSON(string(b))
+	if err != nil {
+		logrus.Errorf("Error unmarshalling json: %v", err)
+		return http.StatusInternalServerError, errorSettingHostLimitMsg, nil
+	}
+	// The host of the path is the one limited
+	v.Host = host
+
+	if err := v.Validate(); err != nil {
+		return http.StatusBadRequest, validationErrors(v.Errors), nil
+	}
+
+	l, err := v.Instance()
+	if err != nil {
+		logrus.Errorf("Error Creating valid host limit instance: %v", err)
+		return http.StatusInternalServerError, errorSettingHostLimitMsg, nil
+	}
+
+	if err := s.Storage.SaveHostLimit(l); err != nil {
+		logrus.Errorf("Error storing host limit: %v", err)
+		return http.StatusInternalServerError, errorSettingHostLimitMsg, nil
+	}
+	s.Cron.SetHostLimit(l)
+
+	return http.StatusOK, l, nil
+}
+
+// DeleteHostLimit deletes the limits of a host, the default ones of the
+// configuration will be used
+func (s *KhronosService) DeleteHostLimit(r *http.Request) (int, interface{}, error) {
+	host, _ := mux.Vars(r)["host"]
+	logrus.Debugf("Calling DeleteHostLimit with host: %s", host)
+	host = strings.ToLower(host)
+
+	if err := s.Storage.DeleteHostLimit(host); err != nil {
+		logrus.Errorf("error deleting host limit: %v", err)
+		return http.StatusInternalServerError, errorDeletingHostLimitMsg, nil
+	}
+	s.Cron.RemoveHostLimit(host)
+
+	return http.StatusNoContent, nil, nil
+}
+
+// GetSecrets returns all the secrets of the namespace without their values
+func (s *KhronosService) GetSecrets(r *http.Request) (int, interface{}, error) {
+	ns := namespaceFromRequest(r)
+	logrus.Debugf("Calling GetSecrets endpoint on namespace: %s", ns)
+
+	secs, err := s.Storage.GetSecrets(ns)
+	if err != nil {
+		logrus.Errorf("Error retrieving secrets: %v", err)
+		return http.StatusInternalServerError, errorRetrievingSecretsMsg, nil
+	}
+
+	ps := []*job.Secret{}
+	for _, sec := range secs {
+		ps = append(ps, publicSecret(sec))
+	}
+	return http.StatusOK, ps, nil
+}
+
+// CreateSecret creates a new secret, the value is stored encrypted and never
+// returned
+func (s *KhronosService) CreateSecret(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling CreateSecret endpoint")
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	v, err := validate.NewSecretValidatorFromJSON(string(b))
+	if err != nil {
+		logrus.Errorf("Error unmarshalling json: %v", err)
+		return http.StatusInternalServerError, errorCreatingSecretMsg, nil
+	}
+
+	if err := v.Validate(); err != nil {
+		return http.StatusBadRequest, validationErrors(v.Errors), nil
+	}
+	ns := namespaceFromRequest(r)
+	if _, err := s.Storage.GetSecret(ns, v.Name); err == nil {
+		return http.StatusBadRequest, validationErrors([]error{fmt.Errorf("Secret %s already exists", v.Name)}), nil
+	}
+
+	return s.saveSecret(ns, v, http.StatusCreated, errorCreatingSecretMsg)
+}
+
+// GetSecret returns a secret without its value
+func (s *KhronosService) GetSecret(r *http.Request) (int, interface{}, error) {
+	name, _ := mux.Vars(r)["name"]
+	ns := namespaceFromRequest(r)
+	logrus.Debugf("Calling GetSecret with name: %s", name)
+
+	sec, err := s.Storage.GetSecret(ns, name)
+	if err != nil {
+		logrus.Errorf("Error retrieving secret: %v", err)
+		return http.StatusInternalServerError, errorRetrievingSecretMsg, nil
+	}
+
+	return http.StatusOK, publicSecret(sec), nil
+}
+
+// UpdateSecret replaces the value and description of a secret, the jobs use
+// the new value from their next execution
+func (s *KhronosService) UpdateSecret(r *http.Request) (int, interface{}, error) {
+	name, _ := mux.Vars(r)["name"]
+	ns := namespaceFromRequest(r)
+	logrus.Debugf("Calling UpdateSecret with name: %s", name)
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	if _, err := s.Storage.GetSecret(ns, name); err != nil {
+		logrus.Errorf("Error retrieving secret: %v", err)
+		return http.StatusInternalServerError, errorRetrievingSecretMsg, nil
+	}
+
+	v, err := validate.NewSecretValidatorFromJSON(string(b))
+	if err != nil {
+		logrus.Errorf("Error unmarshalling json: %v", err)
+		return http.StatusInternalServerError, errorUpdatingSecretMsg, nil
+	}
+	// The secret of the path is the one updated
+	v.Name = name
+
+	if err := v.Validate(); err != nil {
+		return http.StatusBadRequest, validationErrors(v.Errors), nil
+	}
+
+	return s.saveSecret(ns, v, http.StatusOK, errorUpdatingSecretMsg)
+}
+
+// saveSecret encrypts and stores a valid secret on a namespace
+func (s *KhronosService) saveSecret(ns string, v *validate.SecretValidator, code int, errMsg string) (int, interface{}, error) {
+	sec, err := v.Instance()
+	if err != nil {
+		logrus.Errorf("Error Creating valid secret instance: %v", err)
+		return http.StatusInternalServerError, errMsg, nil
+	}
+
+	if err := s.secrets().Save(ns, sec, v.Value); err != nil {
+		logrus.Errorf("Error storing secret: %v", err)
+		return http.StatusInternalServerError, errMsg, nil
+	}
+
+	return code, publicSecret(sec), nil
+}
+
+// DeleteSecret deletes a secret, the secrets used by the jobs of the namespace
+// can't be deleted
+func (s *KhronosService) DeleteSecret(r *http.Request) (int, interface{}, error) {
+	name, _ := mux.Vars(r)["name"]
+	ns := namespaceFromRequest(r)
+	logrus.Debugf("Calling DeleteSecret with name: %s", name)
+
+	sec, err := s.Storage.GetSecret(ns, name)
+	// No secret, we are ok
+	if err != nil {
+		logrus.Errorf("Error retrieving secret: %v", err)
+		return http.StatusNoContent, nil, nil
+	}
+
+	jobs, err := s.Storage.GetJobs(ns, 0, 0)
+	if err != nil {
+		logrus.Errorf("Error retrieving jobs: %v", err)
+		return http.StatusInternalServerError, errorDeletingSecretMsg, nil
+	}
+	errs := []error{}
+	for _, j := range jobs {
+		for _, n := range j.SecretNames() {
+			if n == sec.Name {
+				errs = append(errs, fmt.Errorf("Secret is used by job %d", j.ID))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return http.StatusBadRequest, validationErrors(errs), nil
+	}
+
+	if err := s.Storage.DeleteSecret(ns, sec); err != nil {
+		logrus.Errorf("error deleting secret: %v", err)
+		return http.StatusInternalServerError, errorDeletingSecretMsg, nil
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// GetNamespaces returns all the namespaces
+func (s *KhronosService) GetNamespaces(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling GetNamespaces endpoint")
+
+	nss, err := s.Storage.GetNamespaces()
+	if err != nil {
+		logrus.Errorf("Error retrieving namespaces: %v", err)
+		return http.StatusInternalServerError, errorRetrievingNamespacesMsg, nil
+	}
+	return http.StatusOK, nss, nil
+}
+
+// CreateNamespace creates a new namespace for the jobs and tokens of a team
+func (s *KhronosService) CreateNamespace(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling CreateNamespace endpoint")
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	v, err := validate.NewNamespaceValidatorFromJSON(string(b))
+	if err != nil {
+		logrus.Errorf("Error unmarshalling json: %v", err)
+		return http.StatusInternalServerError, errorCreatingNamespaceMsg, nil
+	}
+
+	if err := v.Validate(); err != nil {
+		return http.StatusBadRequest, validationErrors(v.Errors), nil
+	}
+	if _, err := s.Storage.GetNamespace(v.Name); err == nil {
+		return http.StatusBadRequest, validationErrors([]error{fmt.Errorf("Namespace %s already exists", v.Name)}), nil
+	}
+
+	ns, err := v.Instance()
+	if err != nil {
+		logrus.Errorf("Error Creating valid namespace instance: %v", err)
+		return http.StatusInternalServerError, errorCreatingNamespaceMsg, nil
+	}
+
+	if err := s.Storage.SaveNamespace(ns); err != nil {
+		logrus.Errorf("Error storing namespace: %v", err)
+		return http.StatusInternalServerError, errorCreatingNamespaceMsg, nil
+	}
+
+	return http.StatusCreated, ns, nil
+}
+
+// GetNamespace returns a single namespace by name
+func (s *KhronosService) GetNamespace(r *http.Request) (int, interface{}, error) {
+	name, _ := mux.Vars(r)["ns"]
+	logrus.Debugf("Calling GetNamespace with name: %s", name)
+
+	ns, err := s.Storage.GetNamespace(name)
+	if err != nil {
+		logrus.Errorf("Error retrieving namespace: %v", err)
+		return http.StatusInternalServerError, errorRetrievingNamespaceMsg, nil
+	}
+
+	return http.StatusOK, ns, nil
+}
+
+// DeleteNamespace deletes a namespace with its tokens, the namespaces with
+// jobs and the default namespace can't be deleted
+func (s *KhronosService) DeleteNamespace(r *http.Request) (int, interface{}, error) {
+	name, _ := mux.Vars(r)["ns"]
+	logrus.Debugf("Calling DeleteNamespace with name: %s", name)
+
+	ns, err := s.Storage.GetNamespace(name)
+	// No namespace, we are ok
+	if err != nil {
+		logrus.Errorf("Error retrieving namespace: %v", err)
+		return http.StatusNoContent, nil, nil
+	}
+
+	if ns.Name == job.DefaultNamespace {
+		return http.StatusBadRequest, validationErrors([]error{errors.New("Default namespace can't be deleted")}), nil
+	}
+	if l := s.Storage.JobsLength(ns.Name); l > 0 {
+		return http.StatusBadRequest, validationErrors([]error{fmt.Errorf("Namespace has %d jobs", l)}), nil
+	}
+
+	if err := s.Storage.DeleteNamespace(ns); err != nil {
+		logrus.Errorf("error deleting namespace: %v", err)
+		return http.StatusInternalServerError, errorDeletingNamespaceMsg, nil
+	}
+
+	return http.StatusNoContent, nil, nil
+}
+
+// GetTokens returns all the tokens of a namespace without their secrets
+func (s *KhronosService) GetTokens(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling GetTokens endpoint")
+
+	ts, err := s.Storage.GetAuthenticationTokens(namespaceFromRequest(r))
+	if err != nil {
+		logrus.Errorf("Error retrieving tokens: %v", err)
+		return http.StatusInternalServerError, errorRetrievingTokensMsg, nil
+	}
+
+	pts := []*job.Token{}
+	for _, t := range ts {
+		pts = append(pts, publicToken(t))
+	}
+	return http.StatusOK, pts, nil
+}
+
+// CreateToken creates a new token on a namespace, the secret is only returned
+// on the creation. The tokens can't create tokens with more access than them
+func (s *KhronosService) CreateToken(r *http.Request) (int, interface{}, error) {
+	logrus.Debug("Calling CreateToken endpoint")
+	b, _ := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+
+	v, err := validate.NewTokenValidatorFromJSON(string(b))
+	if err != nil {
+		logrus.Errorf("Error unmarshalling json: %v", err)
+		return http.StatusInternalServerError, errorCreatingTokenMsg, nil
+	}
+	if err = v.Validate(); err != nil {
+		return http.StatusBadRequest, validationErrors(v.Errors), nil
+	}
+
+	ns := namespaceFromRequest(r)
+	if ns != job.DefaultNamespace && len(v.Namespaces) > 0 {
+		return http.StatusBadRequest, validationErrors([]error{errors.New("Namespaces are only valid on the tokens of the default namespace")}), nil
+	}
+	ts, err := s.Storage.GetAuthenticationTokens(ns)
+	if err != nil {
+		logrus.Errorf("Error retrieving tokens: %v", err)
+		return http.StatusInternalServerError, errorCreatingTokenMsg, nil
+	}
+	for _, t := range ts {
+		if t.Name == v.Name {
+			return http.StatusBadRequest, validationErrors([]error{fmt.Errorf("Token %s already exists", v.Name)}), nil
+		}
+	}
+
+	t, err := v.Instance()
+	if err != nil {
+		logrus.Errorf("Error Creating valid token instance: %v", err)
+		return http.StatusInternalServerError, errorCreatingTokenMsg, nil
+	}
+
+	// The tokens limited by namespaces or labels only create tokens with the
+	// same limits
+	if ct := contextToken(r); ct != nil {
+		for _, n := range t.Namespaces {
+			if !ct.InNamespaceScope(n) {
+				return http.StatusForbidden, fmt.Sprintf(forbiddenNamespaceScopeMsg, n), nil
+			}
+		}
+		if ct.Selector != "" && t.Selector != "" {
+			t.Selector = ct.Selector + "," + t.Selector
+		} else if ct.Selector != "" {
+			t.Selector = ct.Selector
+		}
+	}
+
+	secret, err := t.Generate()
+	if err != nil {
+		logrus.Errorf("Error generating token secret: %v", err)
+		return http.StatusInternalServerError, errorCreatingTokenMsg, nil
+	}
+	if err := s.Storage.SaveAuthenticationToken(ns, t); err != nil {
+		logrus.Errorf("Error storing token: %v", err)
+		return http.StatusInternalServerError, errorCreatingTokenMsg, nil
+	}
+
+	return http.StatusCreated, &createdToken{Token: publicToken(t), Secret: secret}, nil
+}
+
+// DeleteToken revokes a token of a namespace
+func (s *KhronosService) DeleteToken(r *http.Request) (int, interface{}, error) {
+	id, _ := mux.Vars(r)["tokenID"]
+	logrus.Debugf("Calling DeleteToken with id: %s", id)
+
+	// No token, we are ok
+	if !s.Storage.AuthenticationTokenExists(namespaceFromRequest(r), id) {
+		return http.StatusNoContent, nil, nil
+	}
+
+	if err := s.Storage.DeleteAuthenticationToken(namespaceFromRequest(r), id); err != nil {
+		logrus.Errorf("error deleting token: %v", err)
+		return http.StatusInternalServerError, errorDeletingTokenMsg, nil
+	}
+
+	return http.StatusNoContent, nil, nil
+}
@@ -1,19 +1,53 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
+	"time"
 
+	"github.com/NYTimes/gizmo/server"
 	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+const (
+	forbiddenTokenMsg          = "Missing or not valid token"
+	forbiddenNamespaceMsg      = "Token can't access the namespace '%s'"
+	forbiddenPermissionMsg     = "Token role '%s' doesn't have the '%s' permission"
+	forbiddenSelectorMsg       = "Token has a not valid label selector"
+	forbiddenJobMsg            = "Token can't access the job, its labels don't match the token selector"
+	forbiddenJobLabelsMsg      = "Token can't use these job labels, they don't match the token selector"
+	forbiddenWorkflowMsg       = "Token can't access the workflow run, the labels of its jobs don't match the token selector"
+	forbiddenSharedMsg         = "Token limited by a label selector can't change the resources shared by all the jobs"
+	forbiddenNamespaceScopeMsg = "Token can't give access to the namespace '%s'"
 )
 
+// lastUsedInterval is the minimum interval between the updates of the last
+// time a token was used
+const lastUsedInterval = time.Minute
+
+// tokenKey is the key of the request token on the request context
+type tokenKey struct{}
+
 var (
 	tokenRe = regexp.MustCompile(`Bearer (\w+)`)
+	// namespaceRouteRe matches the routes of a namespace and its resources
+	namespaceRouteRe = regexp.MustCompile(`/namespaces/([^/]+)(/|$)`)
+	// jobRouteRe matches the routes of a job and its results
+	jobRouteRe = regexp.MustCompile(`/jobs/(\d+)`)
 )
 
 // AuthenticationHandler Checks the application security, Authorization header
-// and let it pass if correct.
+// and let it pass if correct. The tokens only give access to the routes of
+// their namespace, the routes without namespace need a token of the default
+// namespace. The tokens of the default namespace also give access to the
+// namespaces of their scope. The requests without a valid token or with a token
+// out of its namespace scope get a 403 with the reason
 func (s *KhronosService) AuthenticationHandler(f http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Check security only if enabled
@@ -21,18 +55,12 @@ func (s *KhronosService) AuthenticationHandler(f http.Handler) http.Handler {
 			// Check header
 			auth := r.Header.Get("Authorization")
 
-			reResult := tokenRe.FindStringSubmatch(auth)
-			var access bool
-			fmt.Println(reResult)
-			// If valid then access is true
-			if len(reResult) > 0 && s.Storage.AuthenticationTokenExists(reResult[1]) {
-				access = true
-			}
-
 			// Can access?
-			if !access {
-				logrus.Debugf("Forbidden access with header '%s'", auth)
-				w.WriteHeader(http.StatusForbidden)
+			if _, code, msg := s.requestToken(r); code != http.StatusOK {
+				logrus.Debugf("Forbidden access with header '%s': %s", auth, msg)
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(code)
+				json.NewEncoder(w).Encode(msg)
 				return
 			}
 		}
@@ -40,3 +68,146 @@ func (s *KhronosService) AuthenticationHandler(f http.Handler) http.Handler {
 		f.ServeHTTP(w, r)
 	})
 }
+
+// namespaceFromPath returns the namespace of a route path, the default one if
+// the route is not of a namespace
+func namespaceFromPath(path string) string {
+	if m := namespaceRouteRe.FindStringSubmatch(path); len(m) > 0 {
+		return m[1]
+	}
+	return job.DefaultNamespace
+}
+
+// requestToken returns the token of the request Authorization header, when
+// the token isn't valid on the namespace of the route returns nil with the
+// status code and the message of the response
+func (s *KhronosService) requestToken(r *http.Request) (*job.Token, int, string) {
+	reResult := tokenRe.FindStringSubmatch(r.Header.Get("Authorization"))
+	if len(reResult) == 0 {
+		return nil, http.StatusForbidden, forbiddenTokenMsg
+	}
+
+	ns := namespaceFromPath(r.URL.Path)
+	if t := s.namespaceToken(ns, reResult[1]); t != nil {
+		return t, http.StatusOK, ""
+	}
+	if ns == job.DefaultNamespace {
+		return nil, http.StatusForbidden, forbiddenTokenMsg
+	}
+
+	// The tokens of the default namespace can access other namespaces
+	t := s.namespaceToken(job.DefaultNamespace, reResult[1])
+	if t == nil {
+		return nil, http.StatusForbidden, forbiddenTokenMsg
+	}
+	if !t.InNamespaceScope(ns) {
+		return nil, http.StatusForbidden, fmt.Sprintf(forbiddenNamespaceMsg, ns)
+	}
+	return t, http.StatusOK, ""
+}
+
+// namespaceToken returns the token of a namespace with the secret, nil if
+// there isn't one or is expired. The last time the token is used is updated
+func (s *KhronosService) namespaceToken(ns, secret string) *job.Token {
+	t, err := s.Storage.GetAuthenticationToken(ns, job.TokenID(secret))
+	if err != nil || !t.Verify(secret) {
+		t = s.legacyToken(ns, secret)
+	}
+
+	now := time.Now().UTC()
+	if t == nil || t.Expired(now) {
+		return nil
+	}
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= lastUsedInterval {
+		// The stored token could be shared, update a copy
+		ut := *t
+		ut.LastUsedAt = &now
+		if err := s.Storage.SaveAuthenticationToken(ns, &ut); err != nil {
+			logrus.Warningf("Error updating token '%s' last use: %v", t.ID, err)
+		}
+		t = &ut
+	}
+	return t
+}
+
+// legacyToken returns the legacy token of a namespace with the secret, nil if
+// there isn't one. The secrets of the tokens migrated from the clear text ones
+// don't have the ID so only these tokens are checked one by one
+func (s *KhronosService) legacyToken(ns, secret string) *job.Token {
+	ts, _ := s.Storage.GetAuthenticationTokens(ns)
+	for _, t := range ts {
+		if t.Legacy && t.Verify(secret) {
+			return t
+		}
+	}
+	return nil
+}
+
+// authorize wraps an endpoint with the permission it needs, the role of the
+// request token needs to grant it and the job of the route needs to match the
+// label selector of the token. The endpoint gets the token on the request
+// context
+func (s *KhronosService) authorize(permission string, e server.JSONEndpoint) server.JSONEndpoint {
+	return func(r *http.Request) (int, interface{}, error) {
+		// Check security only if enabled
+		if s.Config.APIDisableSecurity {
+			return e(r)
+		}
+
+		t, code, msg := s.requestToken(r)
+		if t == nil {
+			return code, msg, nil
+		}
+		if !t.Can(permission) {
+			logrus.Debugf("Forbidden access with role '%s' to '%s'", t.Role, r.URL.Path)
+			return http.StatusForbidden, fmt.Sprintf(forbiddenPermissionMsg, t.Role, permission), nil
+		}
+
+		sel, err := job.ParseSelector(t.Selector)
+		if err != nil {
+			logrus.Errorf("Error parsing token selector: %v", err)
+			return http.StatusForbidden, forbiddenSelectorMsg, nil
+		}
+		if m := jobRouteRe.FindStringSubmatch(r.URL.Path); len(m) > 0 && len(sel) > 0 {
+			id, _ := strconv.Atoi(m[1])
+			// The missing jobs are left to the endpoint
+			if j, err := s.Storage.GetJob(namespaceFromPath(r.URL.Path), id); err == nil && !sel.Matches(j.Labels) {
+				logrus.Debugf("Forbidden access to job '%d' with selector '%s'", id, t.Selector)
+				return http.StatusForbidden, forbiddenJobMsg, nil
+			}
+		}
+
+		return e(r.WithContext(context.WithValue(r.Context(), tokenKey{}, t)))
+	}
+}
+
+// sharedResource wraps an endpoint that changes a resource shared by all the
+// jobs (e.g calendars or secrets), the tokens limited by a label selector can't
+// call it
+func sharedResource(e server.JSONEndpoint) server.JSONEndpoint {
+	return func(r *http.Request) (int, interface{}, error) {
+		if len(tokenSelector(r)) > 0 {
+			return http.StatusForbidden, forbiddenSharedMsg, nil
+		}
+		return e(r)
+	}
+}
+
+// contextToken returns the request token of the request context, nil if the
+// security is disabled
+func contextToken(r *http.Request) *job.Token {
+	t, _ := r.Context().Value(tokenKey{}).(*job.Token)
+	return t
+}
+
+// tokenSelector returns the label selector of the request token, the selector
+// is empty if the security is disabled
+func tokenSelector(r *http.Request) job.Selector {
+	t := contextToken(r)
+	if t == nil {
+		return job.Selector{}
+	}
+	// The selector was checked on the authorization
+	sel, _ := job.ParseSelector(t.Selector)
+	return sel
+}
@@ -1,35 +1,63 @@
 package service
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/NYTimes/gizmo/server"
+
 	"github.com/slok/khronos/config"
+	"github.com/slok/khronos/job"
 	"github.com/slok/khronos/schedule"
 	"github.com/slok/khronos/storage"
 )
 
 func TestAuthenticationMiddleware(t *testing.T) {
 
-	// These are the valid tokens to make the requests
-	validTokens := map[string]struct{}{
-		"123456789": struct{}{},
+	// These are the valid tokens to make the requests by namespace
+	validTokens := map[string]string{
+		"123456789": job.DefaultNamespace,
+		"555555555": "payments",
+		"111111111": job.DefaultNamespace,
+		"999999999": job.DefaultNamespace,
+	}
+	// These are the namespace scopes of the tokens
+	scopes := map[string][]string{
+		"111111111": []string{"payments"},
+		"999999999": []string{job.AllNamespaces},
 	}
 
 	tests := []struct {
+		GivenPath       string
 		GivenAuthHeader string
 		WantCode        int
+		WantBody        string
 		SecurityEnabled bool
 	}{
-		{GivenAuthHeader: "", WantCode: http.StatusForbidden, SecurityEnabled: true},
+		{GivenAuthHeader: "", WantCode: http.StatusForbidden, WantBody: forbiddenTokenMsg, SecurityEnabled: true},
 		{GivenAuthHeader: "", WantCode: http.StatusOK, SecurityEnabled: false},
-		{GivenAuthHeader: "Bearer ", WantCode: http.StatusForbidden, SecurityEnabled: true},
-		{GivenAuthHeader: "Bearer 987654321", WantCode: http.StatusForbidden, SecurityEnabled: true},
+		{GivenAuthHeader: "Bearer ", WantCode: http.StatusForbidden, WantBody: forbiddenTokenMsg, SecurityEnabled: true},
+		{GivenAuthHeader: "Bearer 987654321", WantCode: http.StatusForbidden, WantBody: forbiddenTokenMsg, SecurityEnabled: true},
 		{GivenAuthHeader: "Bearer 123456789", WantCode: http.StatusOK, SecurityEnabled: true},
-		{GivenAuthHeader: "123456789", WantCode: http.StatusForbidden, SecurityEnabled: true},
-		{GivenAuthHeader: "Bearer  123456789", WantCode: http.StatusForbidden, SecurityEnabled: true},
+		{GivenAuthHeader: "123456789", WantCode: http.StatusForbidden, WantBody: forbiddenTokenMsg, SecurityEnabled: true},
+		{GivenAuthHeader: "Bearer  123456789", WantCode: http.StatusForbidden, WantBody: forbiddenTokenMsg, SecurityEnabled: true},
+		{GivenAuthHeader: "Bearer 555555555", WantCode: http.StatusForbidden, WantBody: forbiddenTokenMsg, SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/payments/jobs", GivenAuthHeader: "Bearer 555555555", WantCode: http.StatusOK, SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/payments/jobs/1/results", GivenAuthHeader: "Bearer 555555555", WantCode: http.StatusOK, SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/payments/jobs", GivenAuthHeader: "Bearer 123456789", WantCode: http.StatusForbidden, WantBody: "Token can't access the namespace 'payments'", SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/payments", GivenAuthHeader: "Bearer 555555555", WantCode: http.StatusOK, SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/payments", GivenAuthHeader: "Bearer 123456789", WantCode: http.StatusForbidden, WantBody: "Token can't access the namespace 'payments'", SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/payments", GivenAuthHeader: "Bearer 111111111", WantCode: http.StatusOK, SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/search", GivenAuthHeader: "Bearer 111111111", WantCode: http.StatusForbidden, WantBody: "Token can't access the namespace 'search'", SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces", GivenAuthHeader: "Bearer 555555555", WantCode: http.StatusForbidden, WantBody: forbiddenTokenMsg, SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/payments/jobs", GivenAuthHeader: "Bearer 111111111", WantCode: http.StatusOK, SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/search/jobs", GivenAuthHeader: "Bearer 111111111", WantCode: http.StatusForbidden, WantBody: "Token can't access the namespace 'search'", SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/search/jobs", GivenAuthHeader: "Bearer 999999999", WantCode: http.StatusOK, SecurityEnabled: true},
+		{GivenPath: "/api/v1/namespaces/default/jobs", GivenAuthHeader: "Bearer 555555555", WantCode: http.StatusForbidden, WantBody: forbiddenTokenMsg, SecurityEnabled: true},
 	}
 
 	for _, test := range tests {
@@ -38,7 +66,11 @@ func TestAuthenticationMiddleware(t *testing.T) {
 		testConfig.APIDisableSecurity = !test.SecurityEnabled
 		testStorageClient := storage.NewDummy()
 		// mock custom auth tokens on database
-		testStorageClient.Tokens = validTokens
+		for token, ns := range validTokens {
+			tk := &job.Token{ID: token, Role: job.RoleAdmin, Namespaces: scopes[token], Legacy: true}
+			tk.SetSecret(token)
+			testStorageClient.SaveAuthenticationToken(ns, tk)
+		}
 		testSchedulerClient := schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK")
 
 		s := &KhronosService{
@@ -48,7 +80,11 @@ func TestAuthenticationMiddleware(t *testing.T) {
 		}
 
 		// Create a testing request
-		r, _ := http.NewRequest("GET", "/", nil)
+		path := test.GivenPath
+		if path == "" {
+			path = "/"
+		}
+		r, _ := http.NewRequest("GET", path, nil)
 		r.Header.Add("Authorization", test.GivenAuthHeader)
 		w := httptest.NewRecorder()
 
@@ -59,8 +95,125 @@ func TestAuthenticationMiddleware(t *testing.T) {
 
 		// Check testing ok
 		if w.Code != test.WantCode {
-			t.Errorf("Authorization middleware error on response status code; expected %d, got %d instead", test.WantCode, w.Code)
+			t.Errorf("Authorization middleware error on response status code of '%s' with '%s'; expected %d, got %d instead", path, test.GivenAuthHeader, test.WantCode, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), test.WantBody) {
+			t.Errorf("Not allowed access of '%s' with '%s' should get the reason %q; got: %s", path, test.GivenAuthHeader, test.WantBody, w.Body.String())
+		}
+	}
+
+}
+
+func TestNamespaceToken(t *testing.T) {
+	testConfig := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	testStorageClient := storage.NewDummy()
+	s := &KhronosService{Config: testConfig, Storage: testStorageClient}
+
+	tk := &job.Token{Name: "ci", Role: job.RoleAdmin}
+	secret, _ := tk.Generate()
+	testStorageClient.SaveAuthenticationToken(job.DefaultNamespace, tk)
+
+	// Tokens not migrated are only looked up by the ID of the secret
+	notLegacy := &job.Token{ID: "1", Role: job.RoleAdmin}
+	notLegacy.SetSecret("123456789")
+	testStorageClient.SaveAuthenticationToken(job.DefaultNamespace, notLegacy)
+	legacy := &job.Token{ID: "2", Role: job.RoleAdmin, Legacy: true}
+	legacy.SetSecret("555555555")
+	testStorageClient.SaveAuthenticationToken(job.DefaultNamespace, legacy)
+
+	got := s.namespaceToken(job.DefaultNamespace, secret)
+	if got == nil || got.ID != tk.ID {
+		t.Fatalf("Token should be found by its ID; got: %#v", got)
+	}
+	if s.namespaceToken(job.DefaultNamespace, "123456789") != nil {
+		t.Errorf("Token without ID on the secret should only be found if legacy")
+	}
+	if got := s.namespaceToken(job.DefaultNamespace, "555555555"); got == nil || got.ID != legacy.ID {
+		t.Errorf("Legacy token should be found; got: %#v", got)
+	}
+
+	// The last use is stored on a copy, not on the stored token
+	if tk.LastUsedAt != nil {
+		t.Errorf("Stored token should not be modified; got: %v", tk.LastUsedAt)
+	}
+	stored, _ := testStorageClient.GetAuthenticationToken(job.DefaultNamespace, tk.ID)
+	if stored.LastUsedAt == nil || !stored.LastUsedAt.Equal(*got.LastUsedAt) {
+		t.Errorf("Token last use should be stored; got: %v", stored.LastUsedAt)
+	}
+}
+
+func TestSelectorTokenRoutes(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	cfg.APIDisableSecurity = false
+
+	testStorageClient := storage.NewDummy()
+	testStorageClient.Jobs = map[string]*job.Job{
+		"job:1": &job.Job{ID: 1, Name: "test1", Labels: map[string]string{"team": "payments"}},
+		"job:2": &job.Job{ID: 2, Name: "test2", Labels: map[string]string{"team": "search"}},
+	}
+	testStorageClient.JobCounter = 2
+	testStorageClient.SaveWorkflowRun(&job.WorkflowRun{JobID: 1, Steps: []*job.WorkflowStep{{JobID: 1}}})
+	testStorageClient.SaveWorkflowRun(&job.WorkflowRun{JobID: 1, Steps: []*job.WorkflowStep{{JobID: 1}, {JobID: 2}}})
+	tokens := map[string]*job.Token{
+		"333333333": {ID: "3", Role: job.RoleAdmin, Legacy: true},
+		"444444444": {ID: "4", Role: job.RoleAdmin, Selector: "team=payments", Legacy: true},
+	}
+	for secret, tk := range tokens {
+		tk.SetSecret(secret)
+		testStorageClient.SaveAuthenticationToken(job.DefaultNamespace, tk)
+	}
+
+	tests := []struct {
+		givenMethod string
+		givenURI    string
+		givenToken  string
+		givenBody   string
+		wantCode    int
+		wantBody    string
+	}{
+		// The tokens limited by labels only read the workflow runs of their jobs
+		{givenMethod: "GET", givenURI: "/api/v1/workflows/runs/1", givenToken: "444444444", wantCode: http.StatusOK},
+		{givenMethod: "GET", givenURI: "/api/v1/workflows/runs/2", givenToken: "444444444", wantCode: http.StatusForbidden, wantBody: forbiddenWorkflowMsg},
+		{givenMethod: "GET", givenURI: "/api/v1/workflows/runs/2", givenToken: "333333333", wantCode: http.StatusOK},
+		// The tokens limited by labels only read the shared resources
+		{givenMethod: "GET", givenURI: "/api/v1/secrets", givenToken: "444444444", wantCode: http.StatusOK},
+		{givenMethod: "POST", givenURI: "/api/v1/secrets", givenToken: "444444444", givenBody: `{"name": "api-token", "value": "s3cr3t"}`, wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "PUT", givenURI: "/api/v1/secrets/api-token", givenToken: "444444444", givenBody: `{"value": "s3cr3t"}`, wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "DELETE", givenURI: "/api/v1/secrets/api-token", givenToken: "444444444", wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "GET", givenURI: "/api/v1/calendars", givenToken: "444444444", wantCode: http.StatusOK},
+		{givenMethod: "POST", givenURI: "/api/v1/calendars", givenToken: "444444444", givenBody: `{"name": "holidays"}`, wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "POST", givenURI: "/api/v1/calendars/import", givenToken: "444444444", wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "PUT", givenURI: "/api/v1/calendars/1", givenToken: "444444444", givenBody: `{"name": "holidays"}`, wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "DELETE", givenURI: "/api/v1/calendars/1", givenToken: "444444444", wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "PUT", givenURI: "/api/v1/hostlimits/test.org", givenToken: "444444444", givenBody: `{"maxConcurrent": 1}`, wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "DELETE", givenURI: "/api/v1/hostlimits/test.org", givenToken: "444444444", wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces", givenToken: "444444444", givenBody: `{"name": "payments"}`, wantCode: http.StatusForbidden, wantBody: forbiddenSharedMsg},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces", givenToken: "333333333", givenBody: `{"name": "payments"}`, wantCode: http.StatusCreated},
+		{givenMethod: "DELETE", givenURI: "/api/v1/namespaces/payments", givenToken: "444444444", wantCode: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		testServer := server.NewSimpleServer(nil)
+		testServer.Register(&KhronosService{
+			Config:  cfg,
+			Storage: testStorageClient,
+			Cron:    schedule.NewDummyCron(cfg, testStorageClient, 0, "OK"),
+		})
+
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, bytes.NewBufferString(test.givenBody))
+		r.Header.Add("Authorization", "Bearer "+test.givenToken)
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s %s with '%s': Expected response code '%d'. Got '%d' instead ", test.givenMethod, test.givenURI, test.givenToken, test.wantCode, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("%s %s with '%s': Expected body with '%s'. Got '%s' instead ", test.givenMethod, test.givenURI, test.givenToken, test.wantBody, w.Body.String())
 		}
 	}
 
+	// Nothing was changed by the tokens limited by labels
+	if len(testStorageClient.Secrets) != 0 || len(testStorageClient.Calendars) != 0 {
+		t.Errorf("Shared resources shouldn't be changed; got: %v, %v", testStorageClient.Secrets, testStorageClient.Calendars)
+	}
 }
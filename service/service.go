@@ -2,11 +2,13 @@ package service
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/NYTimes/gizmo/server"
 	"github.com/Sirupsen/logrus"
 
 	"github.com/slok/khronos/config"
+	"github.com/slok/khronos/job"
 	"github.com/slok/khronos/schedule"
 	"github.com/slok/khronos/storage"
 )
@@ -47,34 +49,136 @@ func (s *KhronosService) JSONMiddleware(j server.JSONEndpoint) server.JSONEndpoi
 	return j
 }
 
-// JSONEndpoints maps the routes to the enpoints
+// JSONEndpoints maps the routes to the enpoints, the routes of the jobs,
+// their results and the tokens are on the default namespace and on every
+// namespace under /namespaces/{ns}. Every endpoint declares the permission the request token
+// needs, the changes of the resources shared by all the jobs can't be done with
+// the tokens limited by labels
 func (s *KhronosService) JSONEndpoints() map[string]map[string]server.JSONEndpoint {
-	return map[string]map[string]server.JSONEndpoint{
+	endpoints := map[string]map[string]server.JSONEndpoint{
 
 		"/ping": map[string]server.JSONEndpoint{
 			// ping is used to check the service is alive
-			"GET": s.Ping,
+			"GET": s.authorize(job.PermissionRead, s.Ping),
 		},
 
 		"/jobs": map[string]server.JSONEndpoint{
 			// Returns all the registered jobs
-			"GET": s.GetJobs,
+			"GET": s.authorize(job.PermissionRead, s.GetJobs),
 			// Register a new job
-			"POST": s.CreateNewJob,
+			"POST": s.authorize(job.PermissionOperate, s.CreateNewJob),
 		},
 
 		"/jobs/{id}": map[string]server.JSONEndpoint{
-			"GET":    s.GetJob,
-			"DELETE": s.DeleteJob,
+			"GET":    s.authorize(job.PermissionRead, s.GetJob),
+			"PUT":    s.authorize(job.PermissionOperate, s.UpdateJob),
+			"PATCH":  s.authorize(job.PermissionOperate, s.PatchJob),
+			"DELETE": s.authorize(job.PermissionOperate, s.DeleteJob),
+		},
+
+		"/jobs/{id}/next": map[string]server.JSONEndpoint{
+			"GET": s.authorize(job.PermissionRead, s.GetJobNextRuns),
+		},
+
+		"/jobs/{id}/run": map[string]server.JSONEndpoint{
+			"POST": s.authorize(job.PermissionOperate, s.RunJob),
+		},
+
+		"/jobs/{id}/pause": map[string]server.JSONEndpoint{
+			"POST": s.authorize(job.PermissionOperate, s.PauseJob),
+		},
+
+		"/jobs/{id}/resume": map[string]server.JSONEndpoint{
+			"POST": s.authorize(job.PermissionOperate, s.ResumeJob),
 		},
 
 		"/jobs/{jobID}/results": map[string]server.JSONEndpoint{
-			"GET": s.GetResults,
+			"GET": s.authorize(job.PermissionRead, s.GetResults),
 		},
 
 		"/jobs/{jobID}/results/{resultID}": map[string]server.JSONEndpoint{
-			"GET":    s.GetResult,
-			"DELETE": s.DeleteResult,
+			"GET":    s.authorize(job.PermissionRead, s.GetResult),
+			"DELETE": s.authorize(job.PermissionOperate, s.DeleteResult),
+		},
+
+		"/schedules/preview": map[string]server.JSONEndpoint{
+			"POST": s.authorize(job.PermissionRead, s.PreviewSchedule),
+		},
+
+		"/pool": map[string]server.JSONEndpoint{
+			// Returns the running and queued executions of the jobs
+			"GET": s.authorize(job.PermissionRead, s.GetPoolState),
+		},
+
+		"/workflows/runs/{id}": map[string]server.JSONEndpoint{
+			"GET": s.authorize(job.PermissionRead, s.GetWorkflowRun),
+		},
+
+		"/calendars": map[string]server.JSONEndpoint{
+			"GET":  s.authorize(job.PermissionRead, s.GetCalendars),
+			"POST": s.authorize(job.PermissionAdmin, sharedResource(s.CreateCalendar)),
+		},
+
+		"/calendars/import": map[string]server.JSONEndpoint{
+			// Create a calendar from an iCalendar (.ics) file
+			"POST": s.authorize(job.PermissionAdmin, sharedResource(s.ImportCalendar)),
+		},
+
+		"/calendars/{id}": map[string]server.JSONEndpoint{
+			"GET":    s.authorize(job.PermissionRead, s.GetCalendar),
+			"PUT":    s.authorize(job.PermissionAdmin, sharedResource(s.UpdateCalendar)),
+			"DELETE": s.authorize(job.PermissionAdmin, sharedResource(s.DeleteCalendar)),
+		},
+
+		"/hostlimits": map[string]server.JSONEndpoint{
+			// Returns the hosts with their own limits
+			"GET": s.authorize(job.PermissionRead, s.GetHostLimits),
+		},
+
+		"/hostlimits/{host}": map[string]server.JSONEndpoint{
+			"GET":    s.authorize(job.PermissionRead, s.GetHostLimit),
+			"PUT":    s.authorize(job.PermissionAdmin, sharedResource(s.SetHostLimit)),
+			"DELETE": s.authorize(job.PermissionAdmin, sharedResource(s.DeleteHostLimit)),
 		},
+
+		"/secrets": map[string]server.JSONEndpoint{
+			// Returns the secrets without their values
+			"GET":  s.authorize(job.PermissionRead, s.GetSecrets),
+			"POST": s.authorize(job.PermissionAdmin, sharedResource(s.CreateSecret)),
+		},
+
+		"/secrets/{name}": map[string]server.JSONEndpoint{
+			"GET":    s.authorize(job.PermissionRead, s.GetSecret),
+			"PUT":    s.authorize(job.PermissionAdmin, sharedResource(s.UpdateSecret)),
+			"DELETE": s.authorize(job.PermissionAdmin, sharedResource(s.DeleteSecret)),
+		},
+
+		"/namespaces": map[string]server.JSONEndpoint{
+			"GET":  s.authorize(job.PermissionRead, s.GetNamespaces),
+			"POST": s.authorize(job.PermissionAdmin, sharedResource(s.CreateNamespace)),
+		},
+
+		"/tokens": map[string]server.JSONEndpoint{
+			// Returns the tokens without their secrets
+			"GET":  s.authorize(job.PermissionAdmin, s.GetTokens),
+			"POST": s.authorize(job.PermissionAdmin, s.CreateToken),
+		},
+
+		"/tokens/{tokenID}": map[string]server.JSONEndpoint{
+			"DELETE": s.authorize(job.PermissionAdmin, s.DeleteToken),
+		},
+
+		"/namespaces/{ns}": map[string]server.JSONEndpoint{
+			"GET":    s.authorize(job.PermissionRead, s.GetNamespace),
+			"DELETE": s.authorize(job.PermissionAdmin, sharedResource(s.DeleteNamespace)),
+		},
+	}
+
+	// The same job, workflow run, token and secret routes scoped to a namespace
+	for route, e := range endpoints {
+		if strings.HasPrefix(route, "/jobs") || strings.HasPrefix(route, "/workflows") || strings.HasPrefix(route, "/tokens") || strings.HasPrefix(route, "/secrets") {
+			endpoints["/namespaces/{ns}"+route] = e
+		}
 	}
+	return endpoints
 }
@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/slok/khronos/config"
 	"github.com/slok/khronos/job"
 	"github.com/slok/khronos/schedule"
+	"github.com/slok/khronos/secret"
 	"github.com/slok/khronos/storage"
 )
 
@@ -137,6 +139,37 @@ func TestGetJobs(t *testing.T) {
 	}
 }
 
+func TestOffsetsFromPage(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	cfg.APIResourcesPerPage = 5
+	s := &KhronosService{Config: cfg}
+
+	tests := []struct {
+		givenPage   int
+		givenLength int
+		wantStart   int
+		wantEnd     int
+	}{
+		{givenPage: 1, givenLength: 0, wantStart: 0, wantEnd: 5},
+		{givenPage: 1, givenLength: 3, wantStart: 0, wantEnd: 3},
+		{givenPage: 1, givenLength: 5, wantStart: 0, wantEnd: 5},
+		{givenPage: 2, givenLength: 12, wantStart: 5, wantEnd: 10},
+		// The last page is full when the length is a multiple of the page size
+		{givenPage: 2, givenLength: 10, wantStart: 5, wantEnd: 10},
+		// The last page with only one resource ends on the length
+		{givenPage: 3, givenLength: 11, wantStart: 10, wantEnd: 11},
+		{givenPage: 3, givenLength: 13, wantStart: 10, wantEnd: 13},
+		{givenPage: 4, givenLength: 13, wantStart: 15, wantEnd: 20},
+	}
+
+	for _, test := range tests {
+		start, end := s.offsetsFromPage(test.givenPage, test.givenLength)
+		if start != test.wantStart || end != test.wantEnd {
+			t.Errorf("Page %d of %d: expected [%d, %d); got [%d, %d)", test.givenPage, test.givenLength, test.wantStart, test.wantEnd, start, end)
+		}
+	}
+}
+
 func TestGetJobsPaginated(t *testing.T) {
 	jobs := make(map[string]*job.Job)
 	totalJobs := 27
@@ -239,6 +272,101 @@ func TestGetJobsPaginated(t *testing.T) {
 	}
 }
 
+func TestGetJobsSelected(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	// Custom pagination
+	paginationTestConfig := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	paginationTestConfig.APIResourcesPerPage = 2
+	testCronEngine := schedule.NewDummyCron(paginationTestConfig, testStorageClient, 0, "OK")
+	testCronEngine.Start(nil)
+
+	testStorageClient.Jobs = map[string]*job.Job{
+		"job:1": &job.Job{ID: 1, Name: "test1", When: "@daily", URL: &url.URL{}, Labels: map[string]string{"team": "payments", "env": "production"}},
+		"job:2": &job.Job{ID: 2, Name: "test2", When: "@daily", URL: &url.URL{}, Labels: map[string]string{"team": "payments", "env": "staging"}},
+		"job:3": &job.Job{ID: 3, Name: "test3", When: "@daily", URL: &url.URL{}, Labels: map[string]string{"team": "search"}},
+		"job:4": &job.Job{ID: 4, Name: "test4", When: "@daily", URL: &url.URL{}, Labels: map[string]string{"team": "payments"}},
+		"job:5": &job.Job{ID: 5, Name: "test5", When: "@daily", URL: &url.URL{}},
+	}
+	testStorageClient.JobCounter = 5
+
+	// Testing data
+	tests := []struct {
+		givenURI   string
+		wantCode   int
+		wantJobIDs []int
+	}{
+		{
+			givenURI:   "/api/v1/jobs?selector=team%3Dpayments,env!%3Dstaging",
+			wantCode:   http.StatusOK,
+			wantJobIDs: []int{1, 4},
+		},
+		{
+			givenURI:   "/api/v1/jobs?selector=team",
+			wantCode:   http.StatusOK,
+			wantJobIDs: []int{1, 2},
+		},
+		{
+			givenURI:   "/api/v1/jobs?selector=team&page=2",
+			wantCode:   http.StatusOK,
+			wantJobIDs: []int{3, 4},
+		},
+		{
+			givenURI:   "/api/v1/jobs?selector=team&page=3",
+			wantCode:   http.StatusOK,
+			wantJobIDs: []int{},
+		},
+		{
+			givenURI:   "/api/v1/jobs?selector=env+notin+(staging)&page=2",
+			wantCode:   http.StatusOK,
+			wantJobIDs: []int{4, 5},
+		},
+		{
+			givenURI:   "/api/v1/jobs?selector=team+in+(web",
+			wantCode:   http.StatusBadRequest,
+			wantJobIDs: []int{},
+		},
+	}
+
+	// Tests
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  paginationTestConfig,
+			Storage: testStorageClient,
+			Cron:    testCronEngine,
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest("GET", test.givenURI, nil)
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("Expected response code '%d'. Got '%d' instead ", test.wantCode, w.Code)
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+
+		var got []*job.Job
+		err := json.NewDecoder(w.Body).Decode(&got)
+		if err != nil {
+			t.Error(err)
+		}
+
+		// Check IDs ok (should be in order)
+		gotIDs := []int{}
+		for _, j := range got {
+			gotIDs = append(gotIDs, j.ID)
+		}
+		if !reflect.DeepEqual(gotIDs, test.wantJobIDs) {
+			t.Errorf("Expected job ids '%v'. Got '%v' instead ", test.wantJobIDs, gotIDs)
+		}
+	}
+}
+
 func TestGetJob(t *testing.T) {
 	testStorageClient := storage.NewDummy()
 	testCronEngine := schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK")
@@ -382,6 +510,52 @@ func TestDeleteJob(t *testing.T) {
 	}
 }
 
+func TestDeleteUpstreamJob(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	testStorageClient.Jobs = map[string]*job.Job{
+		"job:1": &job.Job{ID: 1, Name: "test1", When: "@daily", URL: &url.URL{}},
+		"job:2": &job.Job{ID: 2, Name: "test2", URL: &url.URL{}, DependsOn: []*job.Dependency{{JobID: 1, On: job.TriggerSuccess}}},
+	}
+	testStorageClient.JobCounter = 2
+
+	// Testing data, run in order
+	tests := []struct {
+		givenURI string
+		wantCode int
+		wantJobs int
+	}{
+		{givenURI: "/api/v1/jobs/1", wantCode: http.StatusBadRequest, wantJobs: 2},
+		{givenURI: "/api/v1/jobs/2", wantCode: http.StatusNoContent, wantJobs: 1},
+		{givenURI: "/api/v1/jobs/1", wantCode: http.StatusNoContent, wantJobs: 0},
+	}
+
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  testConfig,
+			Storage: testStorageClient,
+			Cron:    schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK"),
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest("DELETE", test.givenURI, nil)
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s: Expected response code '%d'. Got '%d' instead ", test.givenURI, test.wantCode, w.Code)
+		}
+		if w.Code == http.StatusBadRequest && !strings.Contains(w.Body.String(), "Job is an upstream job of job 2") {
+			t.Errorf("%s: Expected the dependent jobs on the errors. Got '%s' instead ", test.givenURI, w.Body.String())
+		}
+		if len(testStorageClient.Jobs) != test.wantJobs {
+			t.Errorf("%s: Expected '%d' jobs. Got '%d' instead ", test.givenURI, test.wantJobs, len(testStorageClient.Jobs))
+		}
+	}
+}
+
 func TestCreateNewJob(t *testing.T) {
 	testStorageClient := storage.NewDummy()
 	testCronEngine := schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK")
@@ -411,6 +585,20 @@ func TestCreateNewJob(t *testing.T) {
 			wantCode:    http.StatusBadRequest,
 			wantJobslen: 0,
 		},
+		{
+			givenURI:    "/api/v1/jobs",
+			givenBody:   `{"active": true, "url": "http://crons.test.com/hello-world", "name": "after-test1", "dependsOn": [{"job": 1, "on": "failure"}]}`,
+			givenJobs:   map[string]*job.Job{"job:1": &job.Job{ID: 1, Name: "test1"}},
+			wantCode:    http.StatusCreated,
+			wantJobslen: 2,
+		},
+		{
+			givenURI:    "/api/v1/jobs",
+			givenBody:   `{"active": true, "url": "http://crons.test.com/hello-world", "name": "after-test2", "dependsOn": [{"job": 2}]}`,
+			givenJobs:   map[string]*job.Job{"job:1": &job.Job{ID: 1, Name: "test1"}},
+			wantCode:    http.StatusBadRequest,
+			wantJobslen: 1,
+		},
 	}
 
 	for _, test := range tests {
@@ -789,3 +977,982 @@ func TestGetResultsPaginated(t *testing.T) {
 		}
 	}
 }
+
+func TestPauseResumeJob(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	testCronEngine := schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK")
+	testCronEngine.Start(nil)
+
+	// Testing data
+	tests := []struct {
+		givenURI    string
+		givenActive bool
+		wantCode    int
+		wantActive  bool
+	}{
+		{givenURI: "/api/v1/jobs/1/pause", givenActive: true, wantCode: http.StatusOK, wantActive: false},
+		{givenURI: "/api/v1/jobs/1/pause", givenActive: false, wantCode: http.StatusOK, wantActive: false},
+		{givenURI: "/api/v1/jobs/1/resume", givenActive: false, wantCode: http.StatusOK, wantActive: true},
+		{givenURI: "/api/v1/jobs/2/resume", givenActive: false, wantCode: http.StatusInternalServerError, wantActive: false},
+	}
+
+	for _, test := range tests {
+		// Set our dummy 'database' on the storage client
+		j := &job.Job{ID: 1, Name: "test1", Description: "test1", When: "@daily", Active: test.givenActive, URL: &url.URL{}}
+		testStorageClient.Jobs = map[string]*job.Job{"job:1": j}
+		testStorageClient.JobCounter = 1
+		testCronEngine.RegisterCronJob(j)
+
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  testConfig,
+			Storage: testStorageClient,
+			Cron:    testCronEngine,
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest("POST", test.givenURI, nil)
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("Expected response code '%d'. Got '%d' instead ", test.wantCode, w.Code)
+		}
+
+		// Check stored job state
+		if testStorageClient.Jobs["job:1"].Active != test.wantActive {
+			t.Errorf("Expected job active '%t'. Got '%t' instead ", test.wantActive, testStorageClient.Jobs["job:1"].Active)
+		}
+	}
+}
+
+func TestUpdateJob(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	testCronEngine := schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK")
+	testCronEngine.Start(nil)
+
+	// Testing data
+	tests := []struct {
+		givenMethod string
+		givenURI    string
+		givenBody   string
+		wantCode    int
+		wantName    string
+		wantWhen    string
+		wantURL     string
+		wantLabels  map[string]string
+	}{
+		{
+			givenMethod: "PUT",
+			givenURI:    "/api/v1/jobs/1",
+			givenBody:   `{"name": "updated", "when": "@hourly", "url": "http://khronos.io/updated"}`,
+			wantCode:    http.StatusOK,
+			wantName:    "updated",
+			wantWhen:    "@hourly",
+			wantURL:     "http://khronos.io/updated",
+		},
+		{
+			givenMethod: "PUT",
+			givenURI:    "/api/v1/jobs/1",
+			givenBody:   `{"name": "updated", "when": "@hourly"}`,
+			wantCode:    http.StatusBadRequest,
+			wantName:    "test1",
+			wantWhen:    "@daily",
+			wantURL:     "http://khronos.io/test1",
+		},
+		{
+			givenMethod: "PATCH",
+			givenURI:    "/api/v1/jobs/1",
+			givenBody:   `{"when": "@hourly"}`,
+			wantCode:    http.StatusOK,
+			wantName:    "test1",
+			wantWhen:    "@hourly",
+			wantURL:     "http://khronos.io/test1",
+		},
+		{
+			givenMethod: "PATCH",
+			givenURI:    "/api/v1/jobs/1",
+			givenBody:   `{"labels": {"env": null, "tier": "1"}}`,
+			wantCode:    http.StatusOK,
+			wantName:    "test1",
+			wantWhen:    "@daily",
+			wantURL:     "http://khronos.io/test1",
+			wantLabels:  map[string]string{"team": "payments", "tier": "1"},
+		},
+		{
+			givenMethod: "PATCH",
+			givenURI:    "/api/v1/jobs/1",
+			givenBody:   `{"when": "wrong"}`,
+			wantCode:    http.StatusBadRequest,
+			wantName:    "test1",
+			wantWhen:    "@daily",
+			wantURL:     "http://khronos.io/test1",
+		},
+		{
+			givenMethod: "PATCH",
+			givenURI:    "/api/v1/jobs/2",
+			givenBody:   `{"when": "@hourly"}`,
+			wantCode:    http.StatusInternalServerError,
+			wantName:    "test1",
+			wantWhen:    "@daily",
+			wantURL:     "http://khronos.io/test1",
+		},
+	}
+
+	for _, test := range tests {
+		// Set our dummy 'database' on the storage client
+		u, _ := url.Parse("http://khronos.io/test1")
+		j := &job.Job{ID: 1, Name: "test1", Description: "test1", Labels: map[string]string{"team": "payments", "env": "prod"}, When: "@daily", Active: true, URL: u}
+		testStorageClient.Jobs = map[string]*job.Job{"job:1": j}
+		testStorageClient.JobCounter = 1
+		testCronEngine.RegisterCronJob(j)
+
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  testConfig,
+			Storage: testStorageClient,
+			Cron:    testCronEngine,
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, bytes.NewBufferString(test.givenBody))
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("Expected response code '%d'. Got '%d' instead ", test.wantCode, w.Code)
+		}
+
+		// Check stored job state
+		if len(testStorageClient.Jobs) != 1 {
+			t.Errorf("Expected only one stored job. Got '%d' instead ", len(testStorageClient.Jobs))
+		}
+		gotJ := testStorageClient.Jobs["job:1"]
+		if gotJ.ID != 1 || gotJ.Name != test.wantName || gotJ.When != test.wantWhen || gotJ.URL.String() != test.wantURL {
+			t.Errorf("Unexpected stored job: %+v", gotJ)
+		}
+		if test.wantLabels != nil && !reflect.DeepEqual(gotJ.Labels, test.wantLabels) {
+			t.Errorf("Expected job labels '%v'. Got '%v' instead ", test.wantLabels, gotJ.Labels)
+		}
+	}
+}
+
+func TestRunJob(t *testing.T) {
+	// Testing data
+	tests := []struct {
+		givenURI   string
+		wantCode   int
+		wantResult bool
+	}{
+		{givenURI: "/api/v1/jobs/1/run", wantCode: http.StatusAccepted, wantResult: false},
+		{givenURI: "/api/v1/jobs/1/run?wait=true", wantCode: http.StatusOK, wantResult: true},
+		{givenURI: "/api/v1/jobs/2/run", wantCode: http.StatusInternalServerError, wantResult: false},
+	}
+
+	for _, test := range tests {
+		// Set our dummy 'database' on the storage client
+		testStorageClient := storage.NewDummy()
+		j := &job.Job{ID: 1, Name: "test1", Description: "test1", When: "@yearly", Active: true, URL: &url.URL{}}
+		testStorageClient.Jobs = map[string]*job.Job{"job:1": j}
+		testStorageClient.JobCounter = 1
+		testCronEngine := schedule.NewDummyCron(testConfig, testStorageClient, job.ResultOK, "OK")
+		testCronEngine.Start(nil)
+
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  testConfig,
+			Storage: testStorageClient,
+			Cron:    testCronEngine,
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest("POST", test.givenURI, nil)
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("Expected response code '%d'. Got '%d' instead ", test.wantCode, w.Code)
+		}
+
+		if test.wantCode == http.StatusAccepted {
+			var gotID map[string]int
+			if err := json.Unmarshal(w.Body.Bytes(), &gotID); err != nil || gotID["id"] != 1 {
+				t.Errorf("Expected result id '1'. Got '%s' instead ", w.Body.String())
+			}
+		}
+
+		if test.wantResult {
+			var gotResult job.Result
+			if err := json.Unmarshal(w.Body.Bytes(), &gotResult); err != nil {
+				t.Errorf("Error unmarshaling: %v", err)
+			}
+			if gotResult.ID != 1 || !gotResult.Manual || gotResult.Status != job.ResultOK || gotResult.Out != "OK" {
+				t.Errorf("Unexpected result: %+v", gotResult)
+			}
+		}
+	}
+}
+
+func TestRunWaitTimeout(t *testing.T) {
+	cfg := &config.AppConfig{Khronos: &config.Khronos{JobDefaultTimeoutSeconds: 2}}
+	s := &KhronosService{Config: cfg}
+
+	tests := []struct {
+		givenJob *job.Job
+		want     time.Duration
+	}{
+		{givenJob: &job.Job{}, want: 2 * time.Second},
+		{givenJob: &job.Job{Timeout: time.Second}, want: time.Second},
+		{givenJob: &job.Job{Timeout: time.Second, Retry: &job.Retry{MaxAttempts: 3}}, want: 3 * time.Second},
+	}
+	for _, test := range tests {
+		if got := s.runWaitTimeout(test.givenJob); got != test.want {
+			t.Errorf("Wrong run wait timeout; expected: %v; got: %v", test.want, got)
+		}
+	}
+}
+func TestGetJobRuns(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	testCronEngine := schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK")
+	testCronEngine.Start(nil)
+
+	j := &job.Job{ID: 1, Name: "test1", Description: "test1", When: "@daily", Active: true, URL: &url.URL{}}
+	j2 := &job.Job{ID: 2, Name: "test2", Description: "test2", When: "@hourly", Active: true, URL: &url.URL{}}
+	testStorageClient.Jobs = map[string]*job.Job{"job:1": j, "job:2": j2}
+	testStorageClient.JobCounter = 2
+	testCronEngine.RegisterCronJob(j)
+	testCronEngine.RegisterCronJob(j2)
+
+	// Create a testing server
+	testServer := server.NewSimpleServer(nil)
+
+	// Register our service on the server (we don't need configuration for this service)
+	testServer.Register(&KhronosService{
+		Config:  testConfig,
+		Storage: testStorageClient,
+		Cron:    testCronEngine,
+	})
+
+	for _, uri := range []string{"/api/v1/jobs/1", "/api/v1/jobs/2", "/api/v1/jobs"} {
+		// Create request and a test recorder
+		r, _ := http.NewRequest("GET", uri, nil)
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected response code '%d'. Got '%d' instead ", http.StatusOK, w.Code)
+		}
+
+		got := []*job.Job{}
+		b := w.Body.Bytes()
+		if b[0] != '[' {
+			b = []byte(fmt.Sprintf("[%s]", b))
+		}
+		if err := json.Unmarshal(b, &got); err != nil || len(got) == 0 {
+			t.Fatalf("Error unmarshaling: %v", err)
+		}
+
+		// Registered jobs only have next run, not executed yet
+		for _, gotJ := range got {
+			if gotJ.NextRun == nil || !gotJ.NextRun.After(time.Now()) {
+				t.Errorf("Expected job next run. Got '%v' instead ", gotJ.NextRun)
+			}
+			if gotJ.PrevRun != nil {
+				t.Errorf("Expected no job previous run. Got '%v' instead ", gotJ.PrevRun)
+			}
+		}
+	}
+
+	// Stored jobs are not modified
+	if j.NextRun != nil || j.PrevRun != nil || j2.NextRun != nil {
+		t.Errorf("Stored jobs shouldn't have runs")
+	}
+}
+
+func TestGetJobNextRuns(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	testCronEngine := schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK")
+	testCronEngine.Start(nil)
+
+	// Testing data
+	tests := []struct {
+		givenURI  string
+		wantCode  int
+		wantCount int
+	}{
+		{givenURI: "/api/v1/jobs/1/next", wantCode: http.StatusOK, wantCount: 5},
+		{givenURI: "/api/v1/jobs/1/next?count=3", wantCode: http.StatusOK, wantCount: 3},
+		{givenURI: "/api/v1/jobs/1/next?count=1000", wantCode: http.StatusOK, wantCount: 100},
+		{givenURI: "/api/v1/jobs/2/next", wantCode: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		// Set our dummy 'database' on the storage client
+		j := &job.Job{ID: 1, Name: "test1", Description: "test1", When: "0 0 9 * * *", TimeZone: "Asia/Tokyo", Active: true, URL: &url.URL{}}
+		testStorageClient.Jobs = map[string]*job.Job{"job:1": j}
+		testStorageClient.JobCounter = 1
+
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  testConfig,
+			Storage: testStorageClient,
+			Cron:    testCronEngine,
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest("GET", test.givenURI, nil)
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("Expected response code '%d'. Got '%d' instead ", test.wantCode, w.Code)
+		}
+
+		if w.Code == http.StatusOK {
+			var runs []time.Time
+			if err := json.Unmarshal(w.Body.Bytes(), &runs); err != nil {
+				t.Errorf("Error unmarshaling: %v", err)
+			}
+			if len(runs) != test.wantCount {
+				t.Errorf("Expected '%d' runs. Got '%d' instead ", test.wantCount, len(runs))
+			}
+			for _, run := range runs {
+				if run.UTC().Hour() != 0 {
+					t.Errorf("Expected runs at 09:00 Asia/Tokyo. Got '%v' instead ", run)
+				}
+			}
+		}
+	}
+}
+
+func TestPreviewSchedule(t *testing.T) {
+	// Testing data
+	tests := []struct {
+		givenBody string
+		wantCode  int
+		wantCount int
+	}{
+		{givenBody: `{"when": "@hourly"}`, wantCode: http.StatusOK, wantCount: 5},
+		{givenBody: `{"when": "0 0 9 * * *", "timeZone": "Europe/Madrid", "count": 10}`, wantCode: http.StatusOK, wantCount: 10},
+		{givenBody: `{"when": "wrong"}`, wantCode: http.StatusBadRequest},
+		{givenBody: `{"when": "@hourly", "count": 1000}`, wantCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config: testConfig,
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest("POST", "/api/v1/schedules/preview", bytes.NewBufferString(test.givenBody))
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("Expected response code '%d'. Got '%d' instead ", test.wantCode, w.Code)
+		}
+
+		if w.Code == http.StatusOK {
+			var runs []time.Time
+			if err := json.Unmarshal(w.Body.Bytes(), &runs); err != nil {
+				t.Errorf("Error unmarshaling: %v", err)
+			}
+			if len(runs) != test.wantCount {
+				t.Errorf("Expected '%d' runs. Got '%d' instead ", test.wantCount, len(runs))
+			}
+		}
+	}
+}
+
+func TestGetWorkflowRun(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	w := &job.WorkflowRun{
+		JobID:  1,
+		Status: job.WorkflowSucceeded,
+		Steps: []*job.WorkflowStep{
+			{JobID: 1, ResultID: 1, Status: job.ResultOK},
+			{JobID: 2, Status: job.ResultSkipped},
+		},
+	}
+	testStorageClient.SaveWorkflowRun(w)
+	testStorageClient.SaveWorkflowRun(&job.WorkflowRun{JobID: 3, Namespace: "payments", Status: job.WorkflowSucceeded})
+
+	// Testing data
+	tests := []struct {
+		givenURI  string
+		wantCode  int
+		wantID    int
+		wantSteps int
+	}{
+		{givenURI: "/api/v1/workflows/runs/1", wantCode: http.StatusOK, wantID: 1, wantSteps: 2},
+		{givenURI: "/api/v1/namespaces/default/workflows/runs/1", wantCode: http.StatusOK, wantID: 1, wantSteps: 2},
+		{givenURI: "/api/v1/namespaces/payments/workflows/runs/2", wantCode: http.StatusOK, wantID: 2},
+		{givenURI: "/api/v1/workflows/runs/3", wantCode: http.StatusInternalServerError},
+		{givenURI: "/api/v1/workflows/runs/wrong", wantCode: http.StatusInternalServerError},
+		// The workflow runs of other namespaces can't be retrieved
+		{givenURI: "/api/v1/workflows/runs/2", wantCode: http.StatusInternalServerError},
+		{givenURI: "/api/v1/namespaces/search/workflows/runs/2", wantCode: http.StatusInternalServerError},
+		{givenURI: "/api/v1/namespaces/payments/workflows/runs/1", wantCode: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  testConfig,
+			Storage: testStorageClient,
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest("GET", test.givenURI, nil)
+		rec := httptest.NewRecorder()
+		testServer.ServeHTTP(rec, r)
+		if rec.Code != test.wantCode {
+			t.Errorf("Expected response code '%d'. Got '%d' instead ", test.wantCode, rec.Code)
+		}
+
+		if rec.Code == http.StatusOK {
+			gotW := &job.WorkflowRun{}
+			if err := json.Unmarshal(rec.Body.Bytes(), gotW); err != nil {
+				t.Errorf("Error unmarshaling: %v", err)
+			}
+			if gotW.ID != test.wantID || gotW.Status != w.Status || len(gotW.Steps) != test.wantSteps {
+				t.Errorf("Expected workflow run '%+v'. Got '%+v' instead ", w, gotW)
+			}
+		}
+	}
+}
+
+func TestCalendars(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	testStorageClient.Jobs = map[string]*job.Job{
+		"job:1": &job.Job{ID: 1, Name: "test1", Calendars: []*job.CalendarRule{{CalendarID: 1, Mode: job.CalendarExclude}}},
+	}
+	testStorageClient.JobCounter = 1
+
+	ics := "BEGIN:VCALENDAR\r\nX-WR-CALNAME:Holidays\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20171225\r\nSUMMARY:Christmas\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+
+	// Testing data, run in order
+	tests := []struct {
+		givenMethod   string
+		givenURI      string
+		givenBody     string
+		wantCode      int
+		wantCalendars int
+	}{
+		{givenMethod: "POST", givenURI: "/api/v1/calendars", givenBody: `{"name": "freeze", "windows": [{"start": "2017-12-28T09:00:00Z", "end": "2017-12-28T18:00:00Z"}]}`, wantCode: http.StatusCreated, wantCalendars: 1},
+		{givenMethod: "POST", givenURI: "/api/v1/calendars", givenBody: `{"windows": [{"start": "2017-12-28T09:00:00Z"}]}`, wantCode: http.StatusBadRequest, wantCalendars: 1},
+		{givenMethod: "POST", givenURI: "/api/v1/calendars/import?timeZone=Europe/Madrid", givenBody: ics, wantCode: http.StatusCreated, wantCalendars: 2},
+		{givenMethod: "POST", givenURI: "/api/v1/calendars/import", givenBody: "not ics", wantCode: http.StatusBadRequest, wantCalendars: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/calendars", wantCode: http.StatusOK, wantCalendars: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/calendars/2", wantCode: http.StatusOK, wantCalendars: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/calendars/3", wantCode: http.StatusInternalServerError, wantCalendars: 2},
+		{givenMethod: "PUT", givenURI: "/api/v1/calendars/1", givenBody: `{"name": "holidays", "windows": [{"start": "2017-12-25"}]}`, wantCode: http.StatusOK, wantCalendars: 2},
+		{givenMethod: "DELETE", givenURI: "/api/v1/calendars/1", wantCode: http.StatusBadRequest, wantCalendars: 2},
+		{givenMethod: "DELETE", givenURI: "/api/v1/calendars/2", wantCode: http.StatusNoContent, wantCalendars: 1},
+	}
+
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  testConfig,
+			Storage: testStorageClient,
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, bytes.NewBufferString(test.givenBody))
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s %s: Expected response code '%d'. Got '%d' instead ", test.givenMethod, test.givenURI, test.wantCode, w.Code)
+		}
+		if len(testStorageClient.Calendars) != test.wantCalendars {
+			t.Errorf("%s %s: Expected '%d' calendars. Got '%d' instead ", test.givenMethod, test.givenURI, test.wantCalendars, len(testStorageClient.Calendars))
+		}
+	}
+
+	// The calendar used by the job has been replaced
+	cal, _ := testStorageClient.GetCalendar(1)
+	if cal.Name != "holidays" || cal.TimeZone != "" || len(cal.Windows) != 1 {
+		t.Errorf("Wrong updated calendar: %#v", cal)
+	}
+}
+
+func TestGetPoolState(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	testCronEngine := schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK")
+
+	// Create a testing server
+	testServer := server.NewSimpleServer(nil)
+
+	// Register our service on the server (we don't need configuration for this service)
+	testServer.Register(&KhronosService{
+		Config:  testConfig,
+		Storage: testStorageClient,
+		Cron:    testCronEngine,
+	})
+
+	// Create request and a test recorder
+	r, _ := http.NewRequest("GET", "/api/v1/pool", nil)
+	w := httptest.NewRecorder()
+	testServer.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected response code '%d'. Got '%d' instead ", http.StatusOK, w.Code)
+	}
+
+	st := &schedule.PoolState{}
+	if err := json.Unmarshal(w.Body.Bytes(), st); err != nil {
+		t.Errorf("Error unmarshaling: %v", err)
+	}
+	if st.Size != testConfig.WorkerPoolSize || st.Running != 0 || len(st.Queue) != 0 {
+		t.Errorf("Wrong pool state: %+v", st)
+	}
+}
+
+func TestHostLimits(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	testCronEngine := schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK")
+
+	// Testing data, run in order
+	tests := []struct {
+		givenMethod    string
+		givenURI       string
+		givenBody      string
+		wantCode       int
+		wantHostLimits int
+		wantLimit      *job.HostLimit
+	}{
+		{givenMethod: "GET", givenURI: "/api/v1/hostlimits/example.org", wantCode: http.StatusOK, wantHostLimits: 0, wantLimit: &job.HostLimit{Host: "example.org", Burst: testConfig.HostRateBurst}},
+		{givenMethod: "PUT", givenURI: "/api/v1/hostlimits/Example.org:8080", givenBody: `{"rate": 0.5, "burst": 2, "maxConcurrency": 4}`, wantCode: http.StatusOK, wantHostLimits: 1, wantLimit: &job.HostLimit{Host: "example.org:8080", Rate: 0.5, Burst: 2, MaxConcurrency: 4}},
+		{givenMethod: "PUT", givenURI: "/api/v1/hostlimits/example.org", givenBody: `{"rate": -1}`, wantCode: http.StatusBadRequest, wantHostLimits: 1},
+		{givenMethod: "GET", givenURI: "/api/v1/hostlimits/example.org:8080", wantCode: http.StatusOK, wantHostLimits: 1, wantLimit: &job.HostLimit{Host: "example.org:8080", Rate: 0.5, Burst: 2, MaxConcurrency: 4}},
+		{givenMethod: "GET", givenURI: "/api/v1/hostlimits", wantCode: http.StatusOK, wantHostLimits: 1},
+		{givenMethod: "DELETE", givenURI: "/api/v1/hostlimits/example.org:8080", wantCode: http.StatusNoContent, wantHostLimits: 0},
+		{givenMethod: "GET", givenURI: "/api/v1/hostlimits/example.org:8080", wantCode: http.StatusOK, wantHostLimits: 0, wantLimit: &job.HostLimit{Host: "example.org:8080", Burst: testConfig.HostRateBurst}},
+	}
+
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  testConfig,
+			Storage: testStorageClient,
+			Cron:    testCronEngine,
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, bytes.NewBufferString(test.givenBody))
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s %s: Expected response code '%d'. Got '%d' instead ", test.givenMethod, test.givenURI, test.wantCode, w.Code)
+		}
+		if len(testStorageClient.HostLimits) != test.wantHostLimits {
+			t.Errorf("%s %s: Expected '%d' host limits. Got '%d' instead ", test.givenMethod, test.givenURI, test.wantHostLimits, len(testStorageClient.HostLimits))
+		}
+
+		if test.wantLimit != nil {
+			l := &job.HostLimit{}
+			if err := json.Unmarshal(w.Body.Bytes(), l); err != nil {
+				t.Errorf("Error unmarshaling: %v", err)
+			}
+			if !reflect.DeepEqual(l, test.wantLimit) {
+				t.Errorf("%s %s: Expected host limit '%+v'. Got '%+v' instead ", test.givenMethod, test.givenURI, test.wantLimit, l)
+			}
+		}
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	// Enable the secrets
+	cfg := *testConfig
+	kcfg := *testConfig.Khronos
+	kcfg.SecretsKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	cfg.Khronos = &kcfg
+
+	testStorageClient := storage.NewDummy()
+	testStorageClient.Jobs = map[string]*job.Job{
+		"job:1": &job.Job{ID: 1, Name: "test1", Template: true, Headers: map[string]string{"Authorization": `Bearer {{secret "api-token"}}`}},
+	}
+	testStorageClient.JobCounter = 1
+
+	// Testing data, run in order
+	tests := []struct {
+		givenMethod string
+		givenURI    string
+		givenBody   string
+		wantCode    int
+		wantSecrets int
+	}{
+		{givenMethod: "POST", givenURI: "/api/v1/secrets", givenBody: `{"name": "api-token", "value": "s3cr3t"}`, wantCode: http.StatusCreated, wantSecrets: 1},
+		{givenMethod: "POST", givenURI: "/api/v1/secrets", givenBody: `{"name": "api-token", "value": "other"}`, wantCode: http.StatusBadRequest, wantSecrets: 1},
+		{givenMethod: "POST", givenURI: "/api/v1/secrets", givenBody: `{"name": "db password"}`, wantCode: http.StatusBadRequest, wantSecrets: 1},
+		{givenMethod: "POST", givenURI: "/api/v1/secrets", givenBody: `{"name": "db-password", "value": "s3cr3t"}`, wantCode: http.StatusCreated, wantSecrets: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/secrets", wantCode: http.StatusOK, wantSecrets: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/secrets/api-token", wantCode: http.StatusOK, wantSecrets: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/secrets/missing", wantCode: http.StatusInternalServerError, wantSecrets: 2},
+		{givenMethod: "PUT", givenURI: "/api/v1/secrets/api-token", givenBody: `{"description": "reports API", "value": "n3w"}`, wantCode: http.StatusOK, wantSecrets: 2},
+		{givenMethod: "DELETE", givenURI: "/api/v1/secrets/api-token", wantCode: http.StatusBadRequest, wantSecrets: 2},
+		{givenMethod: "DELETE", givenURI: "/api/v1/secrets/db-password", wantCode: http.StatusNoContent, wantSecrets: 1},
+		{givenMethod: "POST", givenURI: "/api/v1/jobs", givenBody: `{"name": "test2", "when": "@daily", "url": "http://test.org", "template": true, "headers": {"X-Key": "{{secret \"db-password\"}}"}}`, wantCode: http.StatusBadRequest, wantSecrets: 1},
+		{givenMethod: "POST", givenURI: "/api/v1/jobs", givenBody: `{"name": "test2", "when": "@daily", "url": "http://test.org", "template": true, "headers": {"X-Key": "{{secret \"api-token\"}}"}}`, wantCode: http.StatusCreated, wantSecrets: 1},
+		{givenMethod: "POST", givenURI: "/api/v1/jobs", givenBody: `{"name": "test3", "when": "@daily", "url": "http://test.org", "headers": {"X-Key": "{{secret \"db-password\"}}"}}`, wantCode: http.StatusCreated, wantSecrets: 1},
+	}
+
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  &cfg,
+			Storage: testStorageClient,
+			Cron:    schedule.NewDummyCron(&cfg, testStorageClient, 0, "OK"),
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, bytes.NewBufferString(test.givenBody))
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s %s: Expected response code '%d'. Got '%d' instead ", test.givenMethod, test.givenURI, test.wantCode, w.Code)
+		}
+		if len(testStorageClient.Secrets) != test.wantSecrets {
+			t.Errorf("%s %s: Expected '%d' secrets. Got '%d' instead ", test.givenMethod, test.givenURI, test.wantSecrets, len(testStorageClient.Secrets))
+		}
+
+		// The values are never returned
+		if strings.Contains(w.Body.String(), "s3cr3t") || strings.Contains(w.Body.String(), `"Value"`) {
+			t.Errorf("%s %s: Secret value returned: %s", test.givenMethod, test.givenURI, w.Body.String())
+		}
+	}
+
+	// The value is stored encrypted
+	if v, err := secret.NewStore(cfg.SecretsKey, testStorageClient).Value(job.DefaultNamespace, "api-token"); err != nil || v != "n3w" {
+		t.Errorf("Secret value should be updated; got: '%s', %v", v, err)
+	}
+}
+
+func TestNamespaceSecrets(t *testing.T) {
+	// Enable the secrets
+	cfg := *testConfig
+	kcfg := *testConfig.Khronos
+	kcfg.SecretsKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	cfg.Khronos = &kcfg
+
+	testStorageClient := storage.NewDummy()
+	testStorageClient.SaveNamespace(&job.Namespace{Name: "payments"})
+	testStorageClient.SaveNamespace(&job.Namespace{Name: "search"})
+
+	// Testing data, run in order
+	tests := []struct {
+		givenMethod string
+		givenURI    string
+		givenBody   string
+		wantCode    int
+	}{
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces/payments/secrets", givenBody: `{"name": "api-token", "value": "s3cr3t"}`, wantCode: http.StatusCreated},
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces/payments/secrets/api-token", wantCode: http.StatusOK},
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces/search/secrets/api-token", wantCode: http.StatusInternalServerError},
+		{givenMethod: "GET", givenURI: "/api/v1/secrets/api-token", wantCode: http.StatusInternalServerError},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces/search/jobs", givenBody: `{"name": "test1", "when": "@daily", "url": "http://test.org", "template": true, "headers": {"X-Key": "{{secret \"api-token\"}}"}}`, wantCode: http.StatusBadRequest},
+		{givenMethod: "POST", givenURI: "/api/v1/jobs", givenBody: `{"name": "test1", "when": "@daily", "url": "http://test.org", "template": true, "headers": {"X-Key": "{{secret \"api-token\"}}"}}`, wantCode: http.StatusBadRequest},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces/payments/jobs", givenBody: `{"name": "test1", "when": "@daily", "url": "http://test.org", "template": true, "headers": {"X-Key": "{{secret \"api-token\"}}"}}`, wantCode: http.StatusCreated},
+		// The same name on other namespace is other secret
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces/search/secrets", givenBody: `{"name": "api-token", "value": "other"}`, wantCode: http.StatusCreated},
+		{givenMethod: "DELETE", givenURI: "/api/v1/namespaces/search/secrets/api-token", wantCode: http.StatusNoContent},
+		{givenMethod: "DELETE", givenURI: "/api/v1/namespaces/payments/secrets/api-token", wantCode: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  &cfg,
+			Storage: testStorageClient,
+			Cron:    schedule.NewDummyCron(&cfg, testStorageClient, 0, "OK"),
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, bytes.NewBufferString(test.givenBody))
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s %s: Expected response code '%d'. Got '%d' instead: %s", test.givenMethod, test.givenURI, test.wantCode, w.Code, w.Body.String())
+		}
+	}
+
+	if v, err := secret.NewStore(cfg.SecretsKey, testStorageClient).Value("payments", "api-token"); err != nil || v != "s3cr3t" {
+		t.Errorf("Secret value of the namespace should be kept; got: '%s', %v", v, err)
+	}
+}
+
+func TestNamespaces(t *testing.T) {
+	testStorageClient := storage.NewDummy()
+	testStorageClient.Jobs = map[string]*job.Job{
+		"job:1": &job.Job{ID: 1, Name: "test1", When: "@daily", URL: &url.URL{}},
+	}
+	testStorageClient.JobCounter = 1
+
+	// Testing data, run in order
+	tests := []struct {
+		givenMethod    string
+		givenURI       string
+		givenBody      string
+		wantCode       int
+		wantNamespaces int
+		wantBody       string
+	}{
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces", wantCode: http.StatusOK, wantNamespaces: 1, wantBody: `"Name":"default"`},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces", givenBody: `{"name": "payments", "description": "Payments team"}`, wantCode: http.StatusCreated, wantNamespaces: 2},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces", givenBody: `{"name": "payments"}`, wantCode: http.StatusBadRequest, wantNamespaces: 2},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces", givenBody: `{"name": "Search Team"}`, wantCode: http.StatusBadRequest, wantNamespaces: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces/payments", wantCode: http.StatusOK, wantNamespaces: 2, wantBody: `"Description":"Payments team"`},
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces/missing", wantCode: http.StatusInternalServerError, wantNamespaces: 2},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces/payments/jobs", givenBody: `{"name": "test2", "when": "@daily", "url": "http://test.org"}`, wantCode: http.StatusCreated, wantNamespaces: 2, wantBody: `"Namespace":"payments"`},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces/missing/jobs", givenBody: `{"name": "test3", "when": "@daily", "url": "http://test.org"}`, wantCode: http.StatusInternalServerError, wantNamespaces: 2},
+		// The upstream jobs need to be on the same namespace
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces/payments/jobs", givenBody: `{"name": "test3", "url": "http://test.org", "dependsOn": [{"jobID": 1}]}`, wantCode: http.StatusBadRequest, wantNamespaces: 2},
+		// Every namespace only has access to its jobs
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces/payments/jobs", wantCode: http.StatusOK, wantNamespaces: 2, wantBody: `"Name":"test2"`},
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces/payments/jobs/2", wantCode: http.StatusOK, wantNamespaces: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces/payments/jobs/1", wantCode: http.StatusInternalServerError, wantNamespaces: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/jobs/2", wantCode: http.StatusInternalServerError, wantNamespaces: 2},
+		{givenMethod: "DELETE", givenURI: "/api/v1/jobs/2", wantCode: http.StatusNoContent, wantNamespaces: 2},
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces/default/jobs/1", wantCode: http.StatusOK, wantNamespaces: 2},
+		{givenMethod: "DELETE", givenURI: "/api/v1/namespaces/default", wantCode: http.StatusBadRequest, wantNamespaces: 2},
+		{givenMethod: "DELETE", givenURI: "/api/v1/namespaces/payments", wantCode: http.StatusBadRequest, wantNamespaces: 2},
+		{givenMethod: "DELETE", givenURI: "/api/v1/namespaces/payments/jobs/2", wantCode: http.StatusNoContent, wantNamespaces: 2},
+		{givenMethod: "DELETE", givenURI: "/api/v1/namespaces/payments", wantCode: http.StatusNoContent, wantNamespaces: 1},
+	}
+
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server (we don't need configuration for this service)
+		testServer.Register(&KhronosService{
+			Config:  testConfig,
+			Storage: testStorageClient,
+			Cron:    schedule.NewDummyCron(testConfig, testStorageClient, 0, "OK"),
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, bytes.NewBufferString(test.givenBody))
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s %s: Expected response code '%d'. Got '%d' instead ", test.givenMethod, test.givenURI, test.wantCode, w.Code)
+		}
+		if len(testStorageClient.Namespaces) != test.wantNamespaces {
+			t.Errorf("%s %s: Expected '%d' namespaces. Got '%d' instead ", test.givenMethod, test.givenURI, test.wantNamespaces, len(testStorageClient.Namespaces))
+		}
+		if !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("%s %s: Expected body with '%s'. Got '%s' instead ", test.givenMethod, test.givenURI, test.wantBody, w.Body.String())
+		}
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	cfg.APIDisableSecurity = false
+
+	testStorageClient := storage.NewDummy()
+	testStorageClient.Jobs = map[string]*job.Job{
+		"job:1": &job.Job{ID: 1, Name: "test1", When: "@daily", URL: &url.URL{Scheme: "http", Host: "test.org"}, Labels: map[string]string{"team": "payments"}},
+		"job:2": &job.Job{ID: 2, Name: "test2", When: "@daily", URL: &url.URL{}, Labels: map[string]string{"team": "search"}},
+	}
+	testStorageClient.JobCounter = 2
+	tokens := map[string]*job.Token{
+		"111111111": {ID: "1", Role: job.RoleViewer, Legacy: true},
+		"222222222": {ID: "2", Role: job.RoleOperator, Selector: "team=payments", Legacy: true},
+		"333333333": {ID: "3", Role: job.RoleAdmin, Legacy: true},
+	}
+	for secret, tk := range tokens {
+		tk.SetSecret(secret)
+		testStorageClient.SaveAuthenticationToken(job.DefaultNamespace, tk)
+	}
+
+	// Testing data, run in order
+	tests := []struct {
+		givenMethod string
+		givenURI    string
+		givenToken  string
+		givenBody   string
+		wantCode    int
+		wantBody    string
+	}{
+		{givenMethod: "GET", givenURI: "/api/v1/jobs", wantCode: http.StatusForbidden, wantBody: forbiddenTokenMsg},
+		{givenMethod: "GET", givenURI: "/api/v1/jobs", givenToken: "111111111", wantCode: http.StatusOK, wantBody: `"Name":"test2"`},
+		{givenMethod: "POST", givenURI: "/api/v1/jobs/1/pause", givenToken: "111111111", wantCode: http.StatusForbidden, wantBody: `"Token role 'viewer' doesn't have the 'operate' permission"`},
+		{givenMethod: "DELETE", givenURI: "/api/v1/secrets/api-token", givenToken: "222222222", wantCode: http.StatusForbidden, wantBody: `"Token role 'operator' doesn't have the 'admin' permission"`},
+		// The tokens limited by labels only access their jobs
+		{givenMethod: "GET", givenURI: "/api/v1/jobs", givenToken: "222222222", wantCode: http.StatusOK, wantBody: `"Name":"test1"`},
+		{givenMethod: "GET", givenURI: "/api/v1/jobs?selector=team%3Dsearch", givenToken: "222222222", wantCode: http.StatusOK, wantBody: "[]"},
+		{givenMethod: "GET", givenURI: "/api/v1/jobs/2", givenToken: "222222222", wantCode: http.StatusForbidden, wantBody: forbiddenJobMsg},
+		{givenMethod: "GET", givenURI: "/api/v1/jobs/2/results", givenToken: "222222222", wantCode: http.StatusForbidden, wantBody: forbiddenJobMsg},
+		{givenMethod: "POST", givenURI: "/api/v1/jobs/1/pause", givenToken: "222222222", wantCode: http.StatusOK},
+		{givenMethod: "POST", givenURI: "/api/v1/jobs", givenToken: "222222222", givenBody: `{"name": "test3", "when": "@daily", "url": "http://test.org", "labels": {"team": "search"}}`, wantCode: http.StatusForbidden, wantBody: forbiddenJobLabelsMsg},
+		{givenMethod: "POST", givenURI: "/api/v1/jobs", givenToken: "222222222", givenBody: `{"name": "test3", "when": "@daily", "url": "http://test.org", "labels": {"team": "payments"}}`, wantCode: http.StatusCreated},
+		{givenMethod: "PATCH", givenURI: "/api/v1/jobs/1", givenToken: "222222222", givenBody: `{"labels": {"team": "search"}}`, wantCode: http.StatusForbidden, wantBody: forbiddenJobLabelsMsg},
+		{givenMethod: "DELETE", givenURI: "/api/v1/jobs/2", givenToken: "333333333", wantCode: http.StatusNoContent},
+		{givenMethod: "POST", givenURI: "/api/v1/namespaces", givenToken: "333333333", givenBody: `{"name": "payments"}`, wantCode: http.StatusCreated},
+		// The namespaces out of the token scope can't be managed
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces/payments", givenToken: "333333333", wantCode: http.StatusForbidden, wantBody: "Token can't access the namespace 'payments'"},
+		{givenMethod: "DELETE", givenURI: "/api/v1/namespaces/payments", givenToken: "333333333", wantCode: http.StatusForbidden, wantBody: "Token can't access the namespace 'payments'"},
+		{givenMethod: "GET", givenURI: "/api/v1/namespaces", givenToken: "333333333", wantCode: http.StatusOK, wantBody: `"Name":"payments"`},
+	}
+
+	for _, test := range tests {
+		// Create a testing server
+		testServer := server.NewSimpleServer(nil)
+
+		// Register our service on the server
+		testServer.Register(&KhronosService{
+			Config:  cfg,
+			Storage: testStorageClient,
+			Cron:    schedule.NewDummyCron(cfg, testStorageClient, 0, "OK"),
+		})
+
+		// Create request and a test recorder
+		r, _ := http.NewRequest(test.givenMethod, test.givenURI, bytes.NewBufferString(test.givenBody))
+		if test.givenToken != "" {
+			r.Header.Add("Authorization", "Bearer "+test.givenToken)
+		}
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		if w.Code != test.wantCode {
+			t.Errorf("%s %s: Expected response code '%d'. Got '%d' instead ", test.givenMethod, test.givenURI, test.wantCode, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("%s %s: Expected body with '%s'. Got '%s' instead ", test.givenMethod, test.givenURI, test.wantBody, w.Body.String())
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	cfg := config.NewAppConfig(os.Getenv(config.KhronosConfigFileKey))
+	cfg.APIDisableSecurity = false
+
+	testStorageClient := storage.NewDummy()
+	testStorageClient.SaveNamespace(&job.Namespace{Name: "payments"})
+	admin := &job.Token{ID: "1", Name: "admin", Role: job.RoleAdmin, Legacy: true}
+	admin.SetSecret("111111111")
+	scoped := &job.Token{ID: "2", Name: "scoped", Role: job.RoleAdmin, Namespaces: []string{"payments"}, Selector: "team=payments", Legacy: true}
+	scoped.SetSecret("222222222")
+	testStorageClient.SaveAuthenticationToken(job.DefaultNamespace, admin)
+	testStorageClient.SaveAuthenticationToken(job.DefaultNamespace, scoped)
+
+	// Create a testing server
+	testServer := server.NewSimpleServer(nil)
+	testServer.Register(&KhronosService{
+		Config:  cfg,
+		Storage: testStorageClient,
+		Cron:    schedule.NewDummyCron(cfg, testStorageClient, 0, "OK"),
+	})
+	do := func(method, uri, token, body string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest(method, uri, bytes.NewBufferString(body))
+		r.Header.Add("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		testServer.ServeHTTP(w, r)
+		return w
+	}
+
+	// Create a token, the secret is only returned now
+	w := do("POST", "/api/v1/tokens", "111111111", `{"name": "ci", "role": "viewer"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Token should be created; got '%d': %s", w.Code, w.Body.String())
+	}
+	created := struct {
+		ID     string
+		Hash   []byte
+		Secret string
+	}{}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Secret == "" || len(created.Hash) > 0 || job.TokenID(created.Secret) != created.ID {
+		t.Errorf("Created token should have the secret without the hash; got %s", w.Body.String())
+	}
+	if tk := testStorageClient.Tokens["namespace:default:token:"+created.ID]; tk == nil || !tk.Verify(created.Secret) || tk.CreatedAt.IsZero() {
+		t.Errorf("Token should be stored with the hash of the secret; got %#v", tk)
+	}
+
+	// The new token authenticates with its role and its use is tracked
+	if w := do("GET", "/api/v1/jobs", created.Secret, ""); w.Code != http.StatusOK {
+		t.Errorf("Token should be valid; got '%d'", w.Code)
+	}
+	if tk := testStorageClient.Tokens["namespace:default:token:"+created.ID]; tk.LastUsedAt == nil {
+		t.Error("Token last use should be set")
+	}
+	if w := do("GET", "/api/v1/tokens", created.Secret, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Viewer token shouldn't list tokens; got '%d'", w.Code)
+	}
+
+	// List the tokens without their hashes
+	w = do("GET", "/api/v1/tokens", "111111111", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"Name":"ci"`) || strings.Contains(w.Body.String(), "Hash") || strings.Contains(w.Body.String(), created.Secret) {
+		t.Errorf("Tokens should be listed without secrets; got '%d': %s", w.Code, w.Body.String())
+	}
+
+	// Not valid tokens
+	tests := []struct {
+		givenURI   string
+		givenToken string
+		givenBody  string
+		wantCode   int
+		wantBody   string
+	}{
+		{givenURI: "/api/v1/tokens", givenToken: "111111111", givenBody: `{"name": "ci", "role": "viewer"}`, wantCode: http.StatusBadRequest, wantBody: "Token ci already exists"},
+		{givenURI: "/api/v1/tokens", givenToken: "111111111", givenBody: `{"name": "deploy", "role": "root"}`, wantCode: http.StatusBadRequest, wantBody: "Role is not a valid role"},
+		{givenURI: "/api/v1/namespaces/payments/tokens", givenToken: "222222222", givenBody: `{"name": "deploy", "role": "viewer", "namespaces": ["search"]}`, wantCode: http.StatusBadRequest, wantBody: "Namespaces are only valid on the tokens of the default namespace"},
+		{givenURI: "/api/v1/tokens", givenToken: "222222222", givenBody: `{"name": "deploy", "role": "viewer", "namespaces": ["*"]}`, wantCode: http.StatusForbidden, wantBody: "Token can't give access to the namespace '*'"},
+	}
+	for _, test := range tests {
+		w := do("POST", test.givenURI, test.givenToken, test.givenBody)
+		if w.Code != test.wantCode || !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("POST %s: Expected '%d' with '%s'. Got '%d' with '%s' instead ", test.givenURI, test.wantCode, test.wantBody, w.Code, w.Body.String())
+		}
+	}
+
+	// The tokens limited by labels create tokens limited by the same labels
+	w = do("POST", "/api/v1/namespaces/payments/tokens", "222222222", `{"name": "deploy", "role": "operator", "selector": "env=prod"}`)
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), `"Selector":"team=payments,env=prod"`) {
+		t.Errorf("Token should be created with the selector of its creator; got '%d': %s", w.Code, w.Body.String())
+	}
+
+	// The expired tokens aren't valid
+	expired := time.Now().Add(-time.Minute)
+	testStorageClient.Tokens["namespace:default:token:"+created.ID].ExpiresAt = &expired
+	if w := do("GET", "/api/v1/jobs", created.Secret, ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expired token shouldn't be valid; got '%d'", w.Code)
+	}
+
+	// Revoke the token
+	if w := do("DELETE", "/api/v1/tokens/"+created.ID, "111111111", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Token should be revoked; got '%d'", w.Code)
+	}
+	if testStorageClient.AuthenticationTokenExists(job.DefaultNamespace, created.ID) {
+		t.Error("Revoked token shouldn't be present")
+	}
+	if w := do("DELETE", "/api/v1/tokens/"+created.ID, "111111111", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Missing token should be revoked; got '%d'", w.Code)
+	}
+}
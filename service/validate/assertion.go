@@ -0,0 +1,106 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/slok/khronos/job"
+)
+
+const (
+	notValidStatusRange = "Invalid status code range"
+)
+
+// AssertionValidator implements the requirements of a validator in order to
+// be able to create correct job response assertions
+type AssertionValidator struct {
+	Type  string `json:"type"`
+	Path  string `json:"path"`
+	Value string `json:"value"`
+}
+
+// Validate validates the assertion and returns all the errors found
+func (v *AssertionValidator) Validate() []error {
+	errs := []error{}
+
+	switch v.Type {
+	case job.AssertionRegex:
+		if _, err := regexp.Compile(v.Value); err != nil {
+			errs = append(errs, errors.New("Assertion value is not a valid regex"))
+		}
+	case job.AssertionJSONPath:
+		if _, err := job.ParseJSONPath(v.Path); err != nil {
+			errs = append(errs, errors.New("Assertion path is not a valid JSONPath"))
+		}
+	case job.AssertionContains:
+		if v.Value == "" {
+			errs = append(errs, errors.New("Assertion value is required"))
+		}
+	default:
+		errs = append(errs, errors.New("Assertion type is not valid"))
+	}
+
+	return errs
+}
+
+// Instance returns a valid assertion instance
+func (v *AssertionValidator) Instance() (*job.Assertion, error) {
+	if errs := v.Validate(); len(errs) > 0 {
+		return nil, errors.New("Not valid assertion")
+	}
+
+	return &job.Assertion{
+		Type:  v.Type,
+		Path:  v.Path,
+		Value: v.Value,
+	}, nil
+}
+
+// ParseStatusRange parses a range of HTTP status codes, the range can be a single
+// code (200), a range (200-204) or a class (2xx)
+func ParseStatusRange(value string) (job.StatusRange, error) {
+	sr := job.StatusRange{}
+	var err error
+
+	switch {
+	// Class
+	case len(value) == 3 && strings.HasSuffix(strings.ToLower(value), "xx"):
+		c, err := strconv.Atoi(value[:1])
+		if err != nil {
+			return sr, errors.New(notValidStatusRange)
+		}
+		sr.From, sr.To = c*100, c*100+99
+	// Range
+	case strings.Contains(value, "-"):
+		l := strings.SplitN(value, "-", 2)
+		if sr.From, err = strconv.Atoi(l[0]); err != nil {
+			return sr, errors.New(notValidStatusRange)
+		}
+		if sr.To, err = strconv.Atoi(l[1]); err != nil {
+			return sr, errors.New(notValidStatusRange)
+		}
+	// Single
+	default:
+		if sr.From, err = strconv.Atoi(value); err != nil {
+			return sr, errors.New(notValidStatusRange)
+		}
+		sr.To = sr.From
+	}
+
+	if sr.From < 100 || sr.To > 599 || sr.From > sr.To {
+		return sr, errors.New(notValidStatusRange)
+	}
+	return sr, nil
+}
+
+// formatStatusRange returns the representation of a status code range that
+// ParseStatusRange understands
+func formatStatusRange(sr job.StatusRange) string {
+	if sr.From == sr.To {
+		return strconv.Itoa(sr.From)
+	}
+	return fmt.Sprintf("%d-%d", sr.From, sr.To)
+}
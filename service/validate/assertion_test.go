@@ -0,0 +1,86 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestParseStatusRange(t *testing.T) {
+	tests := []struct {
+		givenRange string
+		wantRange  job.StatusRange
+		wantError  bool
+	}{
+		{givenRange: "200", wantRange: job.StatusRange{From: 200, To: 200}},
+		{givenRange: "200-204", wantRange: job.StatusRange{From: 200, To: 204}},
+		{givenRange: "2xx", wantRange: job.StatusRange{From: 200, To: 299}},
+		{givenRange: "5XX", wantRange: job.StatusRange{From: 500, To: 599}},
+		{givenRange: "204-200", wantError: true},
+		{givenRange: "99", wantError: true},
+		{givenRange: "600", wantError: true},
+		{givenRange: "axx", wantError: true},
+		{givenRange: "ok", wantError: true},
+	}
+
+	for _, test := range tests {
+		r, err := ParseStatusRange(test.givenRange)
+		if test.wantError {
+			if err == nil {
+				t.Errorf("'%s' should raise error", test.givenRange)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("'%s' should not raise error", test.givenRange)
+		}
+		if r != test.wantRange {
+			t.Errorf("'%s' range should be %v; got %v", test.givenRange, test.wantRange, r)
+		}
+	}
+}
+
+func TestAssertionValidatorValidation(t *testing.T) {
+	tests := []struct {
+		givenValidator *AssertionValidator
+		wantErrors     []error
+	}{
+		{
+			givenValidator: &AssertionValidator{Type: "regex", Value: `"status": "\w+"`},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &AssertionValidator{Type: "jsonpath", Path: "$.items[0].status", Value: "ok"},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &AssertionValidator{Type: "contains", Value: "ok"},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &AssertionValidator{Type: "regex", Value: `(`},
+			wantErrors:     []error{errors.New("Assertion value is not a valid regex")},
+		},
+		{
+			givenValidator: &AssertionValidator{Type: "jsonpath", Path: "status", Value: "ok"},
+			wantErrors:     []error{errors.New("Assertion path is not a valid JSONPath")},
+		},
+		{
+			givenValidator: &AssertionValidator{Type: "contains"},
+			wantErrors:     []error{errors.New("Assertion value is required")},
+		},
+		{
+			givenValidator: &AssertionValidator{Type: "equals", Value: "ok"},
+			wantErrors:     []error{errors.New("Assertion type is not valid")},
+		},
+	}
+
+	for _, test := range tests {
+		errs := test.givenValidator.Validate()
+		if !reflect.DeepEqual(errs, test.wantErrors) {
+			t.Errorf("Errors are not equal; expected %v; got %v", test.wantErrors, errs)
+		}
+	}
+}
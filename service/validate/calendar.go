@@ -0,0 +1,246 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+const (
+	notValidCalendarMode = "Invalid calendar mode"
+	notValidWindowTime   = "Invalid window time"
+
+	// windowDateFmt is the format of the whole day windows
+	windowDateFmt = "2006-01-02"
+	// windowLocalFmt is the format of the window times without offset
+	windowLocalFmt = "2006-01-02T15:04:05"
+)
+
+// validCalendarModes are the ways a calendar can apply to a job
+var validCalendarModes = []string{job.CalendarExclude, job.CalendarInclude}
+
+// CalendarValidator implements the requirements of a validator in order to
+// be able to create correct calendars
+type CalendarValidator struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	TimeZone    string             `json:"timeZone"`
+	Windows     []*WindowValidator `json:"windows"`
+
+	// Errors after validating the instance
+	Errors []error
+}
+
+// WindowValidator implements the requirements of a validator in order to be
+// able to create correct calendar windows. Start and end are RFC3339 times,
+// times without offset or dates (whole days, end included) on the calendar
+// time zone
+type WindowValidator struct {
+	Name  string `json:"name"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// NewCalendarValidatorFromJSON creates a validator from a json
+func NewCalendarValidatorFromJSON(j string) (v *CalendarValidator, err error) {
+	v = &CalendarValidator{}
+	err = json.Unmarshal([]byte(j), v)
+	logrus.Debug("Created calendar validator from json")
+	return
+}
+
+// Validate validates the calendar and returns an error if not valid
+func (v *CalendarValidator) Validate() error {
+	// Flush previous errors
+	v.Errors = []error{}
+
+	if v.Name == "" {
+		v.Errors = append(v.Errors, errors.New("Name is required"))
+	}
+	if v.TimeZone != "" {
+		if err := ValidTimeZone(v.TimeZone); err != nil {
+			v.Errors = append(v.Errors, errors.New("Time zone is not a valid tz database time zone"))
+		}
+	}
+	for _, w := range v.Windows {
+		v.Errors = append(v.Errors, w.Validate()...)
+	}
+
+	if len(v.Errors) > 0 {
+		return errors.New("Not valid calendar")
+	}
+	return nil
+}
+
+// Instance returns a valid calendar instance, the windows are evaluated on the
+// calendar time zone or the server local time if not set
+func (v *CalendarValidator) Instance() (*job.Calendar, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	loc := time.Local
+	if v.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(v.TimeZone); err != nil {
+			return nil, err
+		}
+	}
+
+	ws := []*job.Window{}
+	for _, w := range v.Windows {
+		wi, err := w.Instance(loc)
+		if err != nil {
+			return nil, err
+		}
+		ws = append(ws, wi)
+	}
+
+	return &job.Calendar{
+		Name:        v.Name,
+		Description: v.Description,
+		TimeZone:    v.TimeZone,
+		Windows:     ws,
+	}, nil
+}
+
+// Validate validates the window and returns all the errors found
+func (v *WindowValidator) Validate() []error {
+	errs := []error{}
+
+	var start, end time.Time
+	var startDate, endDate bool
+	var startErr, endErr error
+	if v.Start == "" {
+		errs = append(errs, errors.New("Window start is required"))
+	} else if start, startDate, startErr = parseWindowTime(v.Start, time.UTC); startErr != nil {
+		errs = append(errs, errors.New("Window start is not a valid time or date"))
+	}
+	if v.End == "" {
+		// Whole day windows can be a single day
+		if !startDate {
+			errs = append(errs, errors.New("Window end is required"))
+		}
+		return errs
+	} else if end, endDate, endErr = parseWindowTime(v.End, time.UTC); endErr != nil {
+		errs = append(errs, errors.New("Window end is not a valid time or date"))
+	}
+
+	if v.Start == "" || startErr != nil || endErr != nil {
+		return errs
+	}
+	switch {
+	case startDate != endDate:
+		errs = append(errs, errors.New("Window start and end should be both dates or both times"))
+	case startDate && end.Before(start):
+		errs = append(errs, errors.New("Window end should not be before start"))
+	case !startDate && !end.After(start):
+		errs = append(errs, errors.New("Window end should be after start"))
+	}
+
+	return errs
+}
+
+// Instance returns a valid window instance evaluated on a location
+func (v *WindowValidator) Instance(loc *time.Location) (*job.Window, error) {
+	if errs := v.Validate(); len(errs) > 0 {
+		return nil, errors.New("Not valid window")
+	}
+
+	start, date, _ := parseWindowTime(v.Start, loc)
+	end := start
+	if v.End != "" {
+		end, _, _ = parseWindowTime(v.End, loc)
+	}
+	// The end day of whole day windows is included
+	if date {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	return &job.Window{Name: v.Name, Start: start, End: end}, nil
+}
+
+// parseWindowTime parses a window time on a location, date flag is up when
+// the value is a date without time
+func parseWindowTime(value string, loc *time.Location) (t time.Time, date bool, err error) {
+	if t, err = time.Parse(time.RFC3339, value); err == nil {
+		return
+	}
+	if t, err = time.ParseInLocation(windowLocalFmt, value, loc); err == nil {
+		return
+	}
+	if t, err = time.ParseInLocation(windowDateFmt, value, loc); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, errors.New(notValidWindowTime)
+}
+
+// CalendarRuleValidator implements the requirements of a validator in order
+// to be able to create correct job calendar references
+type CalendarRuleValidator struct {
+	Calendar int    `json:"calendar"`
+	Mode     string `json:"mode"`
+}
+
+// Validate validates the calendar reference and returns all the errors found
+func (v *CalendarRuleValidator) Validate() []error {
+	errs := []error{}
+
+	if v.Calendar < 1 {
+		errs = append(errs, errors.New("Calendar is required"))
+	}
+	if v.Mode != "" {
+		if err := ValidCalendarMode(v.Mode); err != nil {
+			errs = append(errs, errors.New("Calendar mode is not a valid calendar mode"))
+		}
+	}
+
+	return errs
+}
+
+// Instance returns a valid calendar reference with the defaults applied
+func (v *CalendarRuleValidator) Instance() (*job.CalendarRule, error) {
+	if errs := v.Validate(); len(errs) > 0 {
+		return nil, errors.New("Not valid calendar rule")
+	}
+
+	// Exclude the calendar windows by default
+	m := v.Mode
+	if m == "" {
+		m = job.CalendarExclude
+	}
+
+	return &job.CalendarRule{CalendarID: v.Calendar, Mode: m}, nil
+}
+
+// ValidCalendarMode checks if the value is a valid way of applying a calendar
+func ValidCalendarMode(value string) error {
+	for _, v := range validCalendarModes {
+		if v == value {
+			return nil
+		}
+	}
+	return errors.New(notValidCalendarMode)
+}
+
+// ValidCalendars checks that the calendars referenced by a job exist
+func ValidCalendars(j *job.Job, cals []*job.Calendar) []error {
+	errs := []error{}
+
+	ids := map[int]bool{}
+	for _, cal := range cals {
+		ids[cal.ID] = true
+	}
+	for _, cr := range j.Calendars {
+		if !ids[cr.CalendarID] {
+			errs = append(errs, fmt.Errorf("Calendar %d doesn't exist", cr.CalendarID))
+		}
+	}
+
+	return errs
+}
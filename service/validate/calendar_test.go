@@ -0,0 +1,138 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestCalendarValidatorValidation(t *testing.T) {
+	tests := []struct {
+		givenValidator *CalendarValidator
+		wantErrors     []error
+	}{
+		{
+			givenValidator: &CalendarValidator{
+				Name:     "holidays",
+				TimeZone: "Europe/Madrid",
+				Windows: []*WindowValidator{
+					{Name: "christmas", Start: "2017-12-25"},
+					{Name: "new year", Start: "2017-12-31", End: "2018-01-01"},
+					{Name: "freeze", Start: "2017-12-28T09:00:00", End: "2017-12-28T18:00:00"},
+					{Name: "migration", Start: "2017-12-29T09:00:00Z", End: "2017-12-29T11:00:00+01:00"},
+				},
+			},
+			wantErrors: []error{},
+		},
+		{
+			givenValidator: &CalendarValidator{
+				TimeZone: "Mars/Olympus",
+				Windows: []*WindowValidator{
+					{},
+					{Start: "2017-12-28T09:00:00"},
+					{Start: "25/12/2017"},
+					{Start: "2017-12-25", End: "2017-12-28T18:00:00"},
+					{Start: "2017-12-25", End: "2017-12-24"},
+					{Start: "2017-12-28T18:00:00", End: "2017-12-28T09:00:00"},
+				},
+			},
+			wantErrors: []error{
+				errors.New("Name is required"),
+				errors.New("Time zone is not a valid tz database time zone"),
+				errors.New("Window start is required"),
+				errors.New("Window end is required"),
+				errors.New("Window end is required"),
+				errors.New("Window start is not a valid time or date"),
+				errors.New("Window end is required"),
+				errors.New("Window start and end should be both dates or both times"),
+				errors.New("Window end should not be before start"),
+				errors.New("Window end should be after start"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test.givenValidator.Validate()
+		if !reflect.DeepEqual(test.givenValidator.Errors, test.wantErrors) {
+			t.Errorf("Errors are not equal; expected %v; got %v", test.wantErrors, test.givenValidator.Errors)
+		}
+	}
+}
+
+func TestCalendarValidatorInstance(t *testing.T) {
+	madrid, _ := time.LoadLocation("Europe/Madrid")
+	v := &CalendarValidator{
+		Name:     "holidays",
+		TimeZone: "Europe/Madrid",
+		Windows: []*WindowValidator{
+			{Name: "christmas", Start: "2017-12-25"},
+			{Name: "new year", Start: "2017-12-31", End: "2018-01-01"},
+			{Name: "freeze", Start: "2017-12-28T09:00:00", End: "2017-12-28T18:00:00"},
+			{Name: "migration", Start: "2017-12-29T09:00:00Z", End: "2017-12-29T10:00:00Z"},
+		},
+	}
+	wantWindows := []*job.Window{
+		{Name: "christmas", Start: time.Date(2017, time.December, 25, 0, 0, 0, 0, madrid), End: time.Date(2017, time.December, 26, 0, 0, 0, 0, madrid)},
+		{Name: "new year", Start: time.Date(2017, time.December, 31, 0, 0, 0, 0, madrid), End: time.Date(2018, time.January, 2, 0, 0, 0, 0, madrid)},
+		{Name: "freeze", Start: time.Date(2017, time.December, 28, 9, 0, 0, 0, madrid), End: time.Date(2017, time.December, 28, 18, 0, 0, 0, madrid)},
+		{Name: "migration", Start: time.Date(2017, time.December, 29, 9, 0, 0, 0, time.UTC), End: time.Date(2017, time.December, 29, 10, 0, 0, 0, time.UTC)},
+	}
+
+	cal, err := v.Instance()
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if cal.Name != v.Name || cal.TimeZone != v.TimeZone || len(cal.Windows) != len(wantWindows) {
+		t.Fatalf("Wrong calendar: %#v", cal)
+	}
+	for i, w := range cal.Windows {
+		want := wantWindows[i]
+		if w.Name != want.Name || !w.Start.Equal(want.Start) || !w.End.Equal(want.End) {
+			t.Errorf("Window should be %v; got %v", want, w)
+		}
+	}
+}
+
+func TestCalendarRuleValidatorInstance(t *testing.T) {
+	tests := []struct {
+		givenValidator *CalendarRuleValidator
+		wantRule       *job.CalendarRule
+		wantError      bool
+	}{
+		{givenValidator: &CalendarRuleValidator{Calendar: 1}, wantRule: &job.CalendarRule{CalendarID: 1, Mode: job.CalendarExclude}},
+		{givenValidator: &CalendarRuleValidator{Calendar: 2, Mode: "include"}, wantRule: &job.CalendarRule{CalendarID: 2, Mode: job.CalendarInclude}},
+		{givenValidator: &CalendarRuleValidator{Mode: "include"}, wantError: true},
+		{givenValidator: &CalendarRuleValidator{Calendar: 1, Mode: "skip"}, wantError: true},
+	}
+
+	for _, test := range tests {
+		cr, err := test.givenValidator.Instance()
+		if test.wantError {
+			if err == nil {
+				t.Errorf("Expected error on %#v", test.givenValidator)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Didn't expect error: %v", err)
+			continue
+		}
+		if !reflect.DeepEqual(cr, test.wantRule) {
+			t.Errorf("Calendar rule should be %#v; got %#v", test.wantRule, cr)
+		}
+	}
+}
+
+func TestValidCalendars(t *testing.T) {
+	cals := []*job.Calendar{{ID: 1}, {ID: 3}}
+	j := &job.Job{Calendars: []*job.CalendarRule{{CalendarID: 1}, {CalendarID: 2}, {CalendarID: 3}}}
+
+	errs := ValidCalendars(j, cals)
+	wantErrors := []error{errors.New("Calendar 2 doesn't exist")}
+	if !reflect.DeepEqual(errs, wantErrors) {
+		t.Errorf("Errors are not equal; expected %v; got %v", wantErrors, errs)
+	}
+}
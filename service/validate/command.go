@@ -0,0 +1,47 @@
+package validate
+
+import (
+	"errors"
+
+	"github.com/slok/khronos/job"
+)
+
+// CommandValidator implements the requirements of a validator in order to
+// be able to create correct job commands
+type CommandValidator struct {
+	Path string            `json:"path"`
+	Args []string          `json:"args"`
+	Env  map[string]string `json:"env"`
+	Dir  string            `json:"dir"`
+}
+
+// Validate validates the command and returns all the errors found
+func (v *CommandValidator) Validate() []error {
+	errs := []error{}
+
+	if v.Path == "" {
+		errs = append(errs, errors.New("Command path is required"))
+	}
+	for k := range v.Env {
+		if k == "" {
+			errs = append(errs, errors.New("Command env can't have an empty name"))
+			break
+		}
+	}
+
+	return errs
+}
+
+// Instance returns a valid command instance
+func (v *CommandValidator) Instance() (*job.Command, error) {
+	if errs := v.Validate(); len(errs) > 0 {
+		return nil, errors.New("Not valid command")
+	}
+
+	return &job.Command{
+		Path: v.Path,
+		Args: v.Args,
+		Env:  v.Env,
+		Dir:  v.Dir,
+	}, nil
+}
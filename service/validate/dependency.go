@@ -0,0 +1,106 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/slok/khronos/job"
+)
+
+const (
+	notValidTrigger = "Invalid dependency trigger"
+)
+
+// validTriggers are the conditions of an upstream job result that trigger a job
+var validTriggers = []string{job.TriggerSuccess, job.TriggerFailure, job.TriggerAlways}
+
+// DependencyValidator implements the requirements of a validator in order to
+// be able to create correct job dependencies
+type DependencyValidator struct {
+	Job int    `json:"job"`
+	On  string `json:"on"`
+}
+
+// Validate validates the dependency and returns all the errors found
+func (v *DependencyValidator) Validate() []error {
+	errs := []error{}
+
+	if v.Job < 1 {
+		errs = append(errs, errors.New("Dependency job is required"))
+	}
+	if v.On != "" {
+		if err := ValidTrigger(v.On); err != nil {
+			errs = append(errs, errors.New("Dependency on is not a valid trigger"))
+		}
+	}
+
+	return errs
+}
+
+// Instance returns a valid dependency instance with the defaults applied
+func (v *DependencyValidator) Instance() (*job.Dependency, error) {
+	if errs := v.Validate(); len(errs) > 0 {
+		return nil, errors.New("Not valid dependency")
+	}
+
+	// Trigger on success by default
+	on := v.On
+	if on == "" {
+		on = job.TriggerSuccess
+	}
+
+	return &job.Dependency{JobID: v.Job, On: on}, nil
+}
+
+// ValidTrigger checks if the value is a valid upstream job result condition
+func ValidTrigger(value string) error {
+	for _, v := range validTriggers {
+		if v == value {
+			return nil
+		}
+	}
+	return errors.New(notValidTrigger)
+}
+
+// ValidDependencies checks the dependencies of a job against the stored jobs,
+// the upstream jobs need to exist and the dependencies can't create a cycle
+func ValidDependencies(j *job.Job, jobs []*job.Job) []error {
+	errs := []error{}
+
+	// Upstream jobs by job, the checked job replaces its stored version
+	upstreams := map[int][]*job.Dependency{}
+	for _, sj := range jobs {
+		upstreams[sj.ID] = sj.DependsOn
+	}
+	upstreams[j.ID] = j.DependsOn
+
+	for _, d := range j.DependsOn {
+		if _, ok := upstreams[d.JobID]; !ok {
+			errs = append(errs, fmt.Errorf("Dependency job %d doesn't exist", d.JobID))
+		}
+	}
+
+	// Look for the job going up from its upstream jobs
+	visited := map[int]bool{}
+	pending := []int{}
+	for _, d := range j.DependsOn {
+		pending = append(pending, d.JobID)
+	}
+	for len(pending) > 0 {
+		id := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if id == j.ID {
+			errs = append(errs, errors.New("Dependencies can't create a cycle"))
+			break
+		}
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		for _, d := range upstreams[id] {
+			pending = append(pending, d.JobID)
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,107 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestDependencyValidatorInstance(t *testing.T) {
+	tests := []struct {
+		givenValidator *DependencyValidator
+		wantDependency *job.Dependency
+	}{
+		{
+			givenValidator: &DependencyValidator{Job: 1},
+			wantDependency: &job.Dependency{JobID: 1, On: job.TriggerSuccess},
+		},
+		{
+			givenValidator: &DependencyValidator{Job: 2, On: "always"},
+			wantDependency: &job.Dependency{JobID: 2, On: job.TriggerAlways},
+		},
+	}
+
+	for _, test := range tests {
+		d, err := test.givenValidator.Instance()
+		if err != nil {
+			t.Errorf("Didn't expect error: %v", err)
+			continue
+		}
+		if !reflect.DeepEqual(d, test.wantDependency) {
+			t.Errorf("Dependency should be %#v; got %#v", test.wantDependency, d)
+		}
+	}
+}
+
+func TestValidTrigger(t *testing.T) {
+	tests := []struct {
+		givenTrigger string
+		wantError    bool
+	}{
+		{givenTrigger: "success", wantError: false},
+		{givenTrigger: "failure", wantError: false},
+		{givenTrigger: "always", wantError: false},
+		{givenTrigger: "", wantError: true},
+		{givenTrigger: "Success", wantError: true},
+		{givenTrigger: "never", wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidTrigger(test.givenTrigger)
+		if !test.wantError && err != nil {
+			t.Errorf("Didn't expect error on %s", test.givenTrigger)
+		} else if test.wantError && err == nil {
+			t.Errorf("Expected error on %s", test.givenTrigger)
+		}
+	}
+}
+
+func TestValidDependencies(t *testing.T) {
+	// 1 <- 2 <- 3
+	jobs := []*job.Job{
+		{ID: 1},
+		{ID: 2, DependsOn: []*job.Dependency{{JobID: 1}}},
+		{ID: 3, DependsOn: []*job.Dependency{{JobID: 2}}},
+	}
+
+	tests := []struct {
+		name       string
+		givenJob   *job.Job
+		wantErrors []error
+	}{
+		{
+			name:       "new job",
+			givenJob:   &job.Job{DependsOn: []*job.Dependency{{JobID: 1}, {JobID: 3}}},
+			wantErrors: []error{},
+		},
+		{
+			name:       "missing upstream",
+			givenJob:   &job.Job{DependsOn: []*job.Dependency{{JobID: 1}, {JobID: 9}}},
+			wantErrors: []error{errors.New("Dependency job 9 doesn't exist")},
+		},
+		{
+			name:       "updated job",
+			givenJob:   &job.Job{ID: 3, DependsOn: []*job.Dependency{{JobID: 1}}},
+			wantErrors: []error{},
+		},
+		{
+			name:       "self dependency",
+			givenJob:   &job.Job{ID: 2, DependsOn: []*job.Dependency{{JobID: 2}}},
+			wantErrors: []error{errors.New("Dependencies can't create a cycle")},
+		},
+		{
+			name:       "cycle",
+			givenJob:   &job.Job{ID: 1, DependsOn: []*job.Dependency{{JobID: 3}}},
+			wantErrors: []error{errors.New("Dependencies can't create a cycle")},
+		},
+	}
+
+	for _, test := range tests {
+		errs := ValidDependencies(test.givenJob, jobs)
+		if !reflect.DeepEqual(errs, test.wantErrors) {
+			t.Errorf("%s: Errors are not equal; expected %v; got %v", test.name, test.wantErrors, errs)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+const notValidHost = "Invalid host"
+
+// HostLimitValidator implements the requirements of a validator in order to
+// be able to create correct host limits, zero values mean no limit
+type HostLimitValidator struct {
+	Host           string  `json:"host"`
+	Rate           float64 `json:"rate"`
+	Burst          int     `json:"burst"`
+	MaxConcurrency int     `json:"maxConcurrency"`
+
+	// Errors after validating the instance
+	Errors []error
+}
+
+// NewHostLimitValidatorFromJSON creates a validator from a json
+func NewHostLimitValidatorFromJSON(j string) (v *HostLimitValidator, err error) {
+	v = &HostLimitValidator{}
+	err = json.Unmarshal([]byte(j), v)
+	logrus.Debug("Created host limit validator from json")
+	return
+}
+
+// Validate validates the host limit and returns an error if not valid
+func (v *HostLimitValidator) Validate() error {
+	// Flush previous errors
+	v.Errors = []error{}
+
+	if v.Host == "" {
+		v.Errors = append(v.Errors, errors.New("Host is required"))
+	} else if err := ValidHost(v.Host); err != nil {
+		v.Errors = append(v.Errors, errors.New("Host is not a valid host"))
+	}
+	if v.Rate < 0 {
+		v.Errors = append(v.Errors, errors.New("Rate should not be negative"))
+	}
+	if v.Burst < 0 {
+		v.Errors = append(v.Errors, errors.New("Burst should not be negative"))
+	}
+	if v.MaxConcurrency < 0 {
+		v.Errors = append(v.Errors, errors.New("Max concurrency should not be negative"))
+	}
+
+	if len(v.Errors) > 0 {
+		return errors.New("Not valid host limit")
+	}
+	return nil
+}
+
+// Instance returns a valid host limit instance
+func (v *HostLimitValidator) Instance() (*job.HostLimit, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &job.HostLimit{
+		Host:           strings.ToLower(v.Host),
+		Rate:           v.Rate,
+		Burst:          v.Burst,
+		MaxConcurrency: v.MaxConcurrency,
+	}, nil
+}
+
+// ValidHost checks if the value is a valid host of a job URL, with an optional
+// port (e.g example.org, example.org:8080)
+func ValidHost(value string) error {
+	u, err := url.Parse("//" + value)
+	if err != nil || u.Host != value || u.Hostname() == "" {
+		return errors.New(notValidHost)
+	}
+	return nil
+}
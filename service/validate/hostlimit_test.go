@@ -0,0 +1,61 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestHostLimitValidatorValidation(t *testing.T) {
+	tests := []struct {
+		givenValidator *HostLimitValidator
+		wantErrors     []error
+	}{
+		{
+			givenValidator: &HostLimitValidator{Host: "api.example.org", Rate: 0.5, Burst: 2, MaxConcurrency: 4},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &HostLimitValidator{Host: "example.org:8080"},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &HostLimitValidator{Rate: -1, Burst: -1, MaxConcurrency: -1},
+			wantErrors: []error{
+				errors.New("Host is required"),
+				errors.New("Rate should not be negative"),
+				errors.New("Burst should not be negative"),
+				errors.New("Max concurrency should not be negative"),
+			},
+		},
+		{
+			givenValidator: &HostLimitValidator{Host: "http://example.org/test"},
+			wantErrors:     []error{errors.New("Host is not a valid host")},
+		},
+	}
+
+	for _, test := range tests {
+		test.givenValidator.Validate()
+		if !reflect.DeepEqual(test.givenValidator.Errors, test.wantErrors) {
+			t.Errorf("Errors are not equal; expected %v; got %v", test.wantErrors, test.givenValidator.Errors)
+		}
+	}
+}
+
+func TestHostLimitValidatorInstance(t *testing.T) {
+	v, err := NewHostLimitValidatorFromJSON(`{"host": "API.example.org", "rate": 0.5, "burst": 2, "maxConcurrency": 4}`)
+	if err != nil {
+		t.Fatalf("Validator should be created: %v", err)
+	}
+
+	want := &job.HostLimit{Host: "api.example.org", Rate: 0.5, Burst: 2, MaxConcurrency: 4}
+	got, err := v.Instance()
+	if err != nil {
+		t.Errorf("Instance should be valid: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Host limits are not equal; expected %+v; got %+v", want, got)
+	}
+}
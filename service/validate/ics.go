@@ -0,0 +1,174 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	icsDateFmt     = "20060102"
+	icsDateTimeFmt = "20060102T150405"
+)
+
+// NewCalendarValidatorFromICS creates a validator from an iCalendar (.ics)
+// file, every event is a window of the calendar. The name, description and
+// time zone are taken from the X-WR-* properties if present. Recurring events
+// are not supported
+func NewCalendarValidatorFromICS(data string) (*CalendarValidator, error) {
+	v := &CalendarValidator{Windows: []*WindowValidator{}}
+
+	// Components being parsed, properties are only read from the calendar and
+	// its events (e.g time zones and alarms are ignored)
+	comps := []string{}
+	var w *WindowValidator
+	for _, l := range unfoldICS(data) {
+		name, params, value, err := parseICSLine(l)
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "BEGIN":
+			comps = append(comps, value)
+			if value == "VEVENT" {
+				w = &WindowValidator{}
+			}
+			continue
+		case "END":
+			if len(comps) == 0 || comps[len(comps)-1] != value {
+				return nil, fmt.Errorf("Not valid iCalendar, unexpected end of %s", value)
+			}
+			comps = comps[:len(comps)-1]
+			if value == "VEVENT" {
+				v.Windows = append(v.Windows, w)
+			}
+			continue
+		}
+
+		if len(comps) == 0 {
+			return nil, errors.New("Not valid iCalendar, property out of calendar")
+		}
+		switch comps[len(comps)-1] {
+		case "VCALENDAR":
+			switch name {
+			case "X-WR-CALNAME":
+				v.Name = unescapeICSText(value)
+			case "X-WR-CALDESC":
+				v.Description = unescapeICSText(value)
+			case "X-WR-TIMEZONE":
+				v.TimeZone = value
+			}
+		case "VEVENT":
+			switch name {
+			case "SUMMARY":
+				w.Name = unescapeICSText(value)
+			case "DTSTART":
+				if w.Start, err = icsWindowTime(params, value, false); err != nil {
+					return nil, err
+				}
+			case "DTEND":
+				if w.End, err = icsWindowTime(params, value, true); err != nil {
+					return nil, err
+				}
+			case "RRULE", "RDATE":
+				return nil, errors.New("Recurring events are not supported")
+			}
+		}
+	}
+
+	if len(comps) > 0 {
+		return nil, errors.New("Not valid iCalendar, unexpected end of file")
+	}
+
+	logrus.Debug("Created calendar validator from ics")
+	return v, nil
+}
+
+// unfoldICS returns the logical lines of an iCalendar, the long lines are
+// folded on multiple lines that start with a space or a tab
+func unfoldICS(data string) []string {
+	lines := []string{}
+	for _, l := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		if (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += l[1:]
+			continue
+		}
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+// parseICSLine splits an iCalendar line (NAME;PARAM=VALUE:VALUE) on its name,
+// parameters and value
+func parseICSLine(l string) (name string, params map[string]string, value string, err error) {
+	i := strings.Index(l, ":")
+	if i < 0 {
+		return "", nil, "", fmt.Errorf("Not valid iCalendar line: %s", l)
+	}
+	value = l[i+1:]
+
+	parts := strings.Split(l[:i], ";")
+	name = strings.ToUpper(parts[0])
+	params = map[string]string{}
+	for _, p := range parts[1:] {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return
+}
+
+// icsWindowTime converts an iCalendar date or date-time to a window time. The
+// end date of the iCalendar events is not included while the window one is
+func icsWindowTime(params map[string]string, value string, end bool) (string, error) {
+	// Dates
+	if params["VALUE"] == "DATE" || len(value) == len(icsDateFmt) {
+		d, err := time.Parse(icsDateFmt, value)
+		if err != nil {
+			return "", fmt.Errorf("Not valid iCalendar date: %s", value)
+		}
+		if end {
+			d = d.AddDate(0, 0, -1)
+		}
+		return d.Format(windowDateFmt), nil
+	}
+
+	// UTC times
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsDateTimeFmt+"Z", value)
+		if err != nil {
+			return "", fmt.Errorf("Not valid iCalendar time: %s", value)
+		}
+		return t.Format(time.RFC3339), nil
+	}
+
+	// Times on a time zone or floating (calendar time zone)
+	loc := time.UTC
+	if tz, ok := params["TZID"]; ok {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return "", fmt.Errorf("Not valid iCalendar time zone: %s", tz)
+		}
+	}
+	t, err := time.ParseInLocation(icsDateTimeFmt, value, loc)
+	if err != nil {
+		return "", fmt.Errorf("Not valid iCalendar time: %s", value)
+	}
+	if _, ok := params["TZID"]; ok {
+		return t.Format(time.RFC3339), nil
+	}
+	return t.Format(windowLocalFmt), nil
+}
+
+// unescapeICSText returns the value of an iCalendar text
+func unescapeICSText(value string) string {
+	r := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return r.Replace(value)
+}
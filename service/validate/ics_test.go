@@ -0,0 +1,96 @@
+package validate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewCalendarValidatorFromICS(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Test//Holidays//EN",
+		"X-WR-CALNAME:Holidays",
+		"X-WR-TIMEZONE:Europe/Madrid",
+		"BEGIN:VTIMEZONE",
+		"TZID:Europe/Madrid",
+		"BEGIN:STANDARD",
+		"DTSTART:19701025T030000",
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20171225",
+		"DTEND;VALUE=DATE:20171226",
+		"SUMMARY:Christmas",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20171231",
+		"DTEND;VALUE=DATE:20180102",
+		"SUMMARY:New year\\, holidays",
+		"BEGIN:VALARM",
+		"TRIGGER:-PT15M",
+		"DESCRIPTION:Reminder",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;TZID=Europe/Madrid:20171228T090000",
+		"DTEND;TZID=Europe/Madrid:20171228T180000",
+		"SUMMARY:Change freeze of the ",
+		" end of the year",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20171229T080000Z",
+		"DTEND:20171229T090000Z",
+		"SUMMARY:Migration",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20171230T100000",
+		"DTEND:20171230T120000",
+		"SUMMARY:Floating",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	wantValidator := &CalendarValidator{
+		Name:     "Holidays",
+		TimeZone: "Europe/Madrid",
+		Windows: []*WindowValidator{
+			{Name: "Christmas", Start: "2017-12-25", End: "2017-12-25"},
+			{Name: "New year, holidays", Start: "2017-12-31", End: "2018-01-01"},
+			{Name: "Change freeze of the end of the year", Start: "2017-12-28T09:00:00+01:00", End: "2017-12-28T18:00:00+01:00"},
+			{Name: "Migration", Start: "2017-12-29T08:00:00Z", End: "2017-12-29T09:00:00Z"},
+			{Name: "Floating", Start: "2017-12-30T10:00:00", End: "2017-12-30T12:00:00"},
+		},
+	}
+
+	v, err := NewCalendarValidatorFromICS(ics)
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if !reflect.DeepEqual(v, wantValidator) {
+		t.Errorf("Validators are not equal; expected %#v; got %#v", wantValidator, v)
+	}
+	if err := v.Validate(); err != nil {
+		t.Errorf("Didn't expect error: %v", v.Errors)
+	}
+}
+
+func TestNewCalendarValidatorFromICSErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		givenICS string
+	}{
+		{name: "recurring", givenICS: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20171225\nRRULE:FREQ=YEARLY\nEND:VEVENT\nEND:VCALENDAR"},
+		{name: "not closed", givenICS: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20171225\nEND:VCALENDAR"},
+		{name: "wrong date", givenICS: "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:2017-12-25\nEND:VEVENT\nEND:VCALENDAR"},
+		{name: "wrong line", givenICS: "BEGIN:VCALENDAR\nnot ics\nEND:VCALENDAR"},
+		{name: "no calendar", givenICS: "SUMMARY:Christmas"},
+	}
+
+	for _, test := range tests {
+		if _, err := NewCalendarValidatorFromICS(test.givenICS); err == nil {
+			t.Errorf("%s: Expected error", test.name)
+		}
+	}
+}
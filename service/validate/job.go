@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 
@@ -13,11 +15,33 @@ import (
 // JobValidator implements the requirements of a validator in order to
 // be able to create correct Jobs
 type JobValidator struct {
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	When        string `json:"when"`
-	Active      bool   `json:"active"`
-	URL         string `json:"url"`
+	Name             string                   `json:"name"`
+	Description      string                   `json:"description"`
+	Labels           map[string]string        `json:"labels"`
+	When             string                   `json:"when"`
+	TimeZone         string                   `json:"timeZone"`
+	At               string                   `json:"at"`
+	Active           bool                     `json:"active"`
+	Priority         int                      `json:"priority"`
+	Concurrency      string                   `json:"concurrency"`
+	CatchUp          string                   `json:"catchUp"`
+	StartingDeadline string                   `json:"startingDeadline"`
+	Jitter           string                   `json:"jitter"`
+	JitterMode       string                   `json:"jitterMode"`
+	DependsOn        []*DependencyValidator   `json:"dependsOn"`
+	Calendars        []*CalendarRuleValidator `json:"calendars"`
+	Type             string                   `json:"type"`
+	Command          *CommandValidator        `json:"command"`
+	URL              string                   `json:"url"`
+	Template         bool                     `json:"template"`
+	Method           string                   `json:"method"`
+	Headers          map[string]string        `json:"headers"`
+	Body             string                   `json:"body"`
+	Variables        map[string]string        `json:"variables"`
+	Timeout          string                   `json:"timeout"`
+	Retry            *RetryValidator          `json:"retry"`
+	SuccessCodes     []string                 `json:"successCodes"`
+	Assertions       []*AssertionValidator    `json:"assertions"`
 
 	// Errors after validating the instance
 	Errors []error
@@ -31,6 +55,145 @@ func NewJobValidatorFromJSON(j string) (v *JobValidator, err error) {
 	return
 }
 
+// NewJobValidatorFromJob creates a validator with the values of a job, the
+// validator doesn't share maps or slices with the job
+func NewJobValidatorFromJob(j *job.Job) *JobValidator {
+	v := &JobValidator{
+		Name:        j.Name,
+		Description: j.Description,
+		Labels:      copyStringMap(j.Labels),
+		When:        j.When,
+		TimeZone:    j.TimeZone,
+		Active:      j.Active,
+		Priority:    j.Priority,
+		Concurrency: j.Concurrency,
+		CatchUp:     j.CatchUp,
+		JitterMode:  j.JitterMode,
+		Type:        j.Type,
+		Template:    j.Template,
+		Method:      j.Method,
+		Headers:     copyStringMap(j.Headers),
+		Body:        j.Body,
+		Variables:   copyStringMap(j.Variables),
+	}
+
+	if j.At != nil {
+		v.At = j.At.Format(time.RFC3339Nano)
+	}
+	if j.URLTemplate != "" {
+		v.URL = j.URLTemplate
+	} else if j.URL != nil {
+		v.URL = j.URL.String()
+	}
+	if j.StartingDeadline > 0 {
+		v.StartingDeadline = j.StartingDeadline.String()
+	}
+	if j.Jitter > 0 {
+		v.Jitter = j.Jitter.String()
+	}
+	if j.Timeout > 0 {
+		v.Timeout = j.Timeout.String()
+	}
+	if j.Command != nil {
+		v.Command = &CommandValidator{
+			Path: j.Command.Path,
+			Args: append([]string(nil), j.Command.Args...),
+			Env:  copyStringMap(j.Command.Env),
+			Dir:  j.Command.Dir,
+		}
+	}
+	for _, d := range j.DependsOn {
+		v.DependsOn = append(v.DependsOn, &DependencyValidator{Job: d.JobID, On: d.On})
+	}
+	for _, cr := range j.Calendars {
+		v.Calendars = append(v.Calendars, &CalendarRuleValidator{Calendar: cr.CalendarID, Mode: cr.Mode})
+	}
+	if j.Retry != nil {
+		v.Retry = newRetryValidatorFromRetry(j.Retry)
+	}
+	for _, sc := range j.SuccessCodes {
+		v.SuccessCodes = append(v.SuccessCodes, formatStatusRange(sc))
+	}
+	for _, a := range j.Assertions {
+		v.Assertions = append(v.Assertions, &AssertionValidator{
+			Type:  a.Type,
+			Path:  a.Path,
+			Value: a.Value,
+		})
+	}
+
+	logrus.Debug("Created Job validator from job")
+	return v
+}
+
+// copyStringMap returns a copy of a map
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// MergeJSON applies a JSON merge patch (RFC 7386) over the validator, this is
+// handy to apply partial updates. The fields present replace the validator
+// ones, the objects (e.g labels or headers) are merged by key and the null
+// values remove the field or the key
+func (v *JobValidator) MergeJSON(j string) error {
+	var patch interface{}
+	if err := json.Unmarshal([]byte(j), &patch); err != nil {
+		return err
+	}
+	if _, ok := patch.(map[string]interface{}); !ok {
+		return errors.New("merge patch is not a json object")
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	// The errors aren't part of the job
+	delete(doc, "Errors")
+
+	if b, err = json.Marshal(mergePatch(doc, patch)); err != nil {
+		return err
+	}
+	mv := &JobValidator{}
+	if err := json.Unmarshal(b, mv); err != nil {
+		return err
+	}
+	mv.Errors = v.Errors
+	*v = *mv
+	return nil
+}
+
+// mergePatch applies a JSON merge patch over a decoded json document
+func mergePatch(doc, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	d, ok := doc.(map[string]interface{})
+	if !ok {
+		d = map[string]interface{}{}
+	}
+	for k, pv := range p {
+		if pv == nil {
+			delete(d, k)
+			continue
+		}
+		d[k] = mergePatch(d[k], pv)
+	}
+	return d
+}
+
 // Validate validates the validator and creates teh correct instance
 func (v *JobValidator) Validate() error {
 	logrus.Debugf("Validating job '%s'", v.Name)
@@ -42,16 +205,166 @@ func (v *JobValidator) Validate() error {
 	if v.Name == "" {
 		v.Errors = append(v.Errors, errors.New("Name is required"))
 	}
-	if v.When == "" {
+	for k, lv := range v.Labels {
+		if err := ValidLabelKey(k); err != nil {
+			v.Errors = append(v.Errors, errors.New("Labels have a not valid key"))
+			break
+		}
+		if err := ValidLabelValue(lv); err != nil {
+			v.Errors = append(v.Errors, errors.New("Labels have a not valid value"))
+			break
+		}
+	}
+	// Jobs with dependencies can be only triggered by their upstream jobs
+	if v.When == "" && v.At == "" && len(v.DependsOn) == 0 {
 		v.Errors = append(v.Errors, errors.New("When is required"))
 	}
-	if v.URL == "" {
-		v.Errors = append(v.Errors, errors.New("URL is required"))
+	switch v.jobType() {
+	case job.TypeHTTP:
+		if v.URL == "" {
+			v.Errors = append(v.Errors, errors.New("URL is required"))
+		} else if v.Template && job.HasTemplate(v.URL) {
+			if u, err := renderSampleTemplate(v.URL, v.Variables); err != nil {
+				v.Errors = append(v.Errors, errors.New("URL is not a valid template"))
+			} else if _, err := url.ParseRequestURI(u); err != nil {
+				v.Errors = append(v.Errors, errors.New("URL is not a valid URL after rendering the template"))
+			}
+		}
+	case job.TypeCommand:
+		if v.Command == nil {
+			v.Errors = append(v.Errors, errors.New("Command is required"))
+		} else {
+			v.Errors = append(v.Errors, v.Command.Validate()...)
+		}
+	default:
+		v.Errors = append(v.Errors, errors.New("Type is not a valid job type"))
+	}
+
+	// Check valid cron or valid time of one-shot jobs
+	if v.At == "" {
+		// Jobs only triggered by their upstream jobs don't have schedule
+		if v.When != "" || len(v.DependsOn) == 0 {
+			if err := ValidCron(v.When); err != nil {
+				v.Errors = append(v.Errors, errors.New("When is not a valid cron"))
+			}
+		}
+	} else {
+		if v.When != "" {
+			v.Errors = append(v.Errors, errors.New("When and at can't be set at the same time"))
+		}
+		if err := ValidTime(v.At); err != nil {
+			v.Errors = append(v.Errors, errors.New("At is not a valid RFC3339 time"))
+		}
+	}
+
+	// Check valid time zone
+	if v.TimeZone != "" {
+		if err := ValidTimeZone(v.TimeZone); err != nil {
+			v.Errors = append(v.Errors, errors.New("Time zone is not a valid tz database time zone"))
+		}
+	}
+
+	// Check valid concurrency policy
+	if v.Concurrency != "" {
+		if err := ValidConcurrency(v.Concurrency); err != nil {
+			v.Errors = append(v.Errors, errors.New("Concurrency is not a valid concurrency policy"))
+		}
+	}
+
+	// Check valid missed runs policy
+	if v.CatchUp != "" {
+		if err := ValidCatchUp(v.CatchUp); err != nil {
+			v.Errors = append(v.Errors, errors.New("Catch up is not a valid missed runs policy"))
+		}
+	}
+	if v.StartingDeadline != "" {
+		if err := ValidTimeout(v.StartingDeadline); err != nil {
+			v.Errors = append(v.Errors, errors.New("Starting deadline is not a valid positive duration"))
+		}
 	}
 
-	// Check valid cron
-	if err := ValidCron(v.When); err != nil {
-		v.Errors = append(v.Errors, errors.New("When is not a valid cron"))
+	// Check valid jitter
+	if v.Jitter != "" {
+		if err := ValidTimeout(v.Jitter); err != nil {
+			v.Errors = append(v.Errors, errors.New("Jitter is not a valid positive duration"))
+		}
+	}
+	if v.JitterMode != "" {
+		if err := ValidJitterMode(v.JitterMode); err != nil {
+			v.Errors = append(v.Errors, errors.New("Jitter mode is not a valid jitter mode"))
+		}
+	}
+
+	// Check valid dependencies
+	deps := map[int]bool{}
+	for _, d := range v.DependsOn {
+		v.Errors = append(v.Errors, d.Validate()...)
+		if deps[d.Job] {
+			v.Errors = append(v.Errors, errors.New("Dependencies can't have the same job twice"))
+		}
+		deps[d.Job] = true
+	}
+
+	// Check valid calendars
+	cals := map[int]bool{}
+	for _, cr := range v.Calendars {
+		v.Errors = append(v.Errors, cr.Validate()...)
+		if cals[cr.Calendar] {
+			v.Errors = append(v.Errors, errors.New("Calendars can't have the same calendar twice"))
+		}
+		cals[cr.Calendar] = true
+	}
+
+	// Check valid http request options
+	if v.Method != "" {
+		if err := ValidHTTPMethod(v.Method); err != nil {
+			v.Errors = append(v.Errors, errors.New("Method is not a valid HTTP method"))
+		}
+	}
+	if v.Timeout != "" {
+		if err := ValidTimeout(v.Timeout); err != nil {
+			v.Errors = append(v.Errors, errors.New("Timeout is not a valid positive duration"))
+		}
+	}
+	for h := range v.Headers {
+		if h == "" {
+			v.Errors = append(v.Errors, errors.New("Headers can't have an empty name"))
+			break
+		}
+	}
+	// Only the templated jobs render the header values and the body
+	if v.Template {
+		for _, hv := range v.Headers {
+			if _, err := renderSampleTemplate(hv, v.Variables); err != nil {
+				v.Errors = append(v.Errors, errors.New("Headers have a not valid template"))
+				break
+			}
+		}
+		if _, err := renderSampleTemplate(v.Body, v.Variables); err != nil {
+			v.Errors = append(v.Errors, errors.New("Body is not a valid template"))
+		}
+	}
+	for name := range v.Variables {
+		if name == "" {
+			v.Errors = append(v.Errors, errors.New("Variables can't have an empty name"))
+			break
+		}
+	}
+
+	// Check valid retry policy
+	if v.Retry != nil {
+		v.Errors = append(v.Errors, v.Retry.Validate()...)
+	}
+
+	// Check valid response checks
+	for _, sc := range v.SuccessCodes {
+		if _, err := ParseStatusRange(sc); err != nil {
+			v.Errors = append(v.Errors, errors.New("Success codes has a not valid status code range"))
+			break
+		}
+	}
+	for _, a := range v.Assertions {
+		v.Errors = append(v.Errors, a.Validate()...)
 	}
 
 	if len(v.Errors) > 0 {
@@ -61,21 +374,178 @@ func (v *JobValidator) Validate() error {
 	return nil
 }
 
+// jobType returns the type of the job, http by default
+func (v *JobValidator) jobType() string {
+	if v.Type == "" {
+		return job.TypeHTTP
+	}
+	return v.Type
+}
+
 // Instance returns a valid instance
 func (v *JobValidator) Instance() (j *job.Job, err error) {
 	if err = v.Validate(); err != nil {
 		return
 	}
-	u, err := url.ParseRequestURI(v.URL)
-	if err != nil {
-		return
+
+	// Only HTTP jobs have URL and method, only command jobs have command
+	var u *url.URL
+	var ut string
+	var m string
+	var cmd *job.Command
+	switch v.jobType() {
+	case job.TypeHTTP:
+		// Templated URLs are parsed after rendering them on each execution
+		if v.Template && job.HasTemplate(v.URL) {
+			ut = v.URL
+		} else if u, err = url.ParseRequestURI(v.URL); err != nil {
+			return
+		}
+		// Default to GET if no method set
+		if m = strings.ToUpper(v.Method); m == "" {
+			m = job.DefaultMethod
+		}
+	case job.TypeCommand:
+		if cmd, err = v.Command.Instance(); err != nil {
+			return
+		}
+	}
+
+	// Only one-shot jobs have time
+	var at *time.Time
+	if v.At != "" {
+		t, err := time.Parse(time.RFC3339, v.At)
+		if err != nil {
+			return nil, err
+		}
+		at = &t
+	}
+
+	// Allow concurrent executions by default
+	cc := v.Concurrency
+	if cc == "" {
+		cc = job.ConcurrencyAllow
+	}
+
+	// Skip missed runs by default
+	cu := v.CatchUp
+	if cu == "" {
+		cu = job.CatchUpSkip
+	}
+
+	// No starting deadline means missed runs are never abandoned
+	var sd time.Duration
+	if v.StartingDeadline != "" {
+		if sd, err = time.ParseDuration(v.StartingDeadline); err != nil {
+			return
+		}
+	}
+
+	// No jitter means no delay, random delay by default
+	var jt time.Duration
+	if v.Jitter != "" {
+		if jt, err = time.ParseDuration(v.Jitter); err != nil {
+			return
+		}
+	}
+	jm := v.JitterMode
+	if jm == "" {
+		jm = job.JitterRandom
+	}
+
+	// No timeout means default timeout
+	var to time.Duration
+	if v.Timeout != "" {
+		if to, err = time.ParseDuration(v.Timeout); err != nil {
+			return
+		}
+	}
+
+	var ds []*job.Dependency
+	for _, d := range v.DependsOn {
+		di, err := d.Instance()
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, di)
+	}
+
+	var crs []*job.CalendarRule
+	for _, cr := range v.Calendars {
+		cri, err := cr.Instance()
+		if err != nil {
+			return nil, err
+		}
+		crs = append(crs, cri)
+	}
+
+	var rt *job.Retry
+	if v.Retry != nil {
+		if rt, err = v.Retry.Instance(); err != nil {
+			return
+		}
+	}
+
+	var scs []job.StatusRange
+	for _, sc := range v.SuccessCodes {
+		r, err := ParseStatusRange(sc)
+		if err != nil {
+			return nil, err
+		}
+		scs = append(scs, r)
+	}
+
+	var as []*job.Assertion
+	for _, a := range v.Assertions {
+		ai, err := a.Instance()
+		if err != nil {
+			return nil, err
+		}
+		as = append(as, ai)
 	}
 
 	return &job.Job{
-		Name:        v.Name,
-		Description: v.Description,
-		When:        v.When,
-		Active:      v.Active,
-		URL:         u,
+		Name:             v.Name,
+		Description:      v.Description,
+		Labels:           v.Labels,
+		When:             v.When,
+		TimeZone:         v.TimeZone,
+		At:               at,
+		Active:           v.Active,
+		Priority:         v.Priority,
+		Concurrency:      cc,
+		CatchUp:          cu,
+		StartingDeadline: sd,
+		Jitter:           jt,
+		JitterMode:       jm,
+		DependsOn:        ds,
+		Calendars:        crs,
+		Type:             v.jobType(),
+		Command:          cmd,
+		URL:              u,
+		Template:         v.Template,
+		URLTemplate:      ut,
+		Method:           m,
+		Headers:          v.Headers,
+		Body:             v.Body,
+		Variables:        v.Variables,
+		Timeout:          to,
+		Retry:            rt,
+		SuccessCodes:     scs,
+		Assertions:       as,
 	}, nil
 }
+
+// renderSampleTemplate renders a job template with sample values of an
+// execution to check that it is valid
+func renderSampleTemplate(text string, vars map[string]string) (string, error) {
+	return job.RenderTemplate(text, &job.TemplateData{
+		JobID:         1,
+		JobName:       "sample",
+		RunID:         "0000000000000000",
+		ScheduledTime: time.Now().UTC(),
+		Vars:          vars,
+		// The secrets are checked when the job is saved
+		Secret: func(name string) (string, error) { return "secret", nil },
+	})
+}
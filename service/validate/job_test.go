@@ -2,8 +2,12 @@ package validate
 
 import (
 	"errors"
+	"net/url"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/slok/khronos/job"
 )
 
 func TestJobValidatorJSON(t *testing.T) {
@@ -22,6 +26,19 @@ func TestJobValidatorJSON(t *testing.T) {
 				Errors:      nil,
 			},
 		},
+		{
+			givenJSON: `{"active": true, "url": "http://crons.test.com/hello-world", "when": "@daily", "name": "hello-world", "method": "POST", "headers": {"Content-Type": "application/json"}, "body": "{\"msg\": \"hello\"}"}`,
+			wantValidator: JobValidator{
+				Name:    "hello-world",
+				When:    "@daily",
+				Active:  true,
+				URL:     "http://crons.test.com/hello-world",
+				Method:  "POST",
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    `{"msg": "hello"}`,
+				Errors:  nil,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -84,6 +101,326 @@ func TestJobValidatorValidation(t *testing.T) {
 				errors.New("Name is required"),
 				errors.New("URL is required"),
 			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:    "hello-world",
+				When:    "@daily",
+				URL:     "http://crons.test.com/hello-world",
+				Method:  "post",
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    `{"msg": "hello"}`,
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:    "hello-world",
+				When:    "@daily",
+				URL:     "http://crons.test.com/hello-world",
+				Method:  "FETCH",
+				Headers: map[string]string{"": "empty"},
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Method is not a valid HTTP method"),
+				errors.New("Headers can't have an empty name"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:    "hello-world",
+				When:    "@daily",
+				URL:     "http://crons.test.com/hello-world",
+				Timeout: "5m",
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:    "hello-world",
+				When:    "@daily",
+				URL:     "http://crons.test.com/hello-world",
+				Timeout: "-5m",
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Timeout is not a valid positive duration"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:  "hello-world",
+				When:  "@daily",
+				URL:   "http://crons.test.com/hello-world",
+				Retry: &RetryValidator{MaxAttempts: 3, On: []string{"wrong"}},
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Retry on has a not valid status"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:    "backup",
+				When:    "@daily",
+				Type:    "command",
+				Command: &CommandValidator{Path: "/usr/bin/backup", Args: []string{"--all"}},
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name: "backup",
+				When: "@daily",
+				Type: "command",
+				URL:  "http://crons.test.com/hello-world",
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Command is required"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:    "backup",
+				When:    "@daily",
+				Type:    "command",
+				Command: &CommandValidator{Args: []string{"--all"}},
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Command path is required"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name: "backup",
+				When: "@daily",
+				Type: "ftp",
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Type is not a valid job type"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:        "hello-world",
+				When:        "@daily",
+				URL:         "http://crons.test.com/hello-world",
+				Concurrency: "forbid",
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:        "hello-world",
+				When:        "@daily",
+				URL:         "http://crons.test.com/hello-world",
+				Concurrency: "queue",
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Concurrency is not a valid concurrency policy"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:     "hello-world",
+				When:     "0 0 9 * * *",
+				TimeZone: "Europe/Madrid",
+				URL:      "http://crons.test.com/hello-world",
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:     "hello-world",
+				When:     "0 0 9 * * *",
+				TimeZone: "Europe/Nowhere",
+				URL:      "http://crons.test.com/hello-world",
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Time zone is not a valid tz database time zone"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name: "hello-world",
+				At:   "2026-11-03T02:00:00Z",
+				URL:  "http://crons.test.com/hello-world",
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name: "hello-world",
+				When: "@daily",
+				At:   "2026-11-03 02:00",
+				URL:  "http://crons.test.com/hello-world",
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("When and at can't be set at the same time"),
+				errors.New("At is not a valid RFC3339 time"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:             "hello-world",
+				When:             "@daily",
+				URL:              "http://crons.test.com/hello-world",
+				CatchUp:          "all",
+				StartingDeadline: "1h",
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:             "hello-world",
+				When:             "@daily",
+				URL:              "http://crons.test.com/hello-world",
+				CatchUp:          "twice",
+				StartingDeadline: "-1h",
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Catch up is not a valid missed runs policy"),
+				errors.New("Starting deadline is not a valid positive duration"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:       "hello-world",
+				When:       "0 * * * * *",
+				URL:        "http://crons.test.com/hello-world",
+				Jitter:     "30s",
+				JitterMode: "hash",
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:       "hello-world",
+				When:       "0 * * * * *",
+				URL:        "http://crons.test.com/hello-world",
+				Jitter:     "0s",
+				JitterMode: "H",
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Jitter is not a valid positive duration"),
+				errors.New("Jitter mode is not a valid jitter mode"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:      "hello-world",
+				URL:       "http://crons.test.com/hello-world",
+				DependsOn: []*DependencyValidator{{Job: 1}, {Job: 2, On: "failure"}},
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:      "hello-world",
+				URL:       "http://crons.test.com/hello-world",
+				DependsOn: []*DependencyValidator{{Job: 1}, {Job: 1, On: "never"}, {}},
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Dependency on is not a valid trigger"),
+				errors.New("Dependencies can't have the same job twice"),
+				errors.New("Dependency job is required"),
+			},
+		},
+		{
+			givenValidator: &JobValidator{
+				Name:      "hello-world",
+				When:      "@daily",
+				URL:       "http://crons.test.com/hello-world",
+				Calendars: []*CalendarRuleValidator{{Calendar: 1}, {Calendar: 2, Mode: "include"}},
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:      "hello-world",
+				When:      "@daily",
+				URL:       "http://crons.test.com/hello-world",
+				Calendars: []*CalendarRuleValidator{{Calendar: 1}, {Calendar: 1, Mode: "only"}},
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("Calendar mode is not a valid calendar mode"),
+				errors.New("Calendars can't have the same calendar twice"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:      "reports",
+				When:      "@daily",
+				Template:  true,
+				URL:       `https://svc.test.com/{{.Vars.team}}/reports?date={{.ScheduledTime | add "-24h" | date "2006-01-02"}}`,
+				Headers:   map[string]string{"X-Run": "{{.RunID}}", "Authorization": `Bearer {{secret "api-token"}}`},
+				Body:      `{"job": {{.JobID}}}`,
+				Variables: map[string]string{"team": "billing"},
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:      "reports",
+				When:      "@daily",
+				Template:  true,
+				URL:       "https://svc.test.com/reports?date={{.ScheduledTime | date}}",
+				Headers:   map[string]string{"X-Team": "{{.Vars.team}}"},
+				Body:      "{{.Missing}}",
+				Variables: map[string]string{"": "billing"},
+			},
+			wantError: true,
+			wantErrors: []error{
+				errors.New("URL is not a valid template"),
+				errors.New("Headers have a not valid template"),
+				errors.New("Body is not a valid template"),
+				errors.New("Variables can't have an empty name"),
+			},
+		}, {
+			givenValidator: &JobValidator{
+				Name:     "reports",
+				When:     "@daily",
+				Template: true,
+				URL:      "{{.JobName}}",
+			},
+			wantError:  true,
+			wantErrors: []error{errors.New("URL is not a valid URL after rendering the template")},
+		}, {
+			// Not templated jobs can have literal template actions
+			givenValidator: &JobValidator{
+				Name:    "render",
+				When:    "@daily",
+				URL:     "https://svc.test.com/render",
+				Headers: map[string]string{"X-Template": "{{.Missing}}"},
+				Body:    `{"template": "Hello {{.Name}"}`,
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:   "reports",
+				When:   "@daily",
+				URL:    "https://svc.test.com/reports",
+				Labels: map[string]string{"team": "payments", "example.org/env": "", "tier": "web_1.2-a"},
+			},
+			wantError:  false,
+			wantErrors: []error{},
+		}, {
+			givenValidator: &JobValidator{
+				Name:   "reports",
+				When:   "@daily",
+				URL:    "https://svc.test.com/reports",
+				Labels: map[string]string{"-team": "payments"},
+			},
+			wantError:  true,
+			wantErrors: []error{errors.New("Labels have a not valid key")},
+		}, {
+			givenValidator: &JobValidator{
+				Name:   "reports",
+				When:   "@daily",
+				URL:    "https://svc.test.com/reports",
+				Labels: map[string]string{"team": "pay ments"},
+			},
+			wantError:  true,
+			wantErrors: []error{errors.New("Labels have a not valid value")},
 		},
 	}
 
@@ -101,3 +438,186 @@ func TestJobValidatorValidation(t *testing.T) {
 	}
 
 }
+
+func TestJobValidatorInstance(t *testing.T) {
+	tests := []struct {
+		givenValidator *JobValidator
+		wantMethod     string
+		wantTimeout    time.Duration
+	}{
+		{
+			givenValidator: &JobValidator{Name: "hello-world", When: "@daily", URL: "http://crons.test.com/hello-world"},
+			wantMethod:     "GET",
+			wantTimeout:    0,
+		},
+		{
+			givenValidator: &JobValidator{Name: "hello-world", When: "@daily", URL: "http://crons.test.com/hello-world", Method: "post", Timeout: "1m30s"},
+			wantMethod:     "POST",
+			wantTimeout:    90 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		j, err := test.givenValidator.Instance()
+		if err != nil {
+			t.Errorf("Didn't expect error: %v", err)
+			continue
+		}
+
+		if j.Method != test.wantMethod {
+			t.Errorf("Job method should be %s; got %s", test.wantMethod, j.Method)
+		}
+
+		if j.Timeout != test.wantTimeout {
+			t.Errorf("Job timeout should be %v; got %v", test.wantTimeout, j.Timeout)
+		}
+	}
+}
+
+func TestJobValidatorOneShotInstance(t *testing.T) {
+	v := &JobValidator{Name: "cleanup", At: "2026-11-03T02:00:00Z", URL: "http://crons.test.com/cleanup"}
+	wantAt := time.Date(2026, time.November, 3, 2, 0, 0, 0, time.UTC)
+
+	j, err := v.Instance()
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+
+	if !j.OneShot() || !j.At.Equal(wantAt) || j.When != "" || j.Completed {
+		t.Errorf("Job should be a not completed one-shot job at %v; got %#v", wantAt, j)
+	}
+
+	// From job again should get the same time
+	if gotV := NewJobValidatorFromJob(j); gotV.At != v.At {
+		t.Errorf("Validator at should be %s; got %s", v.At, gotV.At)
+	}
+}
+
+func TestJobValidatorCommandInstance(t *testing.T) {
+	v := &JobValidator{
+		Name:    "backup",
+		When:    "@daily",
+		Type:    "command",
+		Command: &CommandValidator{Path: "/usr/bin/backup", Args: []string{"--all"}, Env: map[string]string{"LEVEL": "1"}, Dir: "/tmp"},
+	}
+	wantCommand := &job.Command{Path: "/usr/bin/backup", Args: []string{"--all"}, Env: map[string]string{"LEVEL": "1"}, Dir: "/tmp"}
+
+	j, err := v.Instance()
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+
+	if j.Type != job.TypeCommand {
+		t.Errorf("Job type should be %s; got %s", job.TypeCommand, j.Type)
+	}
+	if j.URL != nil {
+		t.Errorf("Command job shouldn't have URL; got %v", j.URL)
+	}
+	if !reflect.DeepEqual(j.Command, wantCommand) {
+		t.Errorf("Job command should be %#v; got %#v", wantCommand, j.Command)
+	}
+}
+
+func TestJobValidatorFromJobMerge(t *testing.T) {
+	u, _ := url.Parse("http://crons.test.com/hello-world")
+	j := &job.Job{
+		Name:             "hello-world",
+		Labels:           map[string]string{"team": "payments"},
+		When:             "@daily",
+		Active:           true,
+		Priority:         10,
+		Concurrency:      job.ConcurrencyAllow,
+		CatchUp:          job.CatchUpOnce,
+		StartingDeadline: time.Minute,
+		Jitter:           30 * time.Second,
+		JitterMode:       job.JitterHash,
+		DependsOn:        []*job.Dependency{{JobID: 2, On: job.TriggerAlways}},
+		Calendars:        []*job.CalendarRule{{CalendarID: 1, Mode: job.CalendarInclude}},
+		Type:             job.TypeHTTP,
+		URL:              u,
+		Method:           "POST",
+		Headers:          map[string]string{"X-Test": "1"},
+		Variables:        map[string]string{"team": "billing"},
+		Timeout:          90 * time.Second,
+		Retry:            &job.Retry{MaxAttempts: 3, Backoff: time.Second, Factor: 2, On: []int{job.ResultError}},
+		SuccessCodes:     []job.StatusRange{{From: 200, To: 299}, {From: 404, To: 404}},
+		Assertions:       []*job.Assertion{{Type: job.AssertionContains, Value: "ok"}},
+	}
+
+	// Without changes the job should be the same
+	v := NewJobValidatorFromJob(j)
+	if err := v.Validate(); err != nil {
+		t.Fatalf("Didn't expect error: %v", v.Errors)
+	}
+	gotJ, err := v.Instance()
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if !reflect.DeepEqual(gotJ, j) {
+		t.Errorf("Job should be %#v; got %#v", j, gotJ)
+	}
+
+	// Merged fields should be the only changes
+	v = NewJobValidatorFromJob(j)
+	if err := v.MergeJSON(`{"when": "@hourly", "headers": {"X-Other": "2"}}`); err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	gotJ, err = v.Instance()
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if gotJ.When != "@hourly" || gotJ.Name != j.Name || gotJ.Timeout != j.Timeout {
+		t.Errorf("Unexpected merged job: %#v", gotJ)
+	}
+	if _, ok := gotJ.Headers["X-Other"]; !ok {
+		t.Errorf("Merged headers should be present; got %v", gotJ.Headers)
+	}
+	if _, ok := j.Headers["X-Other"]; ok {
+		t.Errorf("Original job headers shouldn't be modified")
+	}
+
+	// Null values remove the keys and the fields
+	v = NewJobValidatorFromJob(j)
+	if err := v.MergeJSON(`{"labels": {"team": null, "tier": "1"}, "variables": null, "successCodes": ["200"]}`); err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	gotJ, err = v.Instance()
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if wantLabels := map[string]string{"tier": "1"}; !reflect.DeepEqual(gotJ.Labels, wantLabels) {
+		t.Errorf("Labels should be %v; got %v", wantLabels, gotJ.Labels)
+	}
+	if len(gotJ.Variables) != 0 {
+		t.Errorf("Variables should be removed; got %v", gotJ.Variables)
+	}
+	if wantCodes := []job.StatusRange{{From: 200, To: 200}}; !reflect.DeepEqual(gotJ.SuccessCodes, wantCodes) {
+		t.Errorf("Arrays should be replaced; got %v", gotJ.SuccessCodes)
+	}
+	if !reflect.DeepEqual(gotJ.Headers, j.Headers) {
+		t.Errorf("Fields not present should be kept; got %v", gotJ.Headers)
+	}
+
+	// Only objects are valid patches
+	if err := NewJobValidatorFromJob(j).MergeJSON(`["when"]`); err == nil {
+		t.Errorf("Not object patch should get an error")
+	}
+}
+
+func TestJobValidatorTemplateInstance(t *testing.T) {
+	tmpl := "https://svc.test.com/{{.Vars.team}}/reports?date={{.ScheduledTime | date \"2006-01-02\"}}"
+	v := &JobValidator{Name: "reports", When: "@daily", URL: tmpl, Template: true, Variables: map[string]string{"team": "billing"}}
+
+	j, err := v.Instance()
+	if err != nil {
+		t.Fatalf("Didn't expect error: %v", err)
+	}
+	if j.URL != nil || j.URLTemplate != tmpl {
+		t.Errorf("Job should have the URL template %s; got %v, %s", tmpl, j.URL, j.URLTemplate)
+	}
+
+	// From job again should get the same template
+	if gotV := NewJobValidatorFromJob(j); gotV.URL != tmpl || !gotV.Template || gotV.Variables["team"] != "billing" {
+		t.Errorf("Validator should have the URL template %s; got %s", tmpl, gotV.URL)
+	}
+}
@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+const notValidNamespaceName = "Invalid namespace name"
+
+// namespaceNameRe is the syntax of the namespace names, DNS labels
+var namespaceNameRe = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// NamespaceValidator implements the requirements of a validator in order to
+// be able to create correct namespaces
+type NamespaceValidator struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Errors after validating the instance
+	Errors []error
+}
+
+// NewNamespaceValidatorFromJSON creates a validator from a json
+func NewNamespaceValidatorFromJSON(j string) (v *NamespaceValidator, err error) {
+	v = &NamespaceValidator{}
+	err = json.Unmarshal([]byte(j), v)
+	logrus.Debug("Created namespace validator from json")
+	return
+}
+
+// Validate validates the namespace and returns an error if not valid
+func (v *NamespaceValidator) Validate() error {
+	// Flush previous errors
+	v.Errors = []error{}
+
+	if v.Name == "" {
+		v.Errors = append(v.Errors, errors.New("Name is required"))
+	} else if err := ValidNamespaceName(v.Name); err != nil {
+		v.Errors = append(v.Errors, errors.New("Name is not a valid namespace name"))
+	}
+
+	if len(v.Errors) > 0 {
+		return errors.New("Not valid namespace")
+	}
+	return nil
+}
+
+// Instance returns a valid namespace instance created now
+func (v *NamespaceValidator) Instance() (*job.Namespace, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &job.Namespace{
+		Name:        v.Name,
+		Description: v.Description,
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// ValidNamespaceName checks if the value is a valid namespace name, lowercase
+// letters, digits and - of up to 63 characters (e.g payments, team-search)
+func ValidNamespaceName(value string) error {
+	if len(value) > 63 || !namespaceNameRe.MatchString(value) {
+		return errors.New(notValidNamespaceName)
+	}
+	return nil
+}
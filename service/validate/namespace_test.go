@@ -0,0 +1,62 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNamespaceValidatorValidation(t *testing.T) {
+	tests := []struct {
+		givenValidator *NamespaceValidator
+		wantErrors     []error
+	}{
+		{
+			givenValidator: &NamespaceValidator{Name: "payments", Description: "Payments team"},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &NamespaceValidator{Name: "team-search2"},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &NamespaceValidator{},
+			wantErrors:     []error{errors.New("Name is required")},
+		},
+		{
+			givenValidator: &NamespaceValidator{Name: "Payments"},
+			wantErrors:     []error{errors.New("Name is not a valid namespace name")},
+		},
+		{
+			givenValidator: &NamespaceValidator{Name: "team/search"},
+			wantErrors:     []error{errors.New("Name is not a valid namespace name")},
+		},
+		{
+			givenValidator: &NamespaceValidator{Name: strings.Repeat("a", 64)},
+			wantErrors:     []error{errors.New("Name is not a valid namespace name")},
+		},
+	}
+
+	for _, test := range tests {
+		test.givenValidator.Validate()
+		if !reflect.DeepEqual(test.givenValidator.Errors, test.wantErrors) {
+			t.Errorf("Errors are not equal; expected %v; got %v", test.wantErrors, test.givenValidator.Errors)
+		}
+	}
+}
+
+func TestNamespaceValidatorInstance(t *testing.T) {
+	v, err := NewNamespaceValidatorFromJSON(`{"name": "payments", "description": "Payments team"}`)
+	if err != nil {
+		t.Fatalf("Validator should be created: %v", err)
+	}
+
+	got, err := v.Instance()
+	if err != nil {
+		t.Errorf("Instance should be valid: %v", err)
+	}
+	if got.Name != "payments" || got.Description != "Payments team" || got.CreatedAt.IsZero() {
+		t.Errorf("Namespace is not valid; got %+v", got)
+	}
+}
@@ -0,0 +1,137 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/slok/khronos/job"
+)
+
+const (
+	maxRetryAttempts     = 20
+	retryBackoffDefault  = 1 * time.Second
+	retryFactorDefault   = 2
+	retryStatusError     = "error"
+	retryStatusInternal  = "internal_error"
+	retryStatusUnknown   = "unknown"
+	notValidRetryStatus  = "Invalid retry status"
+	notValidRetryBackoff = "Invalid retry backoff"
+)
+
+// retryStatuses maps the API result statuses to the job result statuses
+var retryStatuses = map[string]int{
+	retryStatusError:    job.ResultError,
+	retryStatusInternal: job.ResultInternalError,
+	retryStatusUnknown:  job.ResultUnknow,
+}
+
+// RetryValidator implements the requirements of a validator in order to
+// be able to create correct job retry policies
+type RetryValidator struct {
+	MaxAttempts int      `json:"maxAttempts"`
+	Backoff     string   `json:"backoff"`
+	Factor      float64  `json:"factor"`
+	MaxBackoff  string   `json:"maxBackoff"`
+	On          []string `json:"on"`
+}
+
+// Validate validates the retry policy and returns all the errors found
+func (v *RetryValidator) Validate() []error {
+	errs := []error{}
+
+	if v.MaxAttempts < 1 || v.MaxAttempts > maxRetryAttempts {
+		errs = append(errs, fmt.Errorf("Retry max attempts should be between 1 and %d", maxRetryAttempts))
+	}
+	if v.Backoff != "" {
+		if err := ValidRetryBackoff(v.Backoff); err != nil {
+			errs = append(errs, errors.New("Retry backoff is not a valid duration"))
+		}
+	}
+	if v.MaxBackoff != "" {
+		if err := ValidRetryBackoff(v.MaxBackoff); err != nil {
+			errs = append(errs, errors.New("Retry max backoff is not a valid duration"))
+		}
+	}
+	if v.Factor != 0 && v.Factor < 1 {
+		errs = append(errs, errors.New("Retry factor should be greater or equal than 1"))
+	}
+	for _, s := range v.On {
+		if err := ValidRetryStatus(s); err != nil {
+			errs = append(errs, errors.New("Retry on has a not valid status"))
+			break
+		}
+	}
+
+	return errs
+}
+
+// Instance returns a valid retry policy instance with the defaults applied
+func (v *RetryValidator) Instance() (*job.Retry, error) {
+	if errs := v.Validate(); len(errs) > 0 {
+		return nil, errors.New("Not valid retry")
+	}
+
+	r := &job.Retry{
+		MaxAttempts: v.MaxAttempts,
+		Backoff:     retryBackoffDefault,
+		Factor:      retryFactorDefault,
+	}
+
+	if v.Backoff != "" {
+		r.Backoff, _ = time.ParseDuration(v.Backoff)
+	}
+	if v.MaxBackoff != "" {
+		r.MaxBackoff, _ = time.ParseDuration(v.MaxBackoff)
+	}
+	if v.Factor != 0 {
+		r.Factor = v.Factor
+	}
+
+	// By default retry the errors
+	if len(v.On) == 0 {
+		r.On = []int{job.ResultError, job.ResultInternalError}
+	}
+	for _, s := range v.On {
+		r.On = append(r.On, retryStatuses[s])
+	}
+
+	return r, nil
+}
+
+// newRetryValidatorFromRetry creates a validator with the values of a retry policy
+func newRetryValidatorFromRetry(r *job.Retry) *RetryValidator {
+	v := &RetryValidator{
+		MaxAttempts: r.MaxAttempts,
+		Backoff:     r.Backoff.String(),
+		Factor:      r.Factor,
+	}
+	if r.MaxBackoff > 0 {
+		v.MaxBackoff = r.MaxBackoff.String()
+	}
+	for _, st := range r.On {
+		for k, s := range retryStatuses {
+			if s == st {
+				v.On = append(v.On, k)
+			}
+		}
+	}
+	return v
+}
+
+// ValidRetryStatus checks if the value is a valid result status to retry
+func ValidRetryStatus(value string) error {
+	if _, ok := retryStatuses[value]; !ok {
+		return errors.New(notValidRetryStatus)
+	}
+	return nil
+}
+
+// ValidRetryBackoff checks if the value is a valid not negative duration
+func ValidRetryBackoff(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return errors.New(notValidRetryBackoff)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestRetryValidatorValidation(t *testing.T) {
+	tests := []struct {
+		givenValidator *RetryValidator
+		wantErrors     []error
+	}{
+		{
+			givenValidator: &RetryValidator{MaxAttempts: 3},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &RetryValidator{MaxAttempts: 5, Backoff: "500ms", Factor: 1.5, MaxBackoff: "1m", On: []string{"error", "unknown"}},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &RetryValidator{},
+			wantErrors: []error{
+				errors.New("Retry max attempts should be between 1 and 20"),
+			},
+		},
+		{
+			givenValidator: &RetryValidator{MaxAttempts: 3, Backoff: "-1s", Factor: 0.5, MaxBackoff: "wrong", On: []string{"error", "ok"}},
+			wantErrors: []error{
+				errors.New("Retry backoff is not a valid duration"),
+				errors.New("Retry max backoff is not a valid duration"),
+				errors.New("Retry factor should be greater or equal than 1"),
+				errors.New("Retry on has a not valid status"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		errs := test.givenValidator.Validate()
+		if !reflect.DeepEqual(errs, test.wantErrors) {
+			t.Errorf("Errors are not equal; expected %v; got %v", test.wantErrors, errs)
+		}
+	}
+}
+
+func TestRetryValidatorInstance(t *testing.T) {
+	tests := []struct {
+		givenValidator *RetryValidator
+		wantRetry      *job.Retry
+	}{
+		{
+			givenValidator: &RetryValidator{MaxAttempts: 3},
+			wantRetry: &job.Retry{
+				MaxAttempts: 3,
+				Backoff:     1 * time.Second,
+				Factor:      2,
+				On:          []int{job.ResultError, job.ResultInternalError},
+			},
+		},
+		{
+			givenValidator: &RetryValidator{MaxAttempts: 5, Backoff: "500ms", Factor: 1.5, MaxBackoff: "1m", On: []string{"unknown"}},
+			wantRetry: &job.Retry{
+				MaxAttempts: 5,
+				Backoff:     500 * time.Millisecond,
+				Factor:      1.5,
+				MaxBackoff:  1 * time.Minute,
+				On:          []int{job.ResultUnknow},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		r, err := test.givenValidator.Instance()
+		if err != nil {
+			t.Errorf("Didn't expect error: %v", err)
+		}
+
+		if !reflect.DeepEqual(r, test.wantRetry) {
+			t.Errorf("Retries are not equal; expected %#v; got %#v", test.wantRetry, r)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+const (
+	// DefaultScheduleRuns is the number of activation times of a schedule
+	// preview when not set
+	DefaultScheduleRuns = 5
+	// MaxScheduleRuns is the max number of activation times of a schedule preview
+	MaxScheduleRuns = 100
+)
+
+// ScheduleValidator implements the requirements of a validator in order to
+// be able to preview correct job schedules
+type ScheduleValidator struct {
+	When     string `json:"when"`
+	TimeZone string `json:"timeZone"`
+	Count    int    `json:"count"`
+
+	// Errors after validating the instance
+	Errors []error
+}
+
+// NewScheduleValidatorFromJSON creates a validator from a json
+func NewScheduleValidatorFromJSON(j string) (v *ScheduleValidator, err error) {
+	v = &ScheduleValidator{}
+	err = json.Unmarshal([]byte(j), v)
+	logrus.Debug("Created schedule validator from json")
+	return
+}
+
+// Validate validates the schedule and returns an error if not valid
+func (v *ScheduleValidator) Validate() error {
+	// Flush previous errors
+	v.Errors = []error{}
+
+	if v.When == "" {
+		v.Errors = append(v.Errors, errors.New("When is required"))
+	} else if err := ValidCron(v.When); err != nil {
+		v.Errors = append(v.Errors, errors.New("When is not a valid cron"))
+	}
+	if v.TimeZone != "" {
+		if err := ValidTimeZone(v.TimeZone); err != nil {
+			v.Errors = append(v.Errors, errors.New("Time zone is not a valid tz database time zone"))
+		}
+	}
+	if v.Count < 0 || v.Count > MaxScheduleRuns {
+		v.Errors = append(v.Errors, errors.New("Count is out of range"))
+	}
+
+	if len(v.Errors) > 0 {
+		return errors.New("Not valid schedule")
+	}
+	return nil
+}
+
+// Instance returns a job with the valid schedule and the number of activation
+// times requested
+func (v *ScheduleValidator) Instance() (*job.Job, int, error) {
+	if err := v.Validate(); err != nil {
+		return nil, 0, err
+	}
+
+	count := v.Count
+	if count == 0 {
+		count = DefaultScheduleRuns
+	}
+
+	return &job.Job{When: v.When, TimeZone: v.TimeZone}, count, nil
+}
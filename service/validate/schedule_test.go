@@ -0,0 +1,42 @@
+package validate
+
+import (
+	"testing"
+)
+
+func TestScheduleValidatorValidation(t *testing.T) {
+	tests := []struct {
+		givenValidator *ScheduleValidator
+		wantError      bool
+		wantCount      int
+	}{
+		{givenValidator: &ScheduleValidator{When: "@daily"}, wantError: false, wantCount: DefaultScheduleRuns},
+		{givenValidator: &ScheduleValidator{When: "0 0 9 * * *", TimeZone: "Asia/Tokyo", Count: 10}, wantError: false, wantCount: 10},
+		{givenValidator: &ScheduleValidator{}, wantError: true},
+		{givenValidator: &ScheduleValidator{When: "wrong"}, wantError: true},
+		{givenValidator: &ScheduleValidator{When: "@daily", TimeZone: "Europe/Nowhere"}, wantError: true},
+		{givenValidator: &ScheduleValidator{When: "@daily", Count: -1}, wantError: true},
+		{givenValidator: &ScheduleValidator{When: "@daily", Count: MaxScheduleRuns + 1}, wantError: true},
+	}
+
+	for _, test := range tests {
+		j, count, err := test.givenValidator.Instance()
+		if test.wantError {
+			if err == nil {
+				t.Errorf("%+v should raise error", test.givenValidator)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%+v should not raise error: %v", test.givenValidator, test.givenValidator.Errors)
+			continue
+		}
+
+		if j.When != test.givenValidator.When || j.TimeZone != test.givenValidator.TimeZone {
+			t.Errorf("Wrong schedule job: %+v", j)
+		}
+		if count != test.wantCount {
+			t.Errorf("Count should be %d; got %d", test.wantCount, count)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+const notValidSecretName = "Invalid secret name"
+
+var secretNameRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// SecretValidator implements the requirements of a validator in order to be
+// able to create correct secrets
+type SecretValidator struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Value       string `json:"value"`
+
+	// Errors after validating the instance
+	Errors []error
+}
+
+// NewSecretValidatorFromJSON creates a validator from a json
+func NewSecretValidatorFromJSON(j string) (v *SecretValidator, err error) {
+	v = &SecretValidator{}
+	err = json.Unmarshal([]byte(j), v)
+	logrus.Debug("Created secret validator from json")
+	return
+}
+
+// Validate validates the secret and returns an error if not valid
+func (v *SecretValidator) Validate() error {
+	// Flush previous errors
+	v.Errors = []error{}
+
+	if v.Name == "" {
+		v.Errors = append(v.Errors, errors.New("Name is required"))
+	} else if err := ValidSecretName(v.Name); err != nil {
+		v.Errors = append(v.Errors, errors.New("Name is not a valid secret name"))
+	}
+	if v.Value == "" {
+		v.Errors = append(v.Errors, errors.New("Value is required"))
+	}
+
+	if len(v.Errors) > 0 {
+		return errors.New("Not valid secret")
+	}
+	return nil
+}
+
+// Instance returns a valid secret instance without value, the value needs to
+// be encrypted before storing it
+func (v *SecretValidator) Instance() (*job.Secret, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &job.Secret{Name: v.Name, Description: v.Description}, nil
+}
+
+// ValidSecretName checks if the value is a valid secret name, letters, digits
+// and the _ . - characters
+func ValidSecretName(value string) error {
+	if !secretNameRe.MatchString(value) {
+		return errors.New(notValidSecretName)
+	}
+	return nil
+}
+
+// ValidSecrets checks that the secrets referenced by the templates of a job
+// exist
+func ValidSecrets(j *job.Job, secs []*job.Secret) []error {
+	errs := []error{}
+
+	names := map[string]bool{}
+	for _, sec := range secs {
+		names[sec.Name] = true
+	}
+	for _, n := range j.SecretNames() {
+		if !names[n] {
+			errs = append(errs, fmt.Errorf("Secret %s doesn't exist", n))
+		}
+	}
+
+	return errs
+}
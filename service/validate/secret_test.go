@@ -0,0 +1,52 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestSecretValidatorValidation(t *testing.T) {
+	tests := []struct {
+		givenValidator *SecretValidator
+		wantErrors     []error
+	}{
+		{
+			givenValidator: &SecretValidator{Name: "api-token_v2.prod", Description: "reports API", Value: "s3cr3t"},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &SecretValidator{},
+			wantErrors: []error{
+				errors.New("Name is required"),
+				errors.New("Value is required"),
+			},
+		},
+		{
+			givenValidator: &SecretValidator{Name: "api token", Value: "s3cr3t"},
+			wantErrors:     []error{errors.New("Name is not a valid secret name")},
+		},
+	}
+
+	for _, test := range tests {
+		test.givenValidator.Validate()
+		if !reflect.DeepEqual(test.givenValidator.Errors, test.wantErrors) {
+			t.Errorf("Errors are not equal; expected %v; got %v", test.wantErrors, test.givenValidator.Errors)
+		}
+	}
+}
+
+func TestValidSecrets(t *testing.T) {
+	secs := []*job.Secret{{Name: "api-token"}}
+	j := &job.Job{Template: true, Headers: map[string]string{
+		"Authorization": `Bearer {{secret "api-token"}}`,
+		"X-Key":         `{{secret "api-key"}}`,
+	}}
+
+	want := []error{errors.New("Secret api-key doesn't exist")}
+	if got := ValidSecrets(j, secs); !reflect.DeepEqual(got, want) {
+		t.Errorf("Errors are not equal; expected %v; got %v", want, got)
+	}
+}
@@ -0,0 +1,106 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+
+	"github.com/slok/khronos/job"
+)
+
+const notValidTokenRole = "Invalid token role"
+
+// TokenValidator implements the requirements of a validator in order to be
+// able to create correct tokens
+type TokenValidator struct {
+	Name       string   `json:"name"`
+	Role       string   `json:"role"`
+	Namespaces []string `json:"namespaces"`
+	Selector   string   `json:"selector"`
+	ExpiresAt  string   `json:"expiresAt"`
+
+	// Errors after validating the instance
+	Errors []error
+}
+
+// NewTokenValidatorFromJSON creates a validator from a json
+func NewTokenValidatorFromJSON(j string) (v *TokenValidator, err error) {
+	v = &TokenValidator{}
+	err = json.Unmarshal([]byte(j), v)
+	logrus.Debug("Created token validator from json")
+	return
+}
+
+// Validate validates the token and returns an error if not valid
+func (v *TokenValidator) Validate() error {
+	// Flush previous errors
+	v.Errors = []error{}
+
+	if v.Name == "" {
+		v.Errors = append(v.Errors, errors.New("Name is required"))
+	}
+
+	if v.Role == "" {
+		v.Errors = append(v.Errors, errors.New("Role is required"))
+	} else if err := ValidTokenRole(v.Role); err != nil {
+		v.Errors = append(v.Errors, errors.New("Role is not a valid role"))
+	}
+
+	for _, ns := range v.Namespaces {
+		if ns != job.AllNamespaces && ValidNamespaceName(ns) != nil {
+			v.Errors = append(v.Errors, errors.New("Namespaces have a not valid namespace name"))
+			break
+		}
+	}
+
+	if _, err := job.ParseSelector(v.Selector); err != nil {
+		v.Errors = append(v.Errors, errors.New("Selector is not a valid label selector"))
+	}
+
+	if v.ExpiresAt != "" {
+		if t, err := time.Parse(time.RFC3339, v.ExpiresAt); err != nil {
+			v.Errors = append(v.Errors, errors.New("ExpiresAt is not a valid RFC3339 time"))
+		} else if !t.After(time.Now()) {
+			v.Errors = append(v.Errors, errors.New("ExpiresAt should be in the future"))
+		}
+	}
+
+	if len(v.Errors) > 0 {
+		return errors.New("Not valid token")
+	}
+	return nil
+}
+
+// Instance returns a valid token instance created now without secret, the
+// secret needs to be generated before storing it
+func (v *TokenValidator) Instance() (*job.Token, error) {
+	if err := v.Validate(); err != nil {
+		return nil, err
+	}
+
+	t := &job.Token{
+		Name:       v.Name,
+		Role:       v.Role,
+		Namespaces: v.Namespaces,
+		Selector:   v.Selector,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if v.ExpiresAt != "" {
+		expiresAt, _ := time.Parse(time.RFC3339, v.ExpiresAt)
+		expiresAt = expiresAt.UTC()
+		t.ExpiresAt = &expiresAt
+	}
+	return t, nil
+}
+
+// ValidTokenRole checks if the value is a role of the tokens, viewer, operator
+// or admin
+func ValidTokenRole(value string) error {
+	switch value {
+	case job.RoleViewer, job.RoleOperator, job.RoleAdmin:
+		return nil
+	}
+	return errors.New(notValidTokenRole)
+}
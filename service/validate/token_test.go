@@ -0,0 +1,79 @@
+package validate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/slok/khronos/job"
+)
+
+func TestTokenValidatorValidation(t *testing.T) {
+	tests := []struct {
+		givenValidator *TokenValidator
+		wantErrors     []error
+	}{
+		{
+			givenValidator: &TokenValidator{Name: "ci", Role: job.RoleOperator},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &TokenValidator{Name: "ci", Role: job.RoleViewer, Namespaces: []string{"payments", job.AllNamespaces}, Selector: "team=payments", ExpiresAt: "2099-01-01T00:00:00Z"},
+			wantErrors:     []error{},
+		},
+		{
+			givenValidator: &TokenValidator{},
+			wantErrors:     []error{errors.New("Name is required"), errors.New("Role is required")},
+		},
+		{
+			givenValidator: &TokenValidator{Name: "ci", Role: "root"},
+			wantErrors:     []error{errors.New("Role is not a valid role")},
+		},
+		{
+			givenValidator: &TokenValidator{Name: "ci", Role: job.RoleAdmin, Namespaces: []string{"Payments"}},
+			wantErrors:     []error{errors.New("Namespaces have a not valid namespace name")},
+		},
+		{
+			givenValidator: &TokenValidator{Name: "ci", Role: job.RoleAdmin, Selector: "team in (web"},
+			wantErrors:     []error{errors.New("Selector is not a valid label selector")},
+		},
+		{
+			givenValidator: &TokenValidator{Name: "ci", Role: job.RoleAdmin, ExpiresAt: "tomorrow"},
+			wantErrors:     []error{errors.New("ExpiresAt is not a valid RFC3339 time")},
+		},
+		{
+			givenValidator: &TokenValidator{Name: "ci", Role: job.RoleAdmin, ExpiresAt: "2017-01-01T00:00:00Z"},
+			wantErrors:     []error{errors.New("ExpiresAt should be in the future")},
+		},
+	}
+
+	for _, test := range tests {
+		test.givenValidator.Validate()
+		if !reflect.DeepEqual(test.givenValidator.Errors, test.wantErrors) {
+			t.Errorf("Errors are not equal; expected %v; got %v", test.wantErrors, test.givenValidator.Errors)
+		}
+	}
+}
+
+func TestTokenValidatorInstance(t *testing.T) {
+	v, err := NewTokenValidatorFromJSON(`{"name": "ci", "role": "operator", "selector": "team=payments", "expiresAt": "2099-01-01T02:00:00+02:00"}`)
+	if err != nil {
+		t.Fatalf("Validator should be created: %v", err)
+	}
+
+	got, err := v.Instance()
+	if err != nil {
+		t.Errorf("Instance should be valid: %v", err)
+	}
+	wantExpiresAt := time.Date(2099, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if got.Name != "ci" || got.Role != job.RoleOperator || got.Selector != "team=payments" || got.CreatedAt.IsZero() {
+		t.Errorf("Token is not valid; got %+v", got)
+	}
+	if got.ExpiresAt == nil || !got.ExpiresAt.Equal(wantExpiresAt) {
+		t.Errorf("Token expiration is not valid; expected %v; got %v", wantExpiresAt, got.ExpiresAt)
+	}
+	if len(got.Hash) != 0 || got.ID != "" {
+		t.Errorf("Token shouldn't have a secret; got %+v", got)
+	}
+}
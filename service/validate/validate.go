@@ -3,15 +3,48 @@ package validate
 
 import (
 	"errors"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/robfig/cron"
+
+	"github.com/slok/khronos/job"
 )
 
 const (
-	notValidCron = "Invalid cron syntax"
-	required     = "Required value"
+	notValidCron       = "Invalid cron syntax"
+	notValidHTTPMethod = "Invalid HTTP method"
+	notValidTimeout    = "Invalid timeout"
+	notValidPolicy     = "Invalid concurrency policy"
+	notValidTimeZone   = "Invalid time zone"
+	notValidTime       = "Invalid time"
+	notValidCatchUp    = "Invalid catch up policy"
+	notValidJitterMode = "Invalid jitter mode"
+	notValidLabelKey   = "Invalid label key"
+	notValidLabelValue = "Invalid label value"
+	required           = "Required value"
 )
 
+// validHTTPMethods are the methods that a job can use to make the HTTP call
+var validHTTPMethods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+
+// validConcurrencies are the concurrency policies that a job can use
+var validConcurrencies = []string{job.ConcurrencyAllow, job.ConcurrencyForbid, job.ConcurrencyReplace}
+
+// validCatchUps are the missed runs policies that a job can use
+var validCatchUps = []string{job.CatchUpSkip, job.CatchUpOnce, job.CatchUpAll}
+
+// validJitterModes are the jitter modes that a job can use
+var validJitterModes = []string{job.JitterRandom, job.JitterHash}
+
+// labelNameRe is the syntax of the label names and values, alphanumerics with
+// - _ . in the middle
+var labelNameRe = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+// labelPrefixRe is the syntax of the optional DNS subdomain prefix of the label keys
+var labelPrefixRe = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
 // Validator validates properties of a validator object
 type Validator interface {
 	Validate() error
@@ -25,3 +58,102 @@ func ValidCron(value string) error {
 	}
 	return nil
 }
+
+// ValidHTTPMethod checks if the value is a valid HTTP method for a job (case insensitive)
+func ValidHTTPMethod(value string) error {
+	m := strings.ToUpper(value)
+	for _, v := range validHTTPMethods {
+		if v == m {
+			return nil
+		}
+	}
+	return errors.New(notValidHTTPMethod)
+}
+
+// ValidTimeout checks if the value is a valid positive duration (e.g 30s, 5m, 1h30m)
+func ValidTimeout(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return errors.New(notValidTimeout)
+	}
+	return nil
+}
+
+// ValidConcurrency checks if the value is a valid concurrency policy for a job
+func ValidConcurrency(value string) error {
+	for _, v := range validConcurrencies {
+		if v == value {
+			return nil
+		}
+	}
+	return errors.New(notValidPolicy)
+}
+
+// ValidTimeZone checks if the value is a time zone of the tz database (e.g
+// Europe/Madrid, America/New_York, UTC), the server local time is not allowed
+func ValidTimeZone(value string) error {
+	if value == "" || value == "Local" {
+		return errors.New(notValidTimeZone)
+	}
+	if _, err := time.LoadLocation(value); err != nil {
+		return errors.New(notValidTimeZone)
+	}
+	return nil
+}
+
+// ValidTime checks if the value is a valid RFC3339 time (e.g 2017-11-03T02:00:00Z)
+func ValidTime(value string) error {
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return errors.New(notValidTime)
+	}
+	return nil
+}
+
+// ValidCatchUp checks if the value is a valid missed runs policy for a job
+func ValidCatchUp(value string) error {
+	for _, v := range validCatchUps {
+		if v == value {
+			return nil
+		}
+	}
+	return errors.New(notValidCatchUp)
+}
+
+// ValidJitterMode checks if the value is a valid jitter mode for a job
+func ValidJitterMode(value string) error {
+	for _, v := range validJitterModes {
+		if v == value {
+			return nil
+		}
+	}
+	return errors.New(notValidJitterMode)
+}
+
+// ValidLabelKey checks if the value is a valid label key, a name of up to 63
+// characters with an optional DNS subdomain prefix (e.g team, example.org/team)
+func ValidLabelKey(value string) error {
+	name := value
+	if i := strings.LastIndex(value, "/"); i >= 0 {
+		prefix := value[:i]
+		if len(prefix) > 253 || !labelPrefixRe.MatchString(prefix) {
+			return errors.New(notValidLabelKey)
+		}
+		name = value[i+1:]
+	}
+	if len(name) > 63 || !labelNameRe.MatchString(name) {
+		return errors.New(notValidLabelKey)
+	}
+	return nil
+}
+
+// ValidLabelValue checks if the value is a valid label value, empty or a name
+// of up to 63 characters
+func ValidLabelValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > 63 || !labelNameRe.MatchString(value) {
+		return errors.New(notValidLabelValue)
+	}
+	return nil
+}
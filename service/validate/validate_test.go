@@ -1,6 +1,9 @@
 package validate
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestValidCron(t *testing.T) {
 
@@ -29,3 +32,246 @@ func TestValidCron(t *testing.T) {
 		}
 	}
 }
+
+func TestValidHTTPMethod(t *testing.T) {
+
+	tests := []struct {
+		givenMethod string
+		wantError   bool
+	}{
+		{givenMethod: "GET", wantError: false},
+		{givenMethod: "post", wantError: false},
+		{givenMethod: "Delete", wantError: false},
+		{givenMethod: "", wantError: true},
+		{givenMethod: "FETCH", wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidHTTPMethod(test.givenMethod)
+
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should raise error", test.givenMethod)
+		}
+
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not raise error", test.givenMethod)
+		}
+	}
+}
+
+func TestValidTimeout(t *testing.T) {
+
+	tests := []struct {
+		givenTimeout string
+		wantError    bool
+	}{
+		{givenTimeout: "30s", wantError: false},
+		{givenTimeout: "5m", wantError: false},
+		{givenTimeout: "1h30m", wantError: false},
+		{givenTimeout: "0s", wantError: true},
+		{givenTimeout: "-1m", wantError: true},
+		{givenTimeout: "30", wantError: true},
+		{givenTimeout: "", wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidTimeout(test.givenTimeout)
+
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should raise error", test.givenTimeout)
+		}
+
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not raise error", test.givenTimeout)
+		}
+	}
+}
+
+func TestValidConcurrency(t *testing.T) {
+
+	tests := []struct {
+		givenConcurrency string
+		wantError        bool
+	}{
+		{givenConcurrency: "allow", wantError: false},
+		{givenConcurrency: "forbid", wantError: false},
+		{givenConcurrency: "replace", wantError: false},
+		{givenConcurrency: "Allow", wantError: true},
+		{givenConcurrency: "", wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidConcurrency(test.givenConcurrency)
+
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should raise error", test.givenConcurrency)
+		}
+
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not raise error", test.givenConcurrency)
+		}
+	}
+}
+
+func TestValidTimeZone(t *testing.T) {
+
+	tests := []struct {
+		givenTimeZone string
+		wantError     bool
+	}{
+		{givenTimeZone: "Europe/Madrid", wantError: false},
+		{givenTimeZone: "America/New_York", wantError: false},
+		{givenTimeZone: "Asia/Tokyo", wantError: false},
+		{givenTimeZone: "UTC", wantError: false},
+		{givenTimeZone: "Local", wantError: true},
+		{givenTimeZone: "Europe/Nowhere", wantError: true},
+		{givenTimeZone: "", wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidTimeZone(test.givenTimeZone)
+
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should raise error", test.givenTimeZone)
+		}
+
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not raise error", test.givenTimeZone)
+		}
+	}
+}
+
+func TestValidTime(t *testing.T) {
+
+	tests := []struct {
+		givenTime string
+		wantError bool
+	}{
+		{givenTime: "2026-11-03T02:00:00Z", wantError: false},
+		{givenTime: "2026-11-03T02:00:00.5+01:00", wantError: false},
+		{givenTime: "2026-11-03 02:00:00", wantError: true},
+		{givenTime: "tomorrow", wantError: true},
+		{givenTime: "", wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidTime(test.givenTime)
+
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should raise error", test.givenTime)
+		}
+
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not raise error", test.givenTime)
+		}
+	}
+}
+
+func TestValidCatchUp(t *testing.T) {
+
+	tests := []struct {
+		givenCatchUp string
+		wantError    bool
+	}{
+		{givenCatchUp: "skip", wantError: false},
+		{givenCatchUp: "once", wantError: false},
+		{givenCatchUp: "all", wantError: false},
+		{givenCatchUp: "All", wantError: true},
+		{givenCatchUp: "", wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidCatchUp(test.givenCatchUp)
+
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should raise error", test.givenCatchUp)
+		}
+
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not raise error", test.givenCatchUp)
+		}
+	}
+}
+
+func TestValidJitterMode(t *testing.T) {
+
+	tests := []struct {
+		givenJitterMode string
+		wantError       bool
+	}{
+		{givenJitterMode: "random", wantError: false},
+		{givenJitterMode: "hash", wantError: false},
+		{givenJitterMode: "H", wantError: true},
+		{givenJitterMode: "", wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidJitterMode(test.givenJitterMode)
+
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should raise error", test.givenJitterMode)
+		}
+
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not raise error", test.givenJitterMode)
+		}
+	}
+}
+
+func TestValidLabelKey(t *testing.T) {
+
+	tests := []struct {
+		givenKey  string
+		wantError bool
+	}{
+		{givenKey: "team", wantError: false},
+		{givenKey: "app.kubernetes_io-name", wantError: false},
+		{givenKey: "example.org/team", wantError: false},
+		{givenKey: "", wantError: true},
+		{givenKey: "team-", wantError: true},
+		{givenKey: "te am", wantError: true},
+		{givenKey: "Example.org/team", wantError: true},
+		{givenKey: "/team", wantError: true},
+		{givenKey: "example.org/", wantError: true},
+		{givenKey: strings.Repeat("a", 64), wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidLabelKey(test.givenKey)
+
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should raise error", test.givenKey)
+		}
+
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not raise error", test.givenKey)
+		}
+	}
+}
+
+func TestValidLabelValue(t *testing.T) {
+
+	tests := []struct {
+		givenValue string
+		wantError  bool
+	}{
+		{givenValue: "payments", wantError: false},
+		{givenValue: "", wantError: false},
+		{givenValue: "v1.2_3-a", wantError: false},
+		{givenValue: "a/b", wantError: true},
+		{givenValue: "_a", wantError: true},
+		{givenValue: strings.Repeat("a", 64), wantError: true},
+	}
+
+	for _, test := range tests {
+		err := ValidLabelValue(test.givenValue)
+
+		if test.wantError && err == nil {
+			t.Errorf("'%s' should raise error", test.givenValue)
+		}
+
+		if !test.wantError && err != nil {
+			t.Errorf("'%s' should not raise error", test.givenValue)
+		}
+	}
+}
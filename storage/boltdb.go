@@ -1,42 +1,98 @@
 /*
 BoltDB storage for jobs and results consist on.
 
+Namespaces are stored in a bucket named "namespaces"; in this bucket every namespace
+has its own bucket named as the namespace with the namespace definition on the
+"namespace" key and the buckets of the jobs, results, job labels, secrets and tokens
+of the namespace. The ID sequence of the jobs is shared by all the namespaces.
 Jobs are stored in a bucket named "jobs"; in this bucket the job key is an incremental ID.
 Results are stored in a bucket named "results", in this bucket will be more buckets
 named "job:ID:results" that will have the results identified with an incremental ID.
-Tokens are stored in a bucket named "authTokens"; in this bucket the key will be the
-token itself, and the valud of the key will be an empty byte array, we only need to store
-the keys, if a key is present then is a valid authentication key
+Job labels are indexed in a bucket named "jobLabels"; in this bucket will be more
+buckets named "key=value" that will have the IDs of the jobs with the label as keys.
+Workflow runs are stored in a bucket named "workflowRuns"; in this bucket the key is
+an incremental ID.
+Calendars are stored in a bucket named "calendars"; in this bucket the key is an
+incremental ID.
+Host limits are stored in a bucket named "hostLimits"; in this bucket the key is
+the host.
+Secrets are stored in a bucket named "secrets"; in this bucket the key is the secret
+name, the values of the secrets are stored encrypted.
+Tokens are stored in a bucket named "authTokens"; in this bucket the key is the token
+ID, and the value of the key is the token with the salted hash of its secret, the
+secrets aren't stored.
 
 .
-├── authTokens
-│   ├── 321ndldl233n32dj43
-│   └── fdsf233d32cc656vsd
-├── jobs
-│   ├── 1
-│   ├── 2
-│   └── 3
-└── results
-   ├── job:1:results
-   │   ├── 1
-   │   ├── 2
-   │   └── 3
-   ├── job:2:results
-   │   └── 1
-   └── job:3:results
-	   ├── 1
-	   └── 2
-
+├── calendars
+│   ├── 1
+│   └── 2
+├── hostLimits
+│   ├── api.example.org
+│   └── example.org:8080
+├── namespaces
+│   ├── default
+│   │   ├── authTokens
+│   │   │   ├── 3c1d5a8e0b7f2964
+│   │   │   └── f0a2b4c6d8e01357
+│   │   ├── jobLabels
+│   │   │   ├── team=payments
+│   │   │   │   ├── 1
+│   │   │   │   └── 3
+│   │   │   └── team=search
+│   │   │       └── 2
+│   │   ├── jobs
+│   │   │   ├── 1
+│   │   │   ├── 2
+│   │   │   └── 3
+│   │   ├── namespace
+│   │   ├── results
+│   │   │   ├── job:1:results
+│   │   │   │   ├── 1
+│   │   │   │   ├── 2
+│   │   │   │   └── 3
+│   │   │   ├── job:2:results
+│   │   │   │   └── 1
+│   │   │   └── job:3:results
+│   │   │       ├── 1
+│   │   │       └── 2
+│   │   └── secrets
+│   │       ├── api-token
+│   │       └── db-password
+│   └── payments
+│       ├── authTokens
+│       │   └── 9ddf4c0b1a23e5f7
+│       ├── jobLabels
+│       ├── jobs
+│       │   └── 4
+│       ├── namespace
+│       ├── results
+│       │   └── job:4:results
+│       │       └── 1
+│       └── secrets
+│           └── api-token
+└── workflowRuns
+    ├── 1
+    └── 2
+
+Before the namespaces the jobs, results, job labels and tokens buckets were at the
+top level, they are moved to the default namespace when the database is opened.
+Before the secrets of the namespaces the secrets bucket was at the top level, it is
+moved to the default namespace when the database is opened.
+Before the hashes the tokens were stored in clear text as the keys, with an empty
+value before the roles (admin tokens), they are stored again by ID with the hash
+of the previous key when the database is opened.
 
 */
 
 package storage
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -46,12 +102,23 @@ import (
 )
 
 const (
-	jobsBucket        = "jobs"
-	resultsBucket     = "results"
-	jobResultsBuckets = "job:%s:results"
-	tokensBucket      = "authTokens"
+	namespacesBucket   = "namespaces"
+	namespaceKey       = "namespace"
+	jobsBucket         = "jobs"
+	resultsBucket      = "results"
+	jobResultsBuckets  = "job:%s:results"
+	tokensBucket       = "authTokens"
+	workflowRunsBucket = "workflowRuns"
+	calendarsBucket    = "calendars"
+	hostLimitsBucket   = "hostLimits"
+	secretsBucket      = "secrets"
+	jobLabelsBucket    = "jobLabels"
+	jobLabelBuckets    = "%s=%s"
 )
 
+// namespaceBuckets are the buckets of the data that belongs to a namespace
+var namespaceBuckets = []string{jobsBucket, resultsBucket, jobLabelsBucket, tokensBucket, secretsBucket}
+
 // BoltDB client to store jobs on database
 type BoltDB struct {
 	BoltPath string
@@ -83,22 +150,91 @@ func NewBoltDB(path string, timeout time.Duration) (*BoltDB, error) {
 	}
 
 	// Create top level buckets if necessary
-	db.Update(func(tx *bolt.Tx) error {
+	err = db.Update(func(tx *bolt.Tx) error {
+
+		if _, err := tx.CreateBucketIfNotExists([]byte(namespacesBucket)); err != nil {
+			return fmt.Errorf("error creating bucket: %s", err)
+		}
 
-		if _, err := tx.CreateBucketIfNotExists([]byte(jobsBucket)); err != nil {
+		if _, err := tx.CreateBucketIfNotExists([]byte(workflowRunsBucket)); err != nil {
 			return fmt.Errorf("error creating bucket: %s", err)
 		}
 
-		if _, err := tx.CreateBucketIfNotExists([]byte(resultsBucket)); err != nil {
+		if _, err := tx.CreateBucketIfNotExists([]byte(calendarsBucket)); err != nil {
 			return fmt.Errorf("error creating bucket: %s", err)
 		}
 
-		if _, err := tx.CreateBucketIfNotExists([]byte(tokensBucket)); err != nil {
+		if _, err := tx.CreateBucketIfNotExists([]byte(hostLimitsBucket)); err != nil {
 			return fmt.Errorf("error creating bucket: %s", err)
 		}
 
+		// The default namespace is always present
+		if _, err := createNamespaceBucket(tx, &job.Namespace{Name: job.DefaultNamespace, CreatedAt: time.Now().UTC()}); err != nil {
+			return fmt.Errorf("error creating default namespace: %s", err)
+		}
+
+		// Move the secrets stored before the secrets of the namespaces to the
+		// default namespace
+		if tx.Bucket([]byte(secretsBucket)) != nil {
+			if err := migrateDefaultNamespaceSecrets(tx); err != nil {
+				return fmt.Errorf("error moving secrets to default namespace: %s", err)
+			}
+		}
+
+		// Move the data stored before the namespaces to the default namespace
+		if tx.Bucket([]byte(jobsBucket)) != nil {
+			if err := migrateDefaultNamespace(tx); err != nil {
+				return fmt.Errorf("error moving data to default namespace: %s", err)
+			}
+		}
+
+		// The index of the job labels is built from the stored jobs the
+		// first time, the buckets can't be modified while iterating them
+		nssB := tx.Bucket([]byte(namespacesBucket))
+		nss, unindexed := [][]byte{}, [][]byte{}
+		nssB.ForEach(func(k, v []byte) error {
+			if nsB := nssB.Bucket(k); nsB != nil {
+				nss = append(nss, copyBytes(k))
+				if nsB.Bucket([]byte(jobLabelsBucket)) == nil {
+					unindexed = append(unindexed, copyBytes(k))
+				}
+			}
+			return nil
+		})
+		for _, ns := range unindexed {
+			nsB := nssB.Bucket(ns)
+			lb, err := nsB.CreateBucket([]byte(jobLabelsBucket))
+			if err != nil {
+				return fmt.Errorf("error creating bucket: %s", err)
+			}
+			err = nsB.Bucket([]byte(jobsBucket)).ForEach(func(k, v []byte) error {
+				return indexJobLabels(lb, int(binary.BigEndian.Uint64(k)), nil, jobLabels(v))
+			})
+			if err != nil {
+				return fmt.Errorf("error indexing job labels: %s", err)
+			}
+		}
+
+		// Hash the tokens stored in clear text
+		for _, ns := range nss {
+			if err := hashClearTokens(nssB.Bucket(ns).Bucket([]byte(tokensBucket))); err != nil {
+				return fmt.Errorf("error hashing tokens: %s", err)
+			}
+		}
+
+		// The namespaces created before the secrets of the namespaces don't
+		// have their bucket
+		for _, ns := range nss {
+			if _, err := nssB.Bucket(ns).CreateBucketIfNotExists([]byte(secretsBucket)); err != nil {
+				return fmt.Errorf("error creating bucket: %s", err)
+			}
+		}
 		return nil
 	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	// Create our client
 	c := &BoltDB{
@@ -111,51 +247,323 @@ func NewBoltDB(path string, timeout time.Duration) (*BoltDB, error) {
 	return c, nil
 }
 
+// createNamespaceBucket creates the bucket of a namespace with the buckets of
+// its data if it doesn't exist
+func createNamespaceBucket(tx *bolt.Tx, ns *job.Namespace) (*bolt.Bucket, error) {
+	nssB := tx.Bucket([]byte(namespacesBucket))
+	if nsB := nssB.Bucket([]byte(ns.Name)); nsB != nil {
+		return nsB, nil
+	}
+
+	nsB, err := nssB.CreateBucket([]byte(ns.Name))
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range namespaceBuckets {
+		if _, err := nsB.CreateBucket([]byte(b)); err != nil {
+			return nil, err
+		}
+	}
+	buf, err := json.Marshal(ns)
+	if err != nil {
+		return nil, err
+	}
+	return nsB, nsB.Put([]byte(namespaceKey), buf)
+}
+
+// hashClearTokens stores again the tokens stored in clear text by ID with the
+// salted hash of the previous key, the tokens without role are admin tokens
+// and every token is named after its new ID and flagged as legacy
+func hashClearTokens(tb *bolt.Bucket) error {
+	clear := map[string]*job.Token{}
+	err := tb.ForEach(func(k, v []byte) error {
+		t := &job.Token{}
+		if len(v) > 0 {
+			if err := json.Unmarshal(v, t); err != nil {
+				return err
+			}
+		}
+		if len(t.Hash) == 0 {
+			clear[string(k)] = t
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for k, t := range clear {
+		id, err := job.NewTokenID()
+		if err != nil {
+			return err
+		}
+		if err := t.SetSecret(k); err != nil {
+			return err
+		}
+		t.ID = id
+		t.Name = fmt.Sprintf("migrated-%s", id)
+		t.Legacy = true
+		t.CreatedAt = time.Now().UTC()
+		if t.Role == "" {
+			t.Role = job.RoleAdmin
+		}
+
+		buf, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		if err := tb.Delete([]byte(k)); err != nil {
+			return err
+		}
+		if err := tb.Put([]byte(t.ID), buf); err != nil {
+			return err
+		}
+	}
+	if len(clear) > 0 {
+		logrus.Infof("Hashed '%d' tokens stored in clear text", len(clear))
+	}
+	return nil
+}
+
+// namespaceBucket returns the bucket of a namespace
+func namespaceBucket(tx *bolt.Tx, ns string) (*bolt.Bucket, error) {
+	nsB := tx.Bucket([]byte(namespacesBucket)).Bucket([]byte(ns))
+	if nsB == nil {
+		return nil, fmt.Errorf("namespace '%s' does not exists", ns)
+	}
+	return nsB, nil
+}
+
+// migrateDefaultNamespace moves the top level buckets of the jobs, results and
+// tokens to the default namespace, the jobs are set on the default namespace
+func migrateDefaultNamespace(tx *bolt.Tx) error {
+	nsB, err := namespaceBucket(tx, job.DefaultNamespace)
+	if err != nil {
+		return err
+	}
+
+	// The job IDs keep going from the last one
+	jobsB := tx.Bucket([]byte(jobsBucket))
+	if err := tx.Bucket([]byte(namespacesBucket)).SetSequence(jobsB.Sequence()); err != nil {
+		return err
+	}
+	err = jobsB.ForEach(func(k, v []byte) error {
+		j := &job.Job{}
+		if err := json.Unmarshal(v, j); err != nil {
+			return err
+		}
+		j.Namespace = job.DefaultNamespace
+		buf, err := json.Marshal(j)
+		if err != nil {
+			return err
+		}
+		return nsB.Bucket([]byte(jobsBucket)).Put(copyBytes(k), buf)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, b := range []string{resultsBucket, tokensBucket} {
+		if old := tx.Bucket([]byte(b)); old != nil {
+			if err := copyBucket(old, nsB.Bucket([]byte(b))); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The labels index is built again from the moved jobs
+	if err := nsB.DeleteBucket([]byte(jobLabelsBucket)); err != nil {
+		return err
+	}
+
+	for _, b := range namespaceBuckets {
+		if tx.Bucket([]byte(b)) != nil {
+			if err := tx.DeleteBucket([]byte(b)); err != nil {
+				return err
+			}
+		}
+	}
+	logrus.Infof("Moved the stored jobs, results and tokens to the '%s' namespace", job.DefaultNamespace)
+	return nil
+}
+
+// migrateDefaultNamespaceSecrets moves the secrets of the top level bucket to
+// the default namespace
+func migrateDefaultNamespaceSecrets(tx *bolt.Tx) error {
+	nsB, err := namespaceBucket(tx, job.DefaultNamespace)
+	if err != nil {
+		return err
+	}
+	secB, err := nsB.CreateBucketIfNotExists([]byte(secretsBucket))
+	if err != nil {
+		return err
+	}
+	if err := copyBucket(tx.Bucket([]byte(secretsBucket)), secB); err != nil {
+		return err
+	}
+	if err := tx.DeleteBucket([]byte(secretsBucket)); err != nil {
+		return err
+	}
+	logrus.Infof("Moved the stored secrets to the '%s' namespace", job.DefaultNamespace)
+	return nil
+}
+
+// copyBucket copies the keys and nested buckets of a bucket to another one
+func copyBucket(src, dst *bolt.Bucket) error {
+	if err := dst.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+	return src.ForEach(func(k, v []byte) error {
+		// The nested buckets have nil value
+		if v == nil {
+			nb, err := dst.CreateBucketIfNotExists(copyBytes(k))
+			if err != nil {
+				return err
+			}
+			return copyBucket(src.Bucket(k), nb)
+		}
+		return dst.Put(copyBytes(k), copyBytes(v))
+	})
+}
+
+// copyBytes returns a copy of the bytes, the ones returned by boltdb are only
+// valid during the transaction
+func copyBytes(b []byte) []byte {
+	return append([]byte{}, b...)
+}
+
 // Close closes boltdb connection to database
 func (c *BoltDB) Close() error {
 	return c.Close()
 }
 
-// GetJobs returns all the HTTP jobs from boltdb. Use low and high params as slice operator
-func (c *BoltDB) GetJobs(low, high int) ([]*job.Job, error) {
-	jobs := []*job.Job{}
+// GetNamespaces returns all the namespaces from boltdb ordered by name
+func (c *BoltDB) GetNamespaces() ([]*job.Namespace, error) {
+	nss := []*job.Namespace{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		nssB := tx.Bucket([]byte(namespacesBucket))
+		return nssB.ForEach(func(k, v []byte) error {
+			ns := &job.Namespace{}
+			if err := json.Unmarshal(nssB.Bucket(k).Get([]byte(namespaceKey)), ns); err != nil {
+				return err
+			}
+			nss = append(nss, ns)
+			return nil
+		})
+	})
 
-	// In database id start from one, not 0, so we convert to help in the logic
-	low++
-	high++
+	if err != nil {
+		logrus.Errorf("error retrieving namespaces form boltdb: %v", err)
+		return nil, err
+	}
+
+	logrus.Debugf("Retrieved '%d' namespaces from boltdb without errors", len(nss))
+	return nss, nil
+}
+
+// GetNamespace returns a namespace from boltdb by name
+func (c *BoltDB) GetNamespace(name string) (*job.Namespace, error) {
+	ns := &job.Namespace{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, name)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(nsB.Get([]byte(namespaceKey)), ns)
+	})
+
+	if err != nil {
+		logrus.Errorf("error retrieving namespace '%s' form boltdb: %v", name, err)
+		return nil, err
+	}
+
+	logrus.Debugf("Namespace '%s' retrieved from boltdb", name)
+	return ns, nil
+}
+
+// SaveNamespace stores a namespace on boltdb, creating its buckets if new
+func (c *BoltDB) SaveNamespace(ns *job.Namespace) error {
+	if ns.Name == "" {
+		return errors.New("wrong namespace parameter")
+	}
+
+	err := c.DB.Update(func(tx *bolt.Tx) error {
+		nsB, err := createNamespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		buf, err := json.Marshal(ns)
+		if err != nil {
+			return err
+		}
+		return nsB.Put([]byte(namespaceKey), buf)
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error storing namespace '%s': %v", ns.Name, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Stored namespace '%s' boltdb", ns.Name)
+	return nil
+}
+
+// DeleteNamespace deletes a namespace with all its jobs, results and tokens
+// from boltdb, doesn't return error if the namespace doesn't exist
+func (c *BoltDB) DeleteNamespace(ns *job.Namespace) error {
+	err := c.DB.Update(func(tx *bolt.Tx) error {
+		nssB := tx.Bucket([]byte(namespacesBucket))
+		if nssB.Bucket([]byte(ns.Name)) == nil {
+			return nil
+		}
+		return nssB.DeleteBucket([]byte(ns.Name))
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error deleting namespace '%s': %v", ns.Name, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Namespace '%s' deleted boltdb", ns.Name)
+	return nil
+}
+
+// GetJobs returns the HTTP jobs of a namespace from boltdb. Use low and high
+// params as slice operator
+func (c *BoltDB) GetJobs(ns string, low, high int) ([]*job.Job, error) {
+	jobs := []*job.Job{}
 
 	// if low and high the same then return empty slice (not when asking for all)
-	if low != 1 && low == high {
+	if low != 0 && low == high {
 		return jobs, nil
 	}
 
 	// Check indexes ok
-	if high != 1 && low >= high {
+	if high != 0 && low >= high {
 		return nil, errors.New("wrong parameters")
 	}
 
-	// Get all asked jobs
+	// Get all asked jobs, the IDs of a namespace are not consecutive so the
+	// jobs are skipped until the first one
 	err := c.DB.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(jobsBucket))
-		c := b.Cursor()
-
-		var lastKey []byte
-		if high == 1 {
-			lastKey = nil
-
-		} else {
-			lastKey = idToByte(high)
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
 		}
-
-		firstKey := idToByte(low)
-
-		// Until we reach the number asked for (we compare that the returned key is not the last one or is nil, that means the end)
-		for k, v := c.Seek([]byte(firstKey)); k != nil && string(k) != string(lastKey); k, v = c.Next() {
-			j := &job.Job{}
-			if err := json.Unmarshal(v, j); err != nil {
-				return err
+		c := nsB.Bucket([]byte(jobsBucket)).Cursor()
+
+		i := 0
+		for k, v := c.First(); k != nil && (high == 0 || i < high); k, v = c.Next() {
+			if i >= low {
+				j := &job.Job{}
+				if err := json.Unmarshal(v, j); err != nil {
+					return err
+				}
+				jobs = append(jobs, j)
 			}
-			jobs = append(jobs, j)
+			i++
 		}
 		return nil
 	})
@@ -167,7 +575,7 @@ func (c *BoltDB) GetJobs(low, high int) ([]*job.Job, error) {
 
 	// return error if not retrieved all asked for (if high is 0 means: want all from low,
 	// doesn't matter how many, so in this case no errors)
-	if high > 1 && len(jobs) != high-low {
+	if high > 0 && len(jobs) != high-low {
 		return jobs, fmt.Errorf("error retrieving all asked for; expected: %d; got: %d", high-low, len(jobs))
 	}
 
@@ -175,13 +583,17 @@ func (c *BoltDB) GetJobs(low, high int) ([]*job.Job, error) {
 	return jobs, nil
 }
 
-// GetJob returns an specific HTTP job based on the ID
-func (c *BoltDB) GetJob(id int) (*job.Job, error) {
+// GetJob returns an specific HTTP job of a namespace based on the ID
+func (c *BoltDB) GetJob(ns string, id int) (*job.Job, error) {
 	j := &job.Job{}
 	err := c.DB.View(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+
 		// Get job from job bucket
-		b := tx.Bucket([]byte(jobsBucket))
-		jb := b.Get(idToByte(id))
+		jb := nsB.Bucket([]byte(jobsBucket)).Get(idToByte(id))
 
 		// Check if job is present
 		if jb == nil {
@@ -196,7 +608,6 @@ func (c *BoltDB) GetJob(id int) (*job.Job, error) {
 	})
 
 	if err != nil {
-		logrus.Errorf("error retrieving job '%d' form boltdb: %v", id, err)
 		return nil, err
 	}
 
@@ -204,15 +615,20 @@ func (c *BoltDB) GetJob(id int) (*job.Job, error) {
 	return j, nil
 }
 
-// SaveJob stores an HTTP job on boltdb
+// SaveJob stores an HTTP job on its namespace on boltdb
 func (c *BoltDB) SaveJob(j *job.Job) error {
 	err := c.DB.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(jobsBucket))
+		nsB, err := namespaceBucket(tx, j.NamespaceOrDefault())
+		if err != nil {
+			return err
+		}
+		b := nsB.Bucket([]byte(jobsBucket))
 
 		// Create a new ID for the new job, not new ID if it has already (update)
-		// Starts in 1, so its safe to check with 0
+		// Starts in 1, so its safe to check with 0. The IDs are unique across
+		// the namespaces
 		if j.ID == 0 {
-			id, _ := b.NextSequence()
+			id, _ := tx.Bucket([]byte(namespacesBucket)).NextSequence()
 			j.ID = int(id)
 		}
 
@@ -222,8 +638,13 @@ func (c *BoltDB) SaveJob(j *job.Job) error {
 			return err
 		}
 
-		// save as always (insert or update doesn't matter)
+		// Replace the labels of the previous version on the index
 		key := idToByte(j.ID)
+		if err := indexJobLabels(nsB.Bucket([]byte(jobLabelsBucket)), j.ID, jobLabels(b.Get(key)), j.Labels); err != nil {
+			return err
+		}
+
+		// save as always (insert or update doesn't matter)
 		return b.Put(key, buf)
 	})
 
@@ -237,39 +658,237 @@ func (c *BoltDB) SaveJob(j *job.Job) error {
 	return nil
 }
 
+// SetLastScheduledRun updates the last activation time of a job schedule on
+// boltdb in the same transaction it reads the stored job
+func (c *BoltDB) SetLastScheduledRun(ns string, id int, t time.Time) error {
+	err := c.DB.Update(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		b := nsB.Bucket([]byte(jobsBucket))
+
+		key := idToByte(id)
+		jb := b.Get(key)
+		if jb == nil {
+			return errors.New("job does not exists")
+		}
+		j := &job.Job{}
+		if err := json.Unmarshal(jb, j); err != nil {
+			return err
+		}
+
+		t = t.UTC()
+		j.LastScheduledRun = &t
+		buf, err := json.Marshal(j)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, buf)
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error storing last scheduled run of job '%d': %v", id, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Stored last scheduled run of job '%d' boltdb", id)
+	return nil
+}
+
 // DeleteJob deletes an HTTP job and all its results from boltdb, doesn't return error if job doesn't exists
 func (c *BoltDB) DeleteJob(j *job.Job) error {
 	err := c.DB.Update(func(tx *bolt.Tx) error {
-		// Delete job
-		tx.Bucket([]byte(jobsBucket)).Delete(idToByte(j.ID))
+		nsB, err := namespaceBucket(tx, j.NamespaceOrDefault())
+		if err != nil {
+			return err
+		}
+
+		// Delete job and its labels from the index
+		b := nsB.Bucket([]byte(jobsBucket))
+		if err := indexJobLabels(nsB.Bucket([]byte(jobLabelsBucket)), j.ID, jobLabels(b.Get(idToByte(j.ID))), nil); err != nil {
+			return err
+		}
+		b.Delete(idToByte(j.ID))
 
 		// Delete all job results
-		resB := tx.Bucket([]byte(resultsBucket))
+		resB := nsB.Bucket([]byte(resultsBucket))
 		jobresKey := fmt.Sprintf(jobResultsBuckets, string(idToByte(j.ID)))
 		// Ignore error if bucket doesn't exists
 		resB.DeleteBucket([]byte(jobresKey))
 		return nil
 	})
 
-	if err != nil {
-		err = fmt.Errorf("error deleting job '%d': %v", j.ID, err)
-		logrus.Error(err.Error())
-		return err
+	if err != nil {
+		err = fmt.Errorf("error deleting job '%d': %v", j.ID, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Job '%d' deleted boltdb", j.ID)
+	return nil
+}
+
+// GetJobIDs returns the IDs of the jobs of a namespace matching the label
+// selector from boltdb, the jobs are selected with the labels index without
+// reading them
+func (c *BoltDB) GetJobIDs(ns string, sel job.Selector) ([]int, error) {
+	ids := []int{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		lb := nsB.Bucket([]byte(jobLabelsBucket))
+
+		// All the job IDs from the keys, needed by the negated requirements
+		all := map[int]bool{}
+		nsB.Bucket([]byte(jobsBucket)).ForEach(func(k, v []byte) error {
+			all[int(binary.BigEndian.Uint64(k))] = true
+			return nil
+		})
+
+		matched := all
+		for _, r := range sel {
+			var rIDs map[int]bool
+			switch r.Operator {
+			case job.SelectorEquals, job.SelectorIn:
+				rIDs = labelIDs(lb, r.Key, r.Values)
+			case job.SelectorNotEquals, job.SelectorNotIn:
+				rIDs = exceptIDs(all, labelIDs(lb, r.Key, r.Values))
+			case job.SelectorExists:
+				rIDs = labelIDs(lb, r.Key, nil)
+			case job.SelectorNotExists:
+				rIDs = exceptIDs(all, labelIDs(lb, r.Key, nil))
+			default:
+				return fmt.Errorf("not valid selector operator '%s'", r.Operator)
+			}
+
+			// Every requirement should match
+			next := map[int]bool{}
+			for id := range rIDs {
+				if matched[id] {
+					next[id] = true
+				}
+			}
+			matched = next
+		}
+
+		for id := range matched {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error getting job IDs: %v", err)
+		logrus.Error(err.Error())
+		return nil, err
+	}
+
+	sort.Ints(ids)
+	logrus.Debugf("Retrieved %d job IDs from boltdb", len(ids))
+	return ids, nil
+}
+
+// jobLabels returns the labels of a stored job without reading the rest of it,
+// nil if the job doesn't exist
+func jobLabels(buf []byte) map[string]string {
+	if buf == nil {
+		return nil
+	}
+	var l struct{ Labels map[string]string }
+	json.Unmarshal(buf, &l)
+	return l.Labels
+}
+
+// indexJobLabels replaces the old labels of a job with the new ones on the
+// labels index, the index has a bucket for each label key and value with the
+// IDs of the jobs
+func indexJobLabels(lb *bolt.Bucket, id int, oldLabels, newLabels map[string]string) error {
+	key := idToByte(id)
+
+	for k, v := range oldLabels {
+		if nv, ok := newLabels[k]; ok && nv == v {
+			continue
+		}
+		lKey := []byte(fmt.Sprintf(jobLabelBuckets, k, v))
+		if b := lb.Bucket(lKey); b != nil {
+			b.Delete(key)
+			// Don't keep the buckets of unused labels
+			if first, _ := b.Cursor().First(); first == nil {
+				lb.DeleteBucket(lKey)
+			}
+		}
+	}
+
+	for k, v := range newLabels {
+		b, err := lb.CreateBucketIfNotExists([]byte(fmt.Sprintf(jobLabelBuckets, k, v)))
+		if err != nil {
+			return err
+		}
+		if err := b.Put(key, []byte{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// labelIDs returns the IDs of the jobs with the label key and one of the
+// values from the labels index, nil values means any value
+func labelIDs(lb *bolt.Bucket, key string, values []string) map[int]bool {
+	ids := map[int]bool{}
+	add := func(b *bolt.Bucket) {
+		b.ForEach(func(k, v []byte) error {
+			ids[int(binary.BigEndian.Uint64(k))] = true
+			return nil
+		})
+	}
+
+	if values == nil {
+		// The label keys don't have '=' so all the buckets of the key are
+		// together after the key prefix
+		prefix := []byte(fmt.Sprintf(jobLabelBuckets, key, ""))
+		c := lb.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			// The nested buckets have nil value
+			if v == nil {
+				add(lb.Bucket(k))
+			}
+		}
+		return ids
+	}
+
+	for _, v := range values {
+		if b := lb.Bucket([]byte(fmt.Sprintf(jobLabelBuckets, key, v))); b != nil {
+			add(b)
+		}
 	}
+	return ids
+}
 
-	logrus.Debugf("Job '%d' deleted boltdb", j.ID)
-	return nil
+// exceptIDs returns the IDs of all that are not in the excluded ones
+func exceptIDs(all, excluded map[int]bool) map[int]bool {
+	ids := map[int]bool{}
+	for id := range all {
+		if !excluded[id] {
+			ids[id] = true
+		}
+	}
+	return ids
 }
 
-// JobsLength returns the number of jobs stored on boltdb
-func (c *BoltDB) JobsLength() int {
+// JobsLength returns the number of jobs of a namespace stored on boltdb
+func (c *BoltDB) JobsLength(ns string) int {
 	var size int
 	err := c.DB.View(func(tx *bolt.Tx) error {
-		// Get job from job bucket
-		b := tx.Bucket([]byte(jobsBucket))
-		if b == nil {
-			return errors.New("No jobs bucket present")
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
 		}
+		// Get job from job bucket
+		b := nsB.Bucket([]byte(jobsBucket))
 		// Number of keys on boltdb
 		size = b.Stats().KeyN
 		return nil
@@ -305,7 +924,11 @@ func (c *BoltDB) GetResults(j *job.Job, low, high int) ([]*job.Result, error) {
 	// Get all asked jobs
 	err := c.DB.View(func(tx *bolt.Tx) error {
 		// Get main results bucket
-		rsB := tx.Bucket([]byte(resultsBucket))
+		nsB, err := namespaceBucket(tx, j.NamespaceOrDefault())
+		if err != nil {
+			return err
+		}
+		rsB := nsB.Bucket([]byte(resultsBucket))
 		// Get results bucket
 		rbKey := fmt.Sprintf(jobResultsBuckets, string(idToByte(j.ID)))
 		rB := rsB.Bucket([]byte(rbKey))
@@ -362,7 +985,11 @@ func (c *BoltDB) GetResult(j *job.Job, id int) (*job.Result, error) {
 
 	err := c.DB.View(func(tx *bolt.Tx) error {
 		// Get main results bucket
-		rsB := tx.Bucket([]byte(resultsBucket))
+		nsB, err := namespaceBucket(tx, j.NamespaceOrDefault())
+		if err != nil {
+			return err
+		}
+		rsB := nsB.Bucket([]byte(resultsBucket))
 
 		// Get results bucket
 		rbKey := fmt.Sprintf(jobResultsBuckets, string(idToByte(j.ID)))
@@ -397,12 +1024,16 @@ func (c *BoltDB) GetResult(j *job.Job, id int) (*job.Result, error) {
 // SaveResult stores a result of a job on boltdb
 func (c *BoltDB) SaveResult(r *job.Result) error {
 	// First check if the job is present, if not, then error
-	if _, err := c.GetJob(r.Job.ID); err != nil {
+	if _, err := c.GetJob(r.Job.NamespaceOrDefault(), r.Job.ID); err != nil {
 		return err
 	}
 
 	err := c.DB.Update(func(tx *bolt.Tx) error {
-		resB := tx.Bucket([]byte(resultsBucket))
+		nsB, err := namespaceBucket(tx, r.Job.NamespaceOrDefault())
+		if err != nil {
+			return err
+		}
+		resB := nsB.Bucket([]byte(resultsBucket))
 
 		// Create job results bucket if doens't exist
 		jobresKey := fmt.Sprintf(jobResultsBuckets, string(idToByte(r.Job.ID)))
@@ -439,7 +1070,11 @@ func (c *BoltDB) SaveResult(r *job.Result) error {
 // DeleteResult deletes a result from boltdv, doesn't return error if result doesn't exist
 func (c *BoltDB) DeleteResult(r *job.Result) error {
 	err := c.DB.Update(func(tx *bolt.Tx) error {
-		resB := tx.Bucket([]byte(resultsBucket))
+		nsB, err := namespaceBucket(tx, r.Job.NamespaceOrDefault())
+		if err != nil {
+			return err
+		}
+		resB := nsB.Bucket([]byte(resultsBucket))
 		jobresKey := fmt.Sprintf(jobResultsBuckets, string(idToByte(r.Job.ID)))
 		return resB.Bucket([]byte(jobresKey)).Delete(idToByte(r.ID))
 	})
@@ -459,7 +1094,11 @@ func (c *BoltDB) ResultsLength(j *job.Job) int {
 	var size int
 	err := c.DB.View(func(tx *bolt.Tx) error {
 		// Get job from job bucket
-		resB := tx.Bucket([]byte(resultsBucket))
+		nsB, err := namespaceBucket(tx, j.NamespaceOrDefault())
+		if err != nil {
+			return err
+		}
+		resB := nsB.Bucket([]byte(resultsBucket))
 		if resB == nil {
 			return errors.New("No results bucket present")
 		}
@@ -483,38 +1122,418 @@ func (c *BoltDB) ResultsLength(j *job.Job) int {
 	return size
 }
 
-// SaveAuthenticationToken stores an authentication token on boltdb
-func (c *BoltDB) SaveAuthenticationToken(token string) error {
-	if token == "" {
-		return errors.New("wrong token parameter")
+// GetWorkflowRun returns a workflow run from boltdb
+func (c *BoltDB) GetWorkflowRun(id int) (*job.WorkflowRun, error) {
+	w := &job.WorkflowRun{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(workflowRunsBucket))
+		wb := b.Get(idToByte(id))
+
+		// Check if workflow run is present
+		if wb == nil {
+			return errors.New("workflow run does not exists")
+		}
+		return json.Unmarshal(wb, w)
+	})
+
+	if err != nil {
+		logrus.Errorf("error retrieving workflow run '%d' form boltdb: %v", id, err)
+		return nil, err
+	}
+
+	logrus.Debugf("Workflow run '%d' retrieved from boltdb", w.ID)
+	return w, nil
+}
+
+// SaveWorkflowRun stores a workflow run on boltdb
+func (c *BoltDB) SaveWorkflowRun(w *job.WorkflowRun) error {
+	err := c.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(workflowRunsBucket))
+
+		// Create a new ID for the new workflow run, not new ID if it has already (update)
+		// Starts in 1, so its safe to check with 0
+		if w.ID == 0 {
+			id, _ := b.NextSequence()
+			w.ID = int(id)
+		}
+
+		buf, err := json.Marshal(w)
+		if err != nil {
+			return err
+		}
+		return b.Put(idToByte(w.ID), buf)
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error storing workflow run '%d': %v", w.ID, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Stored workflow run '%d' boltdb", w.ID)
+	return nil
+}
+
+// GetCalendars returns all the calendars from boltdb
+func (c *BoltDB) GetCalendars() ([]*job.Calendar, error) {
+	cals := []*job.Calendar{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(calendarsBucket))
+		return b.ForEach(func(k, v []byte) error {
+			cal := &job.Calendar{}
+			if err := json.Unmarshal(v, cal); err != nil {
+				return err
+			}
+			cals = append(cals, cal)
+			return nil
+		})
+	})
+
+	if err != nil {
+		logrus.Errorf("error retrieving calendars form boltdb: %v", err)
+		return nil, err
+	}
+
+	logrus.Debugf("Retrieved '%d' calendars from boltdb without errors", len(cals))
+	return cals, nil
+}
+
+// GetCalendar returns a calendar from boltdb
+func (c *BoltDB) GetCalendar(id int) (*job.Calendar, error) {
+	cal := &job.Calendar{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(calendarsBucket))
+		cb := b.Get(idToByte(id))
+
+		// Check if calendar is present
+		if cb == nil {
+			return errors.New("calendar does not exists")
+		}
+		return json.Unmarshal(cb, cal)
+	})
+
+	if err != nil {
+		logrus.Errorf("error retrieving calendar '%d' form boltdb: %v", id, err)
+		return nil, err
+	}
+
+	logrus.Debugf("Calendar '%d' retrieved from boltdb", cal.ID)
+	return cal, nil
+}
+
+// SaveCalendar stores a calendar on boltdb
+func (c *BoltDB) SaveCalendar(cal *job.Calendar) error {
+	err := c.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(calendarsBucket))
+
+		// Create a new ID for the new calendar, not new ID if it has already (update)
+		// Starts in 1, so its safe to check with 0
+		if cal.ID == 0 {
+			id, _ := b.NextSequence()
+			cal.ID = int(id)
+		}
+
+		buf, err := json.Marshal(cal)
+		if err != nil {
+			return err
+		}
+		return b.Put(idToByte(cal.ID), buf)
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error storing calendar '%d': %v", cal.ID, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Stored calendar '%d' boltdb", cal.ID)
+	return nil
+}
+
+// DeleteCalendar deletes a calendar from boltdb, doesn't return error if calendar doesn't exists
+func (c *BoltDB) DeleteCalendar(cal *job.Calendar) error {
+	err := c.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(calendarsBucket)).Delete(idToByte(cal.ID))
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error deleting calendar '%d': %v", cal.ID, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Calendar '%d' deleted boltdb", cal.ID)
+	return nil
+}
+
+// GetHostLimits returns all the host limits from boltdb
+func (c *BoltDB) GetHostLimits() ([]*job.HostLimit, error) {
+	ls := []*job.HostLimit{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(hostLimitsBucket))
+		return b.ForEach(func(k, v []byte) error {
+			l := &job.HostLimit{}
+			if err := json.Unmarshal(v, l); err != nil {
+				return err
+			}
+			ls = append(ls, l)
+			return nil
+		})
+	})
+
+	if err != nil {
+		logrus.Errorf("error retrieving host limits form boltdb: %v", err)
+		return nil, err
+	}
+
+	logrus.Debugf("Retrieved '%d' host limits from boltdb without errors", len(ls))
+	return ls, nil
+}
+
+// SaveHostLimit stores the limits of a host on boltdb
+func (c *BoltDB) SaveHostLimit(l *job.HostLimit) error {
+	if l.Host == "" {
+		return errors.New("wrong host limit host")
 	}
 
 	err := c.DB.Update(func(tx *bolt.Tx) error {
-		tb := tx.Bucket([]byte(tokensBucket))
-		return tb.Put([]byte(token), nil)
+		buf, err := json.Marshal(l)
+		if err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(hostLimitsBucket)).Put([]byte(l.Host), buf)
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error storing host limit '%s': %v", l.Host, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Stored host limit '%s' boltdb", l.Host)
+	return nil
+}
+
+// DeleteHostLimit deletes the limits of a host from boltdb, doesn't return
+// error if the host doesn't have limits
+func (c *BoltDB) DeleteHostLimit(host string) error {
+	err := c.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(hostLimitsBucket)).Delete([]byte(host))
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error deleting host limit '%s': %v", host, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Host limit '%s' deleted boltdb", host)
+	return nil
+}
+
+// GetSecrets returns all the secrets of a namespace from boltdb
+func (c *BoltDB) GetSecrets(ns string) ([]*job.Secret, error) {
+	secs := []*job.Secret{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		b := nsB.Bucket([]byte(secretsBucket))
+		return b.ForEach(func(k, v []byte) error {
+			sec := &job.Secret{}
+			if err := json.Unmarshal(v, sec); err != nil {
+				return err
+			}
+			secs = append(secs, sec)
+			return nil
+		})
+	})
+
+	if err != nil {
+		logrus.Errorf("error retrieving secrets form boltdb: %v", err)
+		return nil, err
+	}
+
+	logrus.Debugf("Retrieved '%d' secrets from boltdb without errors", len(secs))
+	return secs, nil
+}
+
+// GetSecret returns a secret of a namespace from boltdb
+func (c *BoltDB) GetSecret(ns, name string) (*job.Secret, error) {
+	sec := &job.Secret{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		sb := nsB.Bucket([]byte(secretsBucket)).Get([]byte(name))
+
+		// Check if secret is present
+		if sb == nil {
+			return errors.New("secret does not exists")
+		}
+		return json.Unmarshal(sb, sec)
+	})
+
+	if err != nil {
+		logrus.Errorf("error retrieving secret '%s' form boltdb: %v", name, err)
+		return nil, err
+	}
+
+	logrus.Debugf("Secret '%s' retrieved from boltdb", sec.Name)
+	return sec, nil
+}
+
+// SaveSecret stores a secret of a namespace on boltdb
+func (c *BoltDB) SaveSecret(ns string, sec *job.Secret) error {
+	if sec.Name == "" {
+		return errors.New("wrong secret name")
+	}
+
+	err := c.DB.Update(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		buf, err := json.Marshal(sec)
+		if err != nil {
+			return err
+		}
+		return nsB.Bucket([]byte(secretsBucket)).Put([]byte(sec.Name), buf)
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error storing secret '%s': %v", sec.Name, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Stored secret '%s' boltdb", sec.Name)
+	return nil
+}
+
+// DeleteSecret deletes a secret of a namespace from boltdb, doesn't return
+// error if secret doesn't exists
+func (c *BoltDB) DeleteSecret(ns string, sec *job.Secret) error {
+	err := c.DB.Update(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		return nsB.Bucket([]byte(secretsBucket)).Delete([]byte(sec.Name))
+	})
+
+	if err != nil {
+		err = fmt.Errorf("error deleting secret '%s': %v", sec.Name, err)
+		logrus.Error(err.Error())
+		return err
+	}
+
+	logrus.Debugf("Secret '%s' deleted boltdb", sec.Name)
+	return nil
+}
+
+// GetAuthenticationTokens returns all the authentication tokens of a namespace from boltdb
+func (c *BoltDB) GetAuthenticationTokens(ns string) ([]*job.Token, error) {
+	ts := []*job.Token{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		tb := nsB.Bucket([]byte(tokensBucket))
+		return tb.ForEach(func(k, v []byte) error {
+			t := &job.Token{}
+			if err := json.Unmarshal(v, t); err != nil {
+				return err
+			}
+			ts = append(ts, t)
+			return nil
+		})
+	})
+
+	if err != nil {
+		logrus.Errorf("error retrieving tokens form boltdb: %v", err)
+		return nil, err
+	}
+
+	logrus.Debugf("Retrieved '%d' tokens from boltdb without errors", len(ts))
+	return ts, nil
+}
+
+// GetAuthenticationToken returns an authentication token of a namespace from boltdb
+func (c *BoltDB) GetAuthenticationToken(ns, id string) (*job.Token, error) {
+	t := &job.Token{}
+	err := c.DB.View(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		tb := nsB.Bucket([]byte(tokensBucket))
+		tDb := tb.Get([]byte(id))
+		if tDb == nil {
+			return fmt.Errorf("token '%s' doesn't exists on namespace '%s'", id, ns)
+		}
+		return json.Unmarshal(tDb, t)
+	})
+	if err != nil {
+		logrus.Debugf("Error retrieving token: %v", err)
+		return nil, err
+	}
+	logrus.Debugf("Token '%s' retrieved from boltdb", id)
+	return t, nil
+}
+
+// SaveAuthenticationToken stores an authentication token of a namespace on boltdb
+func (c *BoltDB) SaveAuthenticationToken(ns string, t *job.Token) error {
+	if t.ID == "" {
+		return errors.New("wrong token parameter")
+	}
+
+	buf, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	err = c.DB.Update(func(tx *bolt.Tx) error {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		tb := nsB.Bucket([]byte(tokensBucket))
+		return tb.Put([]byte(t.ID), buf)
 	})
-	logrus.Debugf("New token stored on boltdb")
+	logrus.Debugf("Token '%s' stored on boltdb", t.ID)
 	return err
 }
 
-// DeleteAuthenticationToken Deletes an authentication token from boltdb, doesn't return error if doesnt exists
-func (c *BoltDB) DeleteAuthenticationToken(token string) error {
+// DeleteAuthenticationToken Deletes an authentication token of a namespace from boltdb, doesn't return error if doesnt exists
+func (c *BoltDB) DeleteAuthenticationToken(ns, id string) error {
 	err := c.DB.Update(func(tx *bolt.Tx) error {
-		tb := tx.Bucket([]byte(tokensBucket))
-		return tb.Delete([]byte(token))
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		tb := nsB.Bucket([]byte(tokensBucket))
+		return tb.Delete([]byte(id))
 	})
 
 	logrus.Debugf("Token deleted from boltdb")
 	return err
 }
 
-// AuthenticationTokenExists Checks if an authentication token exists on boltdb
-func (c *BoltDB) AuthenticationTokenExists(token string) bool {
+// AuthenticationTokenExists Checks if an authentication token of a namespace exists on boltdb
+func (c *BoltDB) AuthenticationTokenExists(ns, id string) bool {
 	var tkPresent bool
 
 	c.DB.View(func(tx *bolt.Tx) error {
-		tb := tx.Bucket([]byte(tokensBucket))
-		if tDb := tb.Get([]byte(token)); tDb != nil {
+		nsB, err := namespaceBucket(tx, ns)
+		if err != nil {
+			return err
+		}
+		tb := nsB.Bucket([]byte(tokensBucket))
+		if tDb := tb.Get([]byte(id)); tDb != nil {
 			tkPresent = true
 		}
 		return nil
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -27,6 +28,11 @@ func tearDownBoltDB(db *bolt.DB) error {
 	return os.Remove(p)
 }
 
+// defaultNamespaceBucket returns the bucket of the default namespace
+func defaultNamespaceBucket(tx *bolt.Tx) *bolt.Bucket {
+	return tx.Bucket([]byte(namespacesBucket)).Bucket([]byte(job.DefaultNamespace))
+}
+
 func randomPath() string {
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	return fmt.Sprintf("/tmp/khronos_boltdb_test_%d.db", r.Int())
@@ -46,14 +52,19 @@ func TestBoltDBConnection(t *testing.T) {
 		}
 	}()
 
-	// Check root buckets are present
-	checkBuckets := []string{jobsBucket, resultsBucket}
+	// Check root buckets and default namespace buckets are present
+	checkBuckets := []string{namespacesBucket, workflowRunsBucket, calendarsBucket, hostLimitsBucket}
 	err = c.DB.View(func(tx *bolt.Tx) error {
 		for _, cb := range checkBuckets {
 			if b := tx.Bucket([]byte(cb)); b == nil {
 				t.Errorf("Bucket %s not present", cb)
 			}
 		}
+		for _, cb := range namespaceBuckets {
+			if b := defaultNamespaceBucket(tx).Bucket([]byte(cb)); b == nil {
+				t.Errorf("Bucket %s of default namespace not present", cb)
+			}
+		}
 		return nil
 	})
 
@@ -95,6 +106,9 @@ func TestBoltDBSaveJob(t *testing.T) {
 			When:        "@every 2m",
 			Active:      false,
 			URL:         u2,
+			Method:      "POST",
+			Headers:     map[string]string{"Content-Type": "application/json"},
+			Body:        `{"msg": "hello"}`,
 		},
 	}
 
@@ -109,7 +123,7 @@ func TestBoltDBSaveJob(t *testing.T) {
 
 		// Retrieve job
 		err = c.DB.View(func(tx *bolt.Tx) error {
-			b := tx.Bucket([]byte(jobsBucket))
+			b := defaultNamespaceBucket(tx).Bucket([]byte(jobsBucket))
 			key := idToByte(j.ID)
 			if err := json.Unmarshal(b.Get(key), gotJob); err != nil {
 				return err
@@ -216,7 +230,7 @@ func TestBoltDBGetJobs(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		jobs, err := c.GetJobs(test.givenLow, test.givenHigh)
+		jobs, err := c.GetJobs(job.DefaultNamespace, test.givenLow, test.givenHigh)
 
 		// Check it should error or not
 		if test.wantError && err == nil {
@@ -291,7 +305,7 @@ func TestBoltDBGetJob(t *testing.T) {
 
 	// Check all the stored jobs retrieving one by one
 	for id := 1; id <= totalJobs; id++ {
-		gotJob, err := c.GetJob(id)
+		gotJob, err := c.GetJob(job.DefaultNamespace, id)
 
 		if err != nil {
 			t.Errorf("Job should be retrieved, it didn't: %v", err)
@@ -314,7 +328,7 @@ func TestBoltDBGetJob(t *testing.T) {
 	}
 
 	// Check not existent job
-	_, err = c.GetJob(totalJobs + 1)
+	_, err = c.GetJob(job.DefaultNamespace, totalJobs+1)
 	if err == nil {
 		t.Error("Expected error but didn't got")
 	}
@@ -324,6 +338,49 @@ func TestBoltDBGetJob(t *testing.T) {
 	}
 }
 
+func TestBoltDBSetLastScheduledRun(t *testing.T) {
+	c, err := NewBoltDB(randomPath(), 2*time.Second)
+	if err != nil {
+		t.Fatalf("Error creating bolt connection: %v", err)
+	}
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	u, _ := url.Parse("http://khronos.io/job")
+	j := &job.Job{Name: "job", Description: "old", When: "@every 1m", URL: u}
+	if err := c.SaveJob(j); err != nil {
+		t.Fatalf("Error saving job on database: %v", err)
+	}
+
+	// The job is updated while the schedule is running
+	uj, _ := c.GetJob(job.DefaultNamespace, j.ID)
+	uj.Description = "new"
+	if err := c.SaveJob(uj); err != nil {
+		t.Fatalf("Error saving job on database: %v", err)
+	}
+
+	last := time.Now().Truncate(time.Second)
+	if err := c.SetLastScheduledRun(job.DefaultNamespace, j.ID, last); err != nil {
+		t.Errorf("Last scheduled run should be stored: %v", err)
+	}
+
+	gotJob, _ := c.GetJob(job.DefaultNamespace, j.ID)
+	if gotJob.Description != "new" {
+		t.Errorf("The job update should not be reverted; got: %s", gotJob.Description)
+	}
+	if gotJob.LastScheduledRun == nil || !gotJob.LastScheduledRun.Equal(last) {
+		t.Errorf("Wrong last scheduled run; expected: %v; got: %v", last, gotJob.LastScheduledRun)
+	}
+
+	// Not existent job
+	if err := c.SetLastScheduledRun(job.DefaultNamespace, j.ID+1, last); err == nil {
+		t.Errorf("Not existent job should get an error")
+	}
+}
+
 func TestBoltDBDeleteJob(t *testing.T) {
 	boltPath := randomPath()
 	totalJobs := 5
@@ -374,7 +431,7 @@ func TestBoltDBDeleteJob(t *testing.T) {
 	// Delete one by one and check is correct
 	for _, j := range jobs {
 		// Check in database
-		if _, err := c.GetJob(j.ID); err != nil {
+		if _, err := c.GetJob(job.DefaultNamespace, j.ID); err != nil {
 			t.Errorf("Job should exists, got error: %v", err)
 		}
 
@@ -388,7 +445,7 @@ func TestBoltDBDeleteJob(t *testing.T) {
 		}
 
 		// Check not in database
-		if _, err := c.GetJob(j.ID); err == nil {
+		if _, err := c.GetJob(job.DefaultNamespace, j.ID); err == nil {
 			t.Errorf("Job shouldn't exists, should got error, didn't")
 		}
 		if rs, err := c.GetResults(j, 0, 0); err != nil || len(rs) != 0 {
@@ -415,7 +472,7 @@ func TestBoltDBJobsLength(t *testing.T) {
 	}()
 
 	// Empty databse, length is 0
-	jobLen := c.JobsLength()
+	jobLen := c.JobsLength(job.DefaultNamespace)
 	if jobLen != 0 {
 		t.Errorf("Empty database should return 0 size for stored jobs; got %d", jobLen)
 	}
@@ -436,16 +493,16 @@ func TestBoltDBJobsLength(t *testing.T) {
 		}
 	}
 	// Check saved jobs ok
-	jobLen = c.JobsLength()
+	jobLen = c.JobsLength(job.DefaultNamespace)
 	if jobLen != totalJobs {
 		t.Errorf("Wrong job length, expected: %d;got %d", totalJobs, jobLen)
 	}
 
 	// Check while deleting
 	for i := totalJobs; i > 0; i-- {
-		j, _ := c.GetJob(i)
+		j, _ := c.GetJob(job.DefaultNamespace, i)
 		c.DeleteJob(j)
-		jobLen = c.JobsLength()
+		jobLen = c.JobsLength(job.DefaultNamespace)
 		if jobLen != i-1 {
 			t.Errorf("Wrong job length, expected: %d;got %d", i-1, jobLen)
 		}
@@ -512,7 +569,7 @@ func TestBoltDBSaveResultJob(t *testing.T) {
 		// Retrieve result
 		err = c.DB.View(func(tx *bolt.Tx) error {
 			// Get main results bucket
-			rsB := tx.Bucket([]byte(resultsBucket))
+			rsB := defaultNamespaceBucket(tx).Bucket([]byte(resultsBucket))
 			// Get results bucket
 			rbKey := fmt.Sprintf(jobResultsBuckets, string(idToByte(r.Job.ID)))
 			rB := rsB.Bucket([]byte(rbKey))
@@ -871,6 +928,532 @@ func TestBoltDBResultsLength(t *testing.T) {
 	}
 }
 
+func TestBoltDBSaveGetWorkflowRun(t *testing.T) {
+	boltPath := randomPath()
+	now := time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	// Create a new boltdb connection
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Errorf("Error creating bolt connection: %v", err)
+	}
+	// Close ok
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	w := &job.WorkflowRun{
+		JobID:  1,
+		Status: job.WorkflowRunning,
+		Start:  now,
+		Steps:  []*job.WorkflowStep{{JobID: 1, ResultID: 3, Status: job.ResultOK, Start: now, Finish: now}},
+	}
+	if err := c.SaveWorkflowRun(w); err != nil {
+		t.Fatalf("Error saving workflow run on database: %v", err)
+	}
+	if w.ID != 1 {
+		t.Errorf("Wrong workflow run ID; expected: %d; got: %d", 1, w.ID)
+	}
+
+	// Update the workflow run
+	w.Status = job.WorkflowSucceeded
+	w.Finish = now.Add(time.Minute)
+	w.Steps = append(w.Steps, &job.WorkflowStep{JobID: 2, Status: job.ResultSkipped, Start: now, Finish: now})
+	if err := c.SaveWorkflowRun(w); err != nil {
+		t.Fatalf("Error saving workflow run on database: %v", err)
+	}
+	if w.ID != 1 {
+		t.Errorf("Updated workflow run should keep the ID; expected: %d; got: %d", 1, w.ID)
+	}
+
+	gotW, err := c.GetWorkflowRun(w.ID)
+	if err != nil {
+		t.Fatalf("Workflow run should be retrieved, it didn't: %v", err)
+	}
+	if !reflect.DeepEqual(gotW, w) {
+		t.Errorf("Workflow runs didn't match; expected: %#v;\ngot: %#v", w, gotW)
+	}
+
+	// Check not existent workflow run
+	if _, err = c.GetWorkflowRun(2); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+}
+
+func TestBoltDBCalendars(t *testing.T) {
+	boltPath := randomPath()
+	totalCalendars := 5
+	day := time.Date(2017, time.December, 25, 0, 0, 0, 0, time.UTC)
+
+	// Create a new boltdb connection
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Errorf("Error creating bolt connection: %v", err)
+	}
+	// Close ok
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	cals := []*job.Calendar{}
+	for i := 1; i <= totalCalendars; i++ {
+		cal := &job.Calendar{
+			Name:     fmt.Sprintf("calendar%d", i),
+			TimeZone: "UTC",
+			Windows:  []*job.Window{{Name: "christmas", Start: day, End: day.AddDate(0, 0, 1)}},
+		}
+		if err := c.SaveCalendar(cal); err != nil {
+			t.Errorf("Error saving calendar on database: %v", err)
+		}
+		if cal.ID != i {
+			t.Errorf("Wrong calendar ID; expected: %d; got: %d", i, cal.ID)
+		}
+		cals = append(cals, cal)
+	}
+
+	// Check all the stored calendars
+	gotCals, err := c.GetCalendars()
+	if err != nil {
+		t.Errorf("Calendars should be retrieved, they didn't: %v", err)
+	}
+	if !reflect.DeepEqual(gotCals, cals) {
+		t.Errorf("Calendars didn't match; expected: %#v;\ngot: %#v", cals, gotCals)
+	}
+
+	// Update and delete
+	cals[0].Windows = nil
+	if err := c.SaveCalendar(cals[0]); err != nil {
+		t.Errorf("Error saving calendar on database: %v", err)
+	}
+	if gotCal, err := c.GetCalendar(1); err != nil || len(gotCal.Windows) != 0 {
+		t.Errorf("Calendar should be updated; got: %#v, %v", gotCal, err)
+	}
+	if err := c.DeleteCalendar(cals[1]); err != nil {
+		t.Errorf("Error deleting calendar on database: %v", err)
+	}
+	if _, err := c.GetCalendar(2); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+	if gotCals, _ := c.GetCalendars(); len(gotCals) != totalCalendars-1 {
+		t.Errorf("Wrong calendars length; expected: %d; got: %d", totalCalendars-1, len(gotCals))
+	}
+}
+
+func TestBoltDBHostLimits(t *testing.T) {
+	boltPath := randomPath()
+
+	// Create a new boltdb connection
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Errorf("Error creating bolt connection: %v", err)
+	}
+	// Close ok
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	ls := []*job.HostLimit{
+		{Host: "api.example.org", Rate: 0.5, Burst: 2},
+		{Host: "example.org:8080", MaxConcurrency: 3},
+	}
+	for _, l := range ls {
+		if err := c.SaveHostLimit(l); err != nil {
+			t.Errorf("Error saving host limit on database: %v", err)
+		}
+	}
+	if err := c.SaveHostLimit(&job.HostLimit{}); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+
+	// Check all the stored host limits (ordered by host)
+	gotLs, err := c.GetHostLimits()
+	if err != nil {
+		t.Errorf("Host limits should be retrieved, they didn't: %v", err)
+	}
+	if !reflect.DeepEqual(gotLs, ls) {
+		t.Errorf("Host limits didn't match; expected: %#v;\ngot: %#v", ls, gotLs)
+	}
+
+	// Update and delete
+	ls[1].MaxConcurrency = 1
+	if err := c.SaveHostLimit(ls[1]); err != nil {
+		t.Errorf("Error saving host limit on database: %v", err)
+	}
+	if err := c.DeleteHostLimit(ls[0].Host); err != nil {
+		t.Errorf("Error deleting host limit on database: %v", err)
+	}
+	gotLs, _ = c.GetHostLimits()
+	if !reflect.DeepEqual(gotLs, ls[1:]) {
+		t.Errorf("Host limits didn't match; expected: %#v;\ngot: %#v", ls[1:], gotLs)
+	}
+}
+
+func TestBoltDBSecrets(t *testing.T) {
+	boltPath := randomPath()
+
+	// Create a new boltdb connection
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Errorf("Error creating bolt connection: %v", err)
+	}
+	// Close ok
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	updated := time.Date(2017, time.December, 25, 0, 0, 0, 0, time.UTC)
+	secs := []*job.Secret{
+		{Name: "api-token", Description: "reports API", Value: []byte{1, 2, 3}, UpdatedAt: updated},
+		{Name: "db-password", Value: []byte{4, 5, 6}, UpdatedAt: updated},
+	}
+	for _, sec := range secs {
+		if err := c.SaveSecret(job.DefaultNamespace, sec); err != nil {
+			t.Errorf("Error saving secret on database: %v", err)
+		}
+	}
+	if err := c.SaveSecret(job.DefaultNamespace, &job.Secret{}); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+
+	// Check all the stored secrets (ordered by name)
+	gotSecs, err := c.GetSecrets(job.DefaultNamespace)
+	if err != nil {
+		t.Errorf("Secrets should be retrieved, they didn't: %v", err)
+	}
+	if !reflect.DeepEqual(gotSecs, secs) {
+		t.Errorf("Secrets didn't match; expected: %#v;\ngot: %#v", secs, gotSecs)
+	}
+
+	// Update and delete
+	secs[0].Value = []byte{7, 8, 9}
+	if err := c.SaveSecret(job.DefaultNamespace, secs[0]); err != nil {
+		t.Errorf("Error saving secret on database: %v", err)
+	}
+	if gotSec, err := c.GetSecret(job.DefaultNamespace, "api-token"); err != nil || !reflect.DeepEqual(gotSec, secs[0]) {
+		t.Errorf("Secret should be updated; got: %#v, %v", gotSec, err)
+	}
+	if err := c.DeleteSecret(job.DefaultNamespace, secs[1]); err != nil {
+		t.Errorf("Error deleting secret on database: %v", err)
+	}
+	if _, err := c.GetSecret(job.DefaultNamespace, "db-password"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+
+	// The secrets are only on their namespace
+	if err := c.SaveNamespace(&job.Namespace{Name: "payments"}); err != nil {
+		t.Fatalf("Error saving namespace on database: %v", err)
+	}
+	if _, err := c.GetSecret("payments", "api-token"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+	if gotSecs, err := c.GetSecrets("payments"); err != nil || len(gotSecs) != 0 {
+		t.Errorf("Namespace should not have secrets; got: %#v, %v", gotSecs, err)
+	}
+	if err := c.SaveSecret("search", secs[0]); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+}
+
+func TestBoltDBMigrateDefaultNamespaceSecrets(t *testing.T) {
+	boltPath := randomPath()
+
+	// Create a new boltdb connection
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Error creating bolt connection: %v", err)
+	}
+
+	// Store the secrets at the top level and a namespace without secrets bucket
+	c.SaveNamespace(&job.Namespace{Name: "payments"})
+	c.DB.Update(func(tx *bolt.Tx) error {
+		tx.Bucket([]byte(namespacesBucket)).Bucket([]byte("payments")).DeleteBucket([]byte(secretsBucket))
+		defaultNamespaceBucket(tx).DeleteBucket([]byte(secretsBucket))
+		b, _ := tx.CreateBucket([]byte(secretsBucket))
+		return b.Put([]byte("api-token"), []byte(`{"Name":"api-token","Value":"AQID"}`))
+	})
+	c.DB.Close()
+
+	// Opening the database moves the secrets to the default namespace
+	c, err = NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Error creating bolt connection: %v", err)
+	}
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	if sec, err := c.GetSecret(job.DefaultNamespace, "api-token"); err != nil || !bytes.Equal(sec.Value, []byte{1, 2, 3}) {
+		t.Errorf("Secret should be moved to the default namespace; got: %#v, %v", sec, err)
+	}
+	if gotSecs, err := c.GetSecrets("payments"); err != nil || len(gotSecs) != 0 {
+		t.Errorf("Namespace should have an empty secrets bucket; got: %#v, %v", gotSecs, err)
+	}
+	c.DB.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(secretsBucket)) != nil {
+			t.Error("Top level secrets bucket should be removed")
+		}
+		return nil
+	})
+}
+
+func TestBoltDBGetJobIDs(t *testing.T) {
+	boltPath := randomPath()
+
+	// Create a new boltdb connection
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Errorf("Error creating bolt connection: %v", err)
+	}
+
+	jobs := []*job.Job{
+		{Name: "job1", Labels: map[string]string{"team": "payments", "env": "production"}},
+		{Name: "job2", Labels: map[string]string{"team": "payments", "env": "staging"}},
+		{Name: "job3", Labels: map[string]string{"team": "search", "legacy": ""}},
+		{Name: "job4"},
+	}
+	for _, j := range jobs {
+		if err := c.SaveJob(j); err != nil {
+			t.Errorf("Error saving job on database: %v", err)
+		}
+	}
+
+	checkIDs := func(selector string, want []int) {
+		sel, err := job.ParseSelector(selector)
+		if err != nil {
+			t.Fatalf("Selector should be valid: %v", err)
+		}
+		got, err := c.GetJobIDs(job.DefaultNamespace, sel)
+		if err != nil {
+			t.Errorf("Job IDs should be retrieved, they didn't: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Job IDs of '%s' didn't match; expected: %v; got: %v", selector, want, got)
+		}
+	}
+
+	checkIDs("", []int{1, 2, 3, 4})
+	checkIDs("team=payments,env!=staging", []int{1})
+	checkIDs("team in (payments,search)", []int{1, 2, 3})
+	checkIDs("env notin (production)", []int{2, 3, 4})
+	checkIDs("legacy", []int{3})
+	checkIDs("legacy=", []int{3})
+	checkIDs("!team", []int{4})
+	checkIDs("team=billing", []int{})
+
+	// Update and delete the labels of the jobs
+	jobs[0].Labels = map[string]string{"team": "search"}
+	if err := c.SaveJob(jobs[0]); err != nil {
+		t.Errorf("Error saving job on database: %v", err)
+	}
+	if err := c.DeleteJob(jobs[2]); err != nil {
+		t.Errorf("Error deleting job on database: %v", err)
+	}
+	checkIDs("team=search", []int{1})
+	checkIDs("env", []int{2})
+	checkIDs("!legacy", []int{1, 2, 4})
+
+	// The index is rebuilt from the stored jobs when missing
+	err = c.DB.Update(func(tx *bolt.Tx) error {
+		return defaultNamespaceBucket(tx).DeleteBucket([]byte(jobLabelsBucket))
+	})
+	if err != nil {
+		t.Fatalf("Error deleting the labels index: %v", err)
+	}
+	c.DB.Close()
+	c, err = NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Errorf("Error creating bolt connection: %v", err)
+	}
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+	checkIDs("team=search", []int{1})
+	checkIDs("team=payments,env=staging", []int{2})
+}
+
+func TestBoltDBNamespaces(t *testing.T) {
+	boltPath := randomPath()
+
+	// Create a new boltdb connection
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Errorf("Error creating bolt connection: %v", err)
+	}
+	// Close ok
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	created := time.Date(2017, time.December, 25, 0, 0, 0, 0, time.UTC)
+	payments := &job.Namespace{Name: "payments", Description: "Payments team", CreatedAt: created}
+	if err := c.SaveNamespace(payments); err != nil {
+		t.Errorf("Error saving namespace on database: %v", err)
+	}
+	if err := c.SaveNamespace(&job.Namespace{}); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+
+	// Check all the stored namespaces (ordered by name)
+	nss, err := c.GetNamespaces()
+	if err != nil {
+		t.Errorf("Namespaces should be retrieved, they didn't: %v", err)
+	}
+	if len(nss) != 2 || nss[0].Name != job.DefaultNamespace || !reflect.DeepEqual(nss[1], payments) {
+		t.Errorf("Namespaces didn't match; got: %#v", nss)
+	}
+
+	// The jobs of every namespace are isolated, the IDs are unique
+	jobs := []*job.Job{
+		{Name: "job1", Labels: map[string]string{"team": "search"}},
+		{Namespace: "payments", Name: "job2", Labels: map[string]string{"team": "payments"}},
+		{Namespace: "payments", Name: "job3"},
+	}
+	for _, j := range jobs {
+		if err := c.SaveJob(j); err != nil {
+			t.Errorf("Error saving job on database: %v", err)
+		}
+	}
+	if err := c.SaveJob(&job.Job{Namespace: "missing", Name: "job4"}); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+	if jobs[2].ID != 3 {
+		t.Errorf("Job IDs should be unique across the namespaces; expected: 3; got: %d", jobs[2].ID)
+	}
+	if l := c.JobsLength("payments"); l != 2 {
+		t.Errorf("Jobs length didn't match; expected: 2; got: %d", l)
+	}
+	if js, err := c.GetJobs("payments", 1, 2); err != nil || len(js) != 1 || js[0].ID != 3 {
+		t.Errorf("Jobs of namespace didn't match; got: %v, %v", js, err)
+	}
+	if _, err := c.GetJob("payments", 1); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+	if ids, err := c.GetJobIDs("payments", job.Selector{}); err != nil || !reflect.DeepEqual(ids, []int{2, 3}) {
+		t.Errorf("Job IDs of namespace didn't match; got: %v, %v", ids, err)
+	}
+	if err := c.SaveResult(&job.Result{Job: jobs[1]}); err != nil {
+		t.Errorf("Error saving result on database: %v", err)
+	}
+	if l := c.ResultsLength(jobs[1]); l != 1 {
+		t.Errorf("Results length didn't match; expected: 1; got: %d", l)
+	}
+
+	// The tokens belong to a namespace
+	if err := c.SaveAuthenticationToken("payments", &job.Token{ID: "123456789", Role: job.RoleViewer}); err != nil {
+		t.Errorf("Error saving token on database: %v", err)
+	}
+	if !c.AuthenticationTokenExists("payments", "123456789") || c.AuthenticationTokenExists(job.DefaultNamespace, "123456789") {
+		t.Error("Token should only exist on its namespace")
+	}
+
+	// Delete the namespace with all its data
+	if err := c.DeleteNamespace(payments); err != nil {
+		t.Errorf("Error deleting namespace on database: %v", err)
+	}
+	if _, err := c.GetNamespace("payments"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+	if c.AuthenticationTokenExists("payments", "123456789") {
+		t.Error("Token should be deleted with its namespace")
+	}
+	if _, err := c.GetJob(job.DefaultNamespace, 1); err != nil {
+		t.Errorf("Job of other namespace should be present: %v", err)
+	}
+}
+
+func TestBoltDBMigrateDefaultNamespace(t *testing.T) {
+	boltPath := randomPath()
+
+	// Create the layout without namespaces
+	db, err := bolt.Open(boltPath, 0600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Error creating bolt connection: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		jb, _ := tx.CreateBucket([]byte(jobsBucket))
+		for i := 1; i <= 2; i++ {
+			id, _ := jb.NextSequence()
+			buf, _ := json.Marshal(&job.Job{ID: int(id), Name: fmt.Sprintf("job%d", id), Labels: map[string]string{"team": "payments"}})
+			jb.Put(idToByte(int(id)), buf)
+		}
+		rb, _ := tx.CreateBucket([]byte(resultsBucket))
+		jrb, _ := rb.CreateBucket([]byte(fmt.Sprintf(jobResultsBuckets, string(idToByte(1)))))
+		id, _ := jrb.NextSequence()
+		buf, _ := json.Marshal(&job.Result{ID: int(id), Out: "ok"})
+		jrb.Put(idToByte(int(id)), buf)
+		tb, _ := tx.CreateBucket([]byte(tokensBucket))
+		tb.Put([]byte("123456789"), nil)
+		_, err := tx.CreateBucket([]byte(jobLabelsBucket))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Error creating the previous layout: %v", err)
+	}
+	db.Close()
+
+	// Opening the database moves everything to the default namespace
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Error creating bolt connection: %v", err)
+	}
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	j, err := c.GetJob(job.DefaultNamespace, 1)
+	if err != nil {
+		t.Fatalf("Job should be moved: %v", err)
+	}
+	if j.Name != "job1" || j.Namespace != job.DefaultNamespace {
+		t.Errorf("Job didn't match; got: %#v", j)
+	}
+	if r, err := c.GetResult(j, 1); err != nil || r.Out != "ok" {
+		t.Errorf("Result should be moved; got: %v, %v", r, err)
+	}
+	if err := c.SaveResult(&job.Result{Job: j}); err != nil || c.ResultsLength(j) != 2 {
+		t.Errorf("Results should keep their IDs; got: %d, %v", c.ResultsLength(j), err)
+	}
+	if ts, err := c.GetAuthenticationTokens(job.DefaultNamespace); err != nil || len(ts) != 1 || !ts[0].Verify("123456789") {
+		t.Errorf("Token should be moved; got: %v, %v", ts, err)
+	}
+	sel, _ := job.ParseSelector("team=payments")
+	if ids, err := c.GetJobIDs(job.DefaultNamespace, sel); err != nil || !reflect.DeepEqual(ids, []int{1, 2}) {
+		t.Errorf("Labels should be indexed again; got: %v, %v", ids, err)
+	}
+
+	// The new jobs keep going from the last ID
+	nj := &job.Job{Name: "job3"}
+	if err := c.SaveJob(nj); err != nil || nj.ID != 3 {
+		t.Errorf("New job should have the next ID; got: %d, %v", nj.ID, err)
+	}
+
+	// The previous buckets are removed
+	c.DB.View(func(tx *bolt.Tx) error {
+		for _, b := range namespaceBuckets {
+			if tx.Bucket([]byte(b)) != nil {
+				t.Errorf("Bucket %s should be removed", b)
+			}
+		}
+		return nil
+	})
+}
+
 func TestBoltDBSaveAuthToken(t *testing.T) {
 	boltPath := randomPath()
 	totalTokens := 20
@@ -890,13 +1473,13 @@ func TestBoltDBSaveAuthToken(t *testing.T) {
 	for i := 0; i < totalTokens; i++ {
 		// Create token
 		tk := fmt.Sprintf("Token:%d", i)
-		if err := c.SaveAuthenticationToken(tk); err != nil {
+		if err := c.SaveAuthenticationToken(job.DefaultNamespace, &job.Token{ID: tk, Role: job.RoleAdmin}); err != nil {
 			t.Errorf("Error storing token in boltdb: %v", err)
 		}
 
 		// Retrieve result
 		c.DB.View(func(tx *bolt.Tx) error {
-			tb := tx.Bucket([]byte(tokensBucket))
+			tb := defaultNamespaceBucket(tx).Bucket([]byte(tokensBucket))
 			// Check if result present
 			if token := tb.Get([]byte(tk)); token == nil {
 				t.Error("Token not in database, it should be")
@@ -926,13 +1509,13 @@ func TestBoltDBDeleteAuthToken(t *testing.T) {
 	for i := 0; i < totalTokens; i++ {
 		// Create token
 		tk := fmt.Sprintf("Token:%d", i)
-		if err := c.SaveAuthenticationToken(tk); err != nil {
+		if err := c.SaveAuthenticationToken(job.DefaultNamespace, &job.Token{ID: tk, Role: job.RoleAdmin}); err != nil {
 			t.Errorf("Error storing token in boltdb: %v", err)
 		}
 
 		// Retrieve result
 		c.DB.View(func(tx *bolt.Tx) error {
-			tb := tx.Bucket([]byte(tokensBucket))
+			tb := defaultNamespaceBucket(tx).Bucket([]byte(tokensBucket))
 			// Check if result present
 			if token := tb.Get([]byte(tk)); token == nil {
 				t.Error("Token not in database, it should be")
@@ -940,11 +1523,11 @@ func TestBoltDBDeleteAuthToken(t *testing.T) {
 			return nil
 		})
 
-		c.DeleteAuthenticationToken(tk)
+		c.DeleteAuthenticationToken(job.DefaultNamespace, tk)
 
 		// Check result not present
 		c.DB.View(func(tx *bolt.Tx) error {
-			tb := tx.Bucket([]byte(tokensBucket))
+			tb := defaultNamespaceBucket(tx).Bucket([]byte(tokensBucket))
 			if token := tb.Get([]byte(tk)); token != nil {
 				t.Error("Token in database, it shouldn't be")
 			}
@@ -953,6 +1536,112 @@ func TestBoltDBDeleteAuthToken(t *testing.T) {
 	}
 }
 
+func TestBoltDBGetAuthTokens(t *testing.T) {
+	boltPath := randomPath()
+
+	// Create a new boltdb connection
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Errorf("Error creating bolt connection: %v", err)
+	}
+	// Close ok
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	created := time.Date(2017, time.December, 25, 0, 0, 0, 0, time.UTC)
+	tks := []*job.Token{
+		{ID: "b", Name: "ci", Role: job.RoleOperator, Namespaces: []string{"payments"}, Selector: "team=payments", CreatedAt: created},
+		{ID: "a", Name: "dashboard", Role: job.RoleViewer, CreatedAt: created, ExpiresAt: &created},
+	}
+	for _, tk := range tks {
+		tk.SetSecret(tk.Name)
+		if err := c.SaveAuthenticationToken(job.DefaultNamespace, tk); err != nil {
+			t.Errorf("Error storing token in boltdb: %v", err)
+		}
+	}
+	if err := c.SaveAuthenticationToken(job.DefaultNamespace, &job.Token{}); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+
+	// Check all the tokens ordered by ID
+	got, err := c.GetAuthenticationTokens(job.DefaultNamespace)
+	if err != nil {
+		t.Errorf("Tokens should be retrieved, they didn't: %v", err)
+	}
+	if want := []*job.Token{tks[1], tks[0]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens didn't match; expected: %#v; got: %#v", want, got)
+	}
+
+	// Check a single token
+	tk, err := c.GetAuthenticationToken(job.DefaultNamespace, "b")
+	if err != nil {
+		t.Errorf("Token should be retrieved, it didn't: %v", err)
+	}
+	if !reflect.DeepEqual(tk, tks[0]) || !tk.Verify("ci") {
+		t.Errorf("Token didn't match; expected: %#v; got: %#v", tks[0], tk)
+	}
+	if _, err := c.GetAuthenticationToken(job.DefaultNamespace, "c"); err == nil {
+		t.Error("Expected error but didn't got")
+	}
+}
+
+func TestBoltDBHashClearTokens(t *testing.T) {
+	boltPath := randomPath()
+
+	// Create a new boltdb connection
+	c, err := NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Error creating bolt connection: %v", err)
+	}
+
+	// Store the tokens in clear text, without role and with role
+	c.DB.Update(func(tx *bolt.Tx) error {
+		tb := defaultNamespaceBucket(tx).Bucket([]byte(tokensBucket))
+		tb.Put([]byte("123456789"), nil)
+		return tb.Put([]byte("555555555"), []byte(`{"Token":"555555555","Role":"viewer","Selector":"team=payments"}`))
+	})
+	c.DB.Close()
+
+	// Opening the database hashes the tokens
+	c, err = NewBoltDB(boltPath, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Error creating bolt connection: %v", err)
+	}
+	defer func() {
+		if err := tearDownBoltDB(c.DB); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	tks, err := c.GetAuthenticationTokens(job.DefaultNamespace)
+	if err != nil || len(tks) != 2 {
+		t.Fatalf("Tokens should be hashed; got: %v, %v", tks, err)
+	}
+	for _, tk := range tks {
+		if c.AuthenticationTokenExists(job.DefaultNamespace, "123456789") || c.AuthenticationTokenExists(job.DefaultNamespace, "555555555") {
+			t.Error("Tokens in clear text should be removed")
+		}
+		if !tk.Legacy {
+			t.Errorf("Migrated tokens should be legacy; got: %#v", tk)
+		}
+		switch {
+		case tk.Verify("123456789"):
+			if tk.Role != job.RoleAdmin {
+				t.Errorf("Token without role should be admin; got: %s", tk.Role)
+			}
+		case tk.Verify("555555555"):
+			if tk.Role != job.RoleViewer || tk.Selector != "team=payments" {
+				t.Errorf("Token should keep its role and scopes; got: %#v", tk)
+			}
+		default:
+			t.Errorf("Token should be one of the previous ones; got: %#v", tk)
+		}
+	}
+}
+
 func TestBoltDBEAuthTokenExists(t *testing.T) {
 	boltPath := randomPath()
 	totalTokens := 20
@@ -972,17 +1661,17 @@ func TestBoltDBEAuthTokenExists(t *testing.T) {
 	for i := 0; i < totalTokens; i++ {
 		tk := fmt.Sprintf("Token:%d", i)
 		// Check correct token doesn't exists
-		if c.AuthenticationTokenExists(tk) {
+		if c.AuthenticationTokenExists(job.DefaultNamespace, tk) {
 			t.Errorf("Token %s shouldn't be in database, it is", tk)
 		}
 
 		// Create token
-		if err := c.SaveAuthenticationToken(tk); err != nil {
+		if err := c.SaveAuthenticationToken(job.DefaultNamespace, &job.Token{ID: tk, Role: job.RoleAdmin}); err != nil {
 			t.Errorf("Error storing token in boltdb: %v", err)
 		}
 
 		// Check correct token exists
-		if !c.AuthenticationTokenExists(tk) {
+		if !c.AuthenticationTokenExists(job.DefaultNamespace, tk) {
 			t.Errorf("Token %s should be in database, it isn't", tk)
 		}
 	}
@@ -3,7 +3,10 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 
@@ -13,10 +16,19 @@ import (
 const jobKeyFmt = "job:%d"
 const jobResultsKeyFmt = "job:%d:results"
 const resultKeyFmt = "result:%d"
+const workflowRunKeyFmt = "workflowrun:%d"
+const calendarKeyFmt = "calendar:%d"
+const hostLimitKeyFmt = "hostlimit:%s"
+const namespaceKeyFmt = "namespace:%s"
+const tokenKeyFmt = "namespace:%s:token:%s"
+const secretKeyFmt = "namespace:%s:secret:%s"
 
 // Dummy implements the Storage interface everything to a local memory map
 type Dummy struct {
 	// Our memory database
+	namespacesMutex *sync.Mutex
+	Namespaces      map[string]*job.Namespace
+
 	jobsMutex  *sync.Mutex
 	Jobs       map[string]*job.Job
 	JobCounter int
@@ -25,14 +37,34 @@ type Dummy struct {
 	Results        map[string]map[string]*job.Result
 	ResultsCounter map[string]int
 
+	workflowRunsMutex   *sync.Mutex
+	WorkflowRuns        map[string]*job.WorkflowRun
+	WorkflowRunsCounter int
+
+	calendarsMutex   *sync.Mutex
+	Calendars        map[string]*job.Calendar
+	CalendarsCounter int
+
+	hostLimitsMutex *sync.Mutex
+	HostLimits      map[string]*job.HostLimit
+
+	secretsMutex *sync.Mutex
+	Secrets      map[string]*job.Secret
+
 	TokenMutex *sync.Mutex
-	Tokens     map[string]struct{}
+	Tokens     map[string]*job.Token
 }
 
 // NewDummy creates a client that stores on memory
 func NewDummy() *Dummy {
 	logrus.Debug("New Dummy storage client created")
 	return &Dummy{
+		namespacesMutex: &sync.Mutex{},
+		// The default namespace is always present
+		Namespaces: map[string]*job.Namespace{
+			fmt.Sprintf(namespaceKeyFmt, job.DefaultNamespace): &job.Namespace{Name: job.DefaultNamespace},
+		},
+
 		jobsMutex:  &sync.Mutex{},
 		Jobs:       map[string]*job.Job{},
 		JobCounter: 0,
@@ -41,8 +73,22 @@ func NewDummy() *Dummy {
 		Results:        map[string]map[string]*job.Result{},
 		ResultsCounter: map[string]int{},
 
+		workflowRunsMutex:   &sync.Mutex{},
+		WorkflowRuns:        map[string]*job.WorkflowRun{},
+		WorkflowRunsCounter: 0,
+
+		calendarsMutex:   &sync.Mutex{},
+		Calendars:        map[string]*job.Calendar{},
+		CalendarsCounter: 0,
+
+		hostLimitsMutex: &sync.Mutex{},
+		HostLimits:      map[string]*job.HostLimit{},
+
+		secretsMutex: &sync.Mutex{},
+		Secrets:      map[string]*job.Secret{},
+
 		TokenMutex: &sync.Mutex{},
-		Tokens:     map[string]struct{}{},
+		Tokens:     map[string]*job.Token{},
 	}
 }
 
@@ -51,57 +97,174 @@ func (c *Dummy) Close() error {
 	return nil
 }
 
-// GetJobs returns all the http jobs stored on memory
-func (c *Dummy) GetJobs(low, high int) (jobs []*job.Job, err error) {
+// GetNamespaces returns all the namespaces from memory ordered by name
+func (c *Dummy) GetNamespaces() ([]*job.Namespace, error) {
+	c.namespacesMutex.Lock()
+	defer c.namespacesMutex.Unlock()
+
+	keys := []string{}
+	for k := range c.Namespaces {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	nss := []*job.Namespace{}
+	for _, k := range keys {
+		nss = append(nss, c.Namespaces[k])
+	}
+	return nss, nil
+}
+
+// GetNamespace returns a namespace from memory
+func (c *Dummy) GetNamespace(name string) (*job.Namespace, error) {
+	c.namespacesMutex.Lock()
+	defer c.namespacesMutex.Unlock()
+
+	ns, ok := c.Namespaces[fmt.Sprintf(namespaceKeyFmt, name)]
+	if !ok {
+		return nil, errors.New("Not existent namespace")
+	}
+	return ns, nil
+}
+
+// SaveNamespace stores a namespace on memory
+func (c *Dummy) SaveNamespace(ns *job.Namespace) error {
+	if ns.Name == "" {
+		return errors.New("Wrong namespace")
+	}
+	c.namespacesMutex.Lock()
+	defer c.namespacesMutex.Unlock()
+
+	c.Namespaces[fmt.Sprintf(namespaceKeyFmt, ns.Name)] = ns
+	return nil
+}
+
+// DeleteNamespace deletes a namespace with its jobs, results and tokens on memory
+func (c *Dummy) DeleteNamespace(ns *job.Namespace) error {
+	js, err := c.GetJobs(ns.Name, 0, 0)
+	if err != nil {
+		return err
+	}
+	for _, j := range js {
+		c.DeleteJob(j)
+	}
+
+	c.TokenMutex.Lock()
+	for k := range c.Tokens {
+		if strings.HasPrefix(k, fmt.Sprintf(tokenKeyFmt, ns.Name, "")) {
+			delete(c.Tokens, k)
+		}
+	}
+	c.TokenMutex.Unlock()
+
+	c.secretsMutex.Lock()
+	for k := range c.Secrets {
+		if strings.HasPrefix(k, fmt.Sprintf(secretKeyFmt, ns.Name, "")) {
+			delete(c.Secrets, k)
+		}
+	}
+	c.secretsMutex.Unlock()
+
+	c.namespacesMutex.Lock()
+	defer c.namespacesMutex.Unlock()
+	delete(c.Namespaces, fmt.Sprintf(namespaceKeyFmt, ns.Name))
+	return nil
+}
+
+// namespaceExists checks if a namespace is stored on memory
+func (c *Dummy) namespaceExists(name string) bool {
+	c.namespacesMutex.Lock()
+	defer c.namespacesMutex.Unlock()
+	_, ok := c.Namespaces[fmt.Sprintf(namespaceKeyFmt, name)]
+	return ok
+}
+
+// namespaceJobs returns the jobs of a namespace stored on memory ordered by ID
+func (c *Dummy) namespaceJobs(ns string) []*job.Job {
+	ids := []int{}
+	for _, j := range c.Jobs {
+		if j.NamespaceOrDefault() == ns {
+			ids = append(ids, j.ID)
+		}
+	}
+	sort.Ints(ids)
+
+	jobs := []*job.Job{}
+	for _, id := range ids {
+		jobs = append(jobs, c.Jobs[fmt.Sprintf(jobKeyFmt, id)])
+	}
+	return jobs
+}
+
+// GetJobs returns the http jobs of a namespace stored on memory
+func (c *Dummy) GetJobs(ns string, low, high int) ([]*job.Job, error) {
 	c.jobsMutex.Lock()
 	defer c.jobsMutex.Unlock()
 	if c.Jobs == nil {
 		return nil, errors.New("Error retrieving jobs")
 	}
+	jobs := c.namespaceJobs(ns)
 
 	// High on top means all
 	if high == 0 {
-		high = len(c.Jobs)
+		high = len(jobs)
 	}
 
 	// Check indexes ok
-	if low > high || low > len(c.Jobs) || high > len(c.Jobs) {
+	if low > high || low > len(jobs) || high > len(jobs) {
 		return nil, errors.New("wrong parameters")
 	}
-
-	// Add 1 to match the indexes
-	low++
-	high++
-	for i := low; i < high; i++ {
-		jobs = append(jobs, c.Jobs[fmt.Sprintf(jobKeyFmt, i)])
-	}
-	return jobs, nil
+	return jobs[low:high], nil
 }
 
-// GetJob returns a job from memory
-func (c *Dummy) GetJob(id int) (job *job.Job, err error) {
+// GetJob returns a job of a namespace from memory
+func (c *Dummy) GetJob(ns string, id int) (*job.Job, error) {
 	c.jobsMutex.Lock()
 	defer c.jobsMutex.Unlock()
 
 	key := fmt.Sprintf(jobKeyFmt, id)
 	j, ok := c.Jobs[key]
-	if !ok {
+	if !ok || j.NamespaceOrDefault() != ns {
 		return nil, errors.New("Not existent job")
 	}
 	return j, nil
 }
 
-// SaveJob stores a job on memory
+// SaveJob stores a job on memory, creates a new ID if the job doesn't have one
 func (c *Dummy) SaveJob(j *job.Job) error {
+	if !c.namespaceExists(j.NamespaceOrDefault()) {
+		return errors.New("Not existent namespace")
+	}
 	c.jobsMutex.Lock()
 	defer c.jobsMutex.Unlock()
 
-	c.JobCounter++
-	j.ID = c.JobCounter
+	// Starts in 1, so its safe to check with 0
+	if j.ID == 0 {
+		c.JobCounter++
+		j.ID = c.JobCounter
+	}
 	key := fmt.Sprintf(jobKeyFmt, j.ID)
 	c.Jobs[key] = j
 
-	// Never conflict (always creates a new id)
+	// Never conflict (insert or update)
+	return nil
+}
+
+// SetLastScheduledRun stores the last activation time of a job schedule on a
+// copy of the stored job
+func (c *Dummy) SetLastScheduledRun(ns string, id int, t time.Time) error {
+	c.jobsMutex.Lock()
+	defer c.jobsMutex.Unlock()
+
+	key := fmt.Sprintf(jobKeyFmt, id)
+	j, ok := c.Jobs[key]
+	if !ok || j.NamespaceOrDefault() != ns {
+		return errors.New("Not existent job")
+	}
+	nj := *j
+	t = t.UTC()
+	nj.LastScheduledRun = &t
+	c.Jobs[key] = &nj
 	return nil
 }
 
@@ -133,9 +296,29 @@ func (c *Dummy) DeleteJob(j *job.Job) error {
 	return nil
 }
 
-// JobsLength returns the number of jobs stored
-func (c *Dummy) JobsLength() int {
-	return len(c.Jobs)
+// GetJobIDs returns the IDs of the jobs of a namespace on memory matching the
+// label selector
+func (c *Dummy) GetJobIDs(ns string, sel job.Selector) ([]int, error) {
+	c.jobsMutex.Lock()
+	defer c.jobsMutex.Unlock()
+	if c.Jobs == nil {
+		return nil, errors.New("Error retrieving jobs")
+	}
+
+	ids := []int{}
+	for _, j := range c.namespaceJobs(ns) {
+		if sel.Matches(j.Labels) {
+			ids = append(ids, j.ID)
+		}
+	}
+	return ids, nil
+}
+
+// JobsLength returns the number of jobs of a namespace stored
+func (c *Dummy) JobsLength(ns string) int {
+	c.jobsMutex.Lock()
+	defer c.jobsMutex.Unlock()
+	return len(c.namespaceJobs(ns))
 }
 
 // GetResults returns results from a job on memory
@@ -197,8 +380,11 @@ func (c *Dummy) SaveResult(r *job.Result) error {
 	defer c.resultsMutex.Unlock()
 
 	resultsKey := fmt.Sprintf(jobResultsKeyFmt, r.Job.ID)
-	c.ResultsCounter[resultsKey]++
-	r.ID = c.ResultsCounter[resultsKey]
+	// Not new ID if it has already (update)
+	if r.ID == 0 {
+		c.ResultsCounter[resultsKey]++
+		r.ID = c.ResultsCounter[resultsKey]
+	}
 	results, ok := c.Results[resultsKey]
 	if !ok {
 		results = map[string]*job.Result{}
@@ -236,30 +422,233 @@ func (c *Dummy) ResultsLength(j *job.Job) int {
 	return 0
 }
 
-// SaveAuthenticationToken stores an authentication token on database
-func (c *Dummy) SaveAuthenticationToken(token string) error {
-	if token == "" {
+// GetWorkflowRun returns a workflow run from memory
+func (c *Dummy) GetWorkflowRun(id int) (*job.WorkflowRun, error) {
+	c.workflowRunsMutex.Lock()
+	defer c.workflowRunsMutex.Unlock()
+
+	w, ok := c.WorkflowRuns[fmt.Sprintf(workflowRunKeyFmt, id)]
+	if !ok {
+		return nil, errors.New("Workflow run not present")
+	}
+	return w, nil
+}
+
+// SaveWorkflowRun stores a workflow run in memory
+func (c *Dummy) SaveWorkflowRun(w *job.WorkflowRun) error {
+	c.workflowRunsMutex.Lock()
+	defer c.workflowRunsMutex.Unlock()
+
+	// Not new ID if it has already (update)
+	if w.ID == 0 {
+		c.WorkflowRunsCounter++
+		w.ID = c.WorkflowRunsCounter
+	}
+	c.WorkflowRuns[fmt.Sprintf(workflowRunKeyFmt, w.ID)] = w
+	return nil
+}
+
+// GetCalendars returns all the calendars from memory ordered by ID
+func (c *Dummy) GetCalendars() ([]*job.Calendar, error) {
+	c.calendarsMutex.Lock()
+	defer c.calendarsMutex.Unlock()
+
+	cals := []*job.Calendar{}
+	for i := 1; i <= c.CalendarsCounter; i++ {
+		if cal, ok := c.Calendars[fmt.Sprintf(calendarKeyFmt, i)]; ok {
+			cals = append(cals, cal)
+		}
+	}
+	return cals, nil
+}
+
+// GetCalendar returns a calendar from memory
+func (c *Dummy) GetCalendar(id int) (*job.Calendar, error) {
+	c.calendarsMutex.Lock()
+	defer c.calendarsMutex.Unlock()
+
+	cal, ok := c.Calendars[fmt.Sprintf(calendarKeyFmt, id)]
+	if !ok {
+		return nil, errors.New("Calendar not present")
+	}
+	return cal, nil
+}
+
+// SaveCalendar stores a calendar in memory
+func (c *Dummy) SaveCalendar(cal *job.Calendar) error {
+	c.calendarsMutex.Lock()
+	defer c.calendarsMutex.Unlock()
+
+	// Not new ID if it has already (update)
+	if cal.ID == 0 {
+		c.CalendarsCounter++
+		cal.ID = c.CalendarsCounter
+	}
+	c.Calendars[fmt.Sprintf(calendarKeyFmt, cal.ID)] = cal
+	return nil
+}
+
+// DeleteCalendar deletes a calendar from memory
+func (c *Dummy) DeleteCalendar(cal *job.Calendar) error {
+	c.calendarsMutex.Lock()
+	defer c.calendarsMutex.Unlock()
+
+	delete(c.Calendars, fmt.Sprintf(calendarKeyFmt, cal.ID))
+	return nil
+}
+
+// GetHostLimits returns all the host limits from memory ordered by host
+func (c *Dummy) GetHostLimits() ([]*job.HostLimit, error) {
+	c.hostLimitsMutex.Lock()
+	defer c.hostLimitsMutex.Unlock()
+
+	keys := []string{}
+	for k := range c.HostLimits {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	ls := []*job.HostLimit{}
+	for _, k := range keys {
+		ls = append(ls, c.HostLimits[k])
+	}
+	return ls, nil
+}
+
+// SaveHostLimit stores the limits of a host in memory
+func (c *Dummy) SaveHostLimit(l *job.HostLimit) error {
+	if l.Host == "" {
+		return errors.New("wrong host limit host")
+	}
+
+	c.hostLimitsMutex.Lock()
+	defer c.hostLimitsMutex.Unlock()
+
+	c.HostLimits[fmt.Sprintf(hostLimitKeyFmt, l.Host)] = l
+	return nil
+}
+
+// DeleteHostLimit deletes the limits of a host from memory
+func (c *Dummy) DeleteHostLimit(host string) error {
+	c.hostLimitsMutex.Lock()
+	defer c.hostLimitsMutex.Unlock()
+
+	delete(c.HostLimits, fmt.Sprintf(hostLimitKeyFmt, host))
+	return nil
+}
+
+// GetSecrets returns all the secrets of a namespace from memory ordered by name
+func (c *Dummy) GetSecrets(ns string) ([]*job.Secret, error) {
+	c.secretsMutex.Lock()
+	defer c.secretsMutex.Unlock()
+
+	keys := []string{}
+	for k := range c.Secrets {
+		if strings.HasPrefix(k, fmt.Sprintf(secretKeyFmt, ns, "")) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	secs := []*job.Secret{}
+	for _, k := range keys {
+		secs = append(secs, c.Secrets[k])
+	}
+	return secs, nil
+}
+
+// GetSecret returns a secret of a namespace from memory
+func (c *Dummy) GetSecret(ns, name string) (*job.Secret, error) {
+	c.secretsMutex.Lock()
+	defer c.secretsMutex.Unlock()
+
+	sec, ok := c.Secrets[fmt.Sprintf(secretKeyFmt, ns, name)]
+	if !ok {
+		return nil, errors.New("Secret not present")
+	}
+	return sec, nil
+}
+
+// SaveSecret stores a secret of a namespace in memory
+func (c *Dummy) SaveSecret(ns string, sec *job.Secret) error {
+	if sec.Name == "" {
+		return errors.New("wrong secret name")
+	}
+	if !c.namespaceExists(ns) {
+		return errors.New("Not existent namespace")
+	}
+
+	c.secretsMutex.Lock()
+	defer c.secretsMutex.Unlock()
+
+	c.Secrets[fmt.Sprintf(secretKeyFmt, ns, sec.Name)] = sec
+	return nil
+}
+
+// DeleteSecret deletes a secret of a namespace from memory
+func (c *Dummy) DeleteSecret(ns string, sec *job.Secret) error {
+	c.secretsMutex.Lock()
+	defer c.secretsMutex.Unlock()
+
+	delete(c.Secrets, fmt.Sprintf(secretKeyFmt, ns, sec.Name))
+	return nil
+}
+
+// GetAuthenticationTokens returns all the authentication tokens of a namespace from memory
+func (c *Dummy) GetAuthenticationTokens(ns string) ([]*job.Token, error) {
+	c.TokenMutex.Lock()
+	defer c.TokenMutex.Unlock()
+
+	keys := []string{}
+	for k := range c.Tokens {
+		if strings.HasPrefix(k, fmt.Sprintf(tokenKeyFmt, ns, "")) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	ts := []*job.Token{}
+	for _, k := range keys {
+		ts = append(ts, c.Tokens[k])
+	}
+	return ts, nil
+}
+
+// GetAuthenticationToken returns an authentication token of a namespace from memory
+func (c *Dummy) GetAuthenticationToken(ns, id string) (*job.Token, error) {
+	c.TokenMutex.Lock()
+	defer c.TokenMutex.Unlock()
+	t, ok := c.Tokens[fmt.Sprintf(tokenKeyFmt, ns, id)]
+	if !ok {
+		return nil, errors.New("Not existent token")
+	}
+	return t, nil
+}
+
+// SaveAuthenticationToken stores an authentication token of a namespace on database
+func (c *Dummy) SaveAuthenticationToken(ns string, t *job.Token) error {
+	if t.ID == "" {
 		return errors.New("Wrong token")
 	}
 	c.TokenMutex.Lock()
 	defer c.TokenMutex.Unlock()
-	c.Tokens[token] = struct{}{}
+	c.Tokens[fmt.Sprintf(tokenKeyFmt, ns, t.ID)] = t
 	return nil
 }
 
-// DeleteAuthenticationToken Deletes an authentication token from database
-func (c *Dummy) DeleteAuthenticationToken(token string) error {
+// DeleteAuthenticationToken Deletes an authentication token of a namespace from database
+func (c *Dummy) DeleteAuthenticationToken(ns, id string) error {
 	c.TokenMutex.Lock()
 	defer c.TokenMutex.Unlock()
-	delete(c.Tokens, token)
+	delete(c.Tokens, fmt.Sprintf(tokenKeyFmt, ns, id))
 	return nil
 }
 
-// AuthenticationTokenExists Checks if an authentication token exists
-func (c *Dummy) AuthenticationTokenExists(token string) bool {
+// AuthenticationTokenExists Checks if an authentication token of a namespace exists
+func (c *Dummy) AuthenticationTokenExists(ns, id string) bool {
 	c.TokenMutex.Lock()
 	defer c.TokenMutex.Unlock()
-	if _, ok := c.Tokens[token]; ok {
+	if _, ok := c.Tokens[fmt.Sprintf(tokenKeyFmt, ns, id)]; ok {
 		return true
 	}
 	return false
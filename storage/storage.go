@@ -1,32 +1,61 @@
 package storage
 
-import "github.com/slok/khronos/job"
+import (
+	"time"
+
+	"github.com/slok/khronos/job"
+)
 
 // Client implements the client of an storage
 type Client interface {
 	Close() error
 
+	// Namespace actions
+	// GetNamespaces returns all the namespaces ordered by name
+	GetNamespaces() ([]*job.Namespace, error)
+
+	// GetNamespace returns a namespace by name
+	GetNamespace(name string) (*job.Namespace, error)
+
+	// SaveNamespace stores the namespace, replacing the previous one with the
+	// same name
+	SaveNamespace(ns *job.Namespace) error
+
+	// DeleteNamespace deletes a namespace with all its jobs, results and
+	// authentication tokens
+	DeleteNamespace(ns *job.Namespace) error
+
 	// Job actions
-	// GetJobs returns an slice of job instances; the low parmeter will be the
-	// first job and the high will be the next one to the last job that will be
-	// returned; this acts like an slice operator. 0 on high parameter means all.
-	// this would be translated as jobs[low:] and 0 on low would be jobs[:high]
-	GetJobs(low, high int) ([]*job.Job, error)
+	// GetJobs returns an slice of job instances of a namespace ordered by ID;
+	// the low parmeter will be the first job and the high will be the next one
+	// to the last job that will be returned; this acts like an slice operator.
+	// 0 on high parameter means all. this would be translated as jobs[low:]
+	// and 0 on low would be jobs[:high]
+	GetJobs(ns string, low, high int) ([]*job.Job, error)
 
-	// GetJob returns a job by ID
-	GetJob(id int) (*job.Job, error)
+	// GetJob returns a job of a namespace by ID, the IDs are unique across
+	// the namespaces
+	GetJob(ns string, id int) (*job.Job, error)
 
-	// SaveJob stores the job; this method works as an insert or update, the
-	// method will know if the job needs to be updated or inserted by identifying
-	// the presence of the ID. This wil save as a batch so on an update the
-	// instance should have all the fields set
+	// SaveJob stores the job on its namespace; this method works as an insert
+	// or update, the method will know if the job needs to be updated or
+	// inserted by identifying the presence of the ID. This wil save as a batch
+	// so on an update the instance should have all the fields set
 	SaveJob(j *job.Job) error
 
+	// SetLastScheduledRun stores the last activation time of a job schedule,
+	// the rest of the job fields are not updated
+	SetLastScheduledRun(ns string, id int, t time.Time) error
+
 	// DeleteJob deletes a job
 	DeleteJob(j *job.Job) error
 
-	// JobsLength returns the number of jobs stored
-	JobsLength() int
+	// GetJobIDs returns the IDs of the jobs of a namespace whose labels match
+	// the selector ordered by ID, an empty selector returns all the jobs
+	GetJobIDs(ns string, sel job.Selector) ([]int, error)
+
+	// JobsLength returns the number of jobs stored on a namespace
+	JobsLength(ns string) int
 
 	// Result actions
 	// GetResults returns an slice of results from a job; The low parmeter will be the
@@ -47,12 +76,82 @@ type Client interface {
 	// ResultsLength returns the number of results (of a job) stored
 	ResultsLength(j *job.Job) int
 
-	// SaveAuthenticationToken stores an authentication token on database
-	SaveAuthenticationToken(token string) error
+	// Workflow actions
+	// GetWorkflowRun returns a workflow run by ID
+	GetWorkflowRun(id int) (*job.WorkflowRun, error)
+
+	// SaveWorkflowRun stores the workflow run; this method works as an insert or
+	// update identifying the presence of the ID
+	SaveWorkflowRun(w *job.WorkflowRun) error
+
+	// Calendar actions
+	// GetCalendars returns all the calendars
+	GetCalendars() ([]*job.Calendar, error)
+
+	// GetCalendar returns a calendar by ID
+	GetCalendar(id int) (*job.Calendar, error)
+
+	// SaveCalendar stores the calendar; this method works as an insert or
+	// update identifying the presence of the ID
+	SaveCalendar(cal *job.Calendar) error
+
+	// DeleteCalendar deletes a calendar
+	DeleteCalendar(cal *job.Calendar) error
+
+	// Host limit actions
+	// GetHostLimits returns all the host limits
+	GetHostLimits() ([]*job.HostLimit, error)
+
+	// SaveHostLimit stores the limits of a host, replacing the previous ones
+	SaveHostLimit(l *job.HostLimit) error
+
+	// DeleteHostLimit deletes the limits of a host
+	DeleteHostLimit(host string) error
+
+	// Secret actions
+	// GetSecrets returns all the secrets of a namespace ordered by name
+	GetSecrets(ns string) ([]*job.Secret, error)
+
+	// GetSecret returns a secret of a namespace by name
+	GetSecret(ns, name string) (*job.Secret, error)
+
+	// SaveSecret stores the secret on a namespace, replacing the previous one
+	// with the same name
+	SaveSecret(ns string, sec *job.Secret) error
+
+	// DeleteSecret deletes a secret of a namespace
+	DeleteSecret(ns string, sec *job.Secret) error
+
+	// GetAuthenticationTokens returns all the authentication tokens of a namespace ordered by ID
+	GetAuthenticationTokens(ns string) ([]*job.Token, error)
+
+	// GetAuthenticationToken returns an authentication token of a namespace by ID
+	GetAuthenticationToken(ns, id string) (*job.Token, error)
+
+	// SaveAuthenticationToken stores an authentication token of a namespace on database, replacing the previous one with the same ID
+	SaveAuthenticationToken(ns string, t *job.Token) error
+
+	// DeleteAuthenticationToken Deletes an authentication token of a namespace from database by ID
+	DeleteAuthenticationToken(ns, id string) error
+
+	// AuthenticationTokenExists Checks if an authentication token of a namespace exists by ID
+	AuthenticationTokenExists(ns, id string) bool
+}
 
-	// DeleteAuthenticationToken Deletes an authentication token from database
-	DeleteAuthenticationToken(token string) error
+// AllJobs returns the jobs of all the namespaces
+func AllJobs(c Client) ([]*job.Job, error) {
+	nss, err := c.GetNamespaces()
+	if err != nil {
+		return nil, err
+	}
 
-	// AuthenticationTokenExists Checks if an authentication token exists
-	AuthenticationTokenExists(token string) bool
+	jobs := []*job.Job{}
+	for _, ns := range nss {
+		js, err := c.GetJobs(ns.Name, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, js...)
+	}
+	return jobs, nil
 }